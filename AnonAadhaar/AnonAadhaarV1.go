@@ -42,7 +42,7 @@ var (
 		{basicPerson.DocumentIssuer, zero},
 	}
 
-	countryOfIssuance = "IND" // iso3166 country code for India
+	countryOfIssuance = common.MustLookupCountry("IND").Alpha3 // India
 )
 
 func calculateDOE(issuanceDate time.Time) time.Time {
@@ -62,6 +62,9 @@ type AnonAadhaarV1Inputs struct {
 	NullifierSeed int    `json:"nullifierSeed"` // nullifierSeed
 	SignalHash    int    `json:"signalHash"`    // signalHash
 	TimeNow       int64  `json:"timeNow"`       // current time in seconds since epoch
+	// Optional registry of accepted UIDAI keys. When set, PubKey is checked
+	// against it before the inputs are built
+	KeyRegistry *KeyRegistry `json:"-"`
 }
 
 type anonAadhaarV1CircuitInputs struct {
@@ -82,11 +85,29 @@ type anonAadhaarV1CircuitInputs struct {
 	Siblings            [][]string `json:"siblings"`
 }
 
+// validate parses QRData and checks that it is signed with PubKey. When
+// KeyRegistry is set, PubKey must be accepted by it.
+func (a *AnonAadhaarV1Inputs) validate() (*AnonAadhaarDataV2, error) {
+	qr := &AnonAadhaarDataV2{}
+	if err := qr.UnmarshalQR(a.QRData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal QRData: %w", err)
+	}
+	if a.KeyRegistry != nil {
+		if _, err := a.KeyRegistry.CheckPEM([]byte(a.PubKey)); err != nil {
+			return nil, fmt.Errorf("failed to check pubKey: %w", err)
+		}
+	}
+	// The circuit fails to prove a QR which is not signed with the key.
+	if err := qr.VerifySignature([]byte(a.PubKey)); err != nil {
+		return nil, fmt.Errorf("failed to verify QRData: %w", err)
+	}
+	return qr, nil
+}
+
 func (a *AnonAadhaarV1Inputs) W3CCredential() (*verifiable.W3CCredential, error) {
-	QR := &AnonAadhaarDataV2{}
-	err := QR.UnmarshalQR(a.QRData)
+	QR, err := a.validate()
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal QRData: %w", err)
+		return nil, err
 	}
 
 	credentialSubject := map[string]interface{}{
@@ -142,10 +163,9 @@ func (a *AnonAadhaarV1Inputs) InputsMarshal() ([]byte, error) {
 	}
 	templateRoot := tmpl.Root()
 
-	ah := &AnonAadhaarDataV2{}
-	err = ah.UnmarshalQR(a.QRData)
+	ah, err := a.validate()
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal QRData: %w", err)
+		return nil, err
 	}
 
 	// List of values to hash
@@ -228,7 +248,7 @@ func (a *AnonAadhaarV1Inputs) InputsMarshal() ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract pubkey: %w", err)
 	}
-	pk, err := splitToWords(p, big.NewInt(121), big.NewInt(17))
+	pk, err := splitRSAWords(p)
 	if err != nil {
 		return nil, fmt.Errorf("failed to split pubkey: %w", err)
 	}
@@ -331,6 +351,17 @@ func (a *AnonAadhaarV1PubSignals) PubSignalsUnmarshal(data []byte) error {
 	return nil
 }
 
+// VerifyPubKeyHash checks the UIDAI key the proof was generated with against
+// the key registry. In strict mode, test keys and unknown keys are rejected.
+// A nil registry accepts every key, like a nil AnonAadhaarV1Inputs.KeyRegistry.
+func (a *AnonAadhaarV1PubSignals) VerifyPubKeyHash(registry *KeyRegistry) error {
+	if registry == nil {
+		return nil
+	}
+	_, err := registry.CheckHash(a.PubKeyHash)
+	return err
+}
+
 // GetObjMap returns struct field as a map.
 func (a *AnonAadhaarV1PubSignals) GetObjMap() map[string]interface{} {
 	out := make(map[string]interface{})
@@ -362,26 +393,14 @@ func prepareInputs(data *AnonAadhaarDataV2) (*qrParts, error) {
 		return nil, fmt.Errorf("failed to verify data: %w", err)
 	}
 
-	dataPadded, dataPaddedLen, err := sha256Pad(data.rawdata, 512*3)
+	dataPadded, dataPaddedLen, err := common.SHA256Pad(data.rawdata, maxQRDataPadBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pad data: %w", err)
 	}
 
-	var delimiterIndices []int
-	for i, b := range data.rawdata {
-		if b == 255 {
-			delimiterIndices = append(delimiterIndices, i)
-		}
-		if len(delimiterIndices) == 18 {
-			break
-		}
-	}
+	delimiterIndices := findDelimiters(data.rawdata)
 
-	signatureParts, err := splitToWords(
-		big.NewInt(0).SetBytes(data.signature),
-		big.NewInt(121),
-		big.NewInt(17),
-	)
+	signatureParts, err := splitRSAWords(big.NewInt(0).SetBytes(data.signature))
 	if err != nil {
 		return nil, fmt.Errorf("failed to split signature: %w", err)
 	}
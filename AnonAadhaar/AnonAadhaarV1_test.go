@@ -44,6 +44,21 @@ Q5I3LVZhZ3abc1uhLKNYD5GcG9i6cMTCqwrPKwm8L66YHzwClabh6fJI9QBzCU/6
 	require.JSONEq(t, string(expected), string(inputsMarshal))
 }
 
+func TestAnonAadhaarInputsMarshalV1_InvalidSignature(t *testing.T) {
+	bi, ok := big.NewInt(0).SetString(testdata, 10)
+	require.True(t, ok)
+	inputs := AnonAadhaarV1Inputs{
+		QRData:              bi,
+		IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		PubKey:              string(certificatePEM),
+	}
+	_, err := inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
 func TestLatestQR(t *testing.T) {
 	testDataLatest := "695108307045527203055615552134508634869313570553666038981516495163024606686331047866122668409600226925739924893071848414592481335424213584552753291786592939775072103117734818539256508832977708285847841015059559605863774303038302656349752893941881228757909093356158777312001076515703003069270314684506740151408562554687282897620399029276123086022471216698306684853607833641088998702587121769034284067244504349495896476559629455171007417135602941831212413748772309495421790796800593664480405732055819523144191517261133182486977695617103732047134680782075119216709770201511300756012070432334124054405235736909118586930655767904546932773444833614517050245926793832856072579336685572554956176486136018405194370018949537431342597817615642511126765383497527044075934491821346401991670475738449275992574588121498425412764928177474862670723747918472073471473907629224575396602023804751537435684958439304981404413943293977529969017643003462437170136830223830779392043801483506222427742472096635118228799985413436700606150533836994393566861634444888946568423412418398182944932557558673444181691672995543033402380849569288061442537262987130078005494906181956004256839466603233411326709427120017914108081478908557254065314227665902662447290282051123948049639446093506382097643209569059230995159036957942757734405205858318768265704102826105780114152264797022307373500785133790674854473648086457721067256913022806027477483486722050604545165388372693213176582919578420007588707629919875621884721874680339513702814697626878311580641322434758194191843879240706920337317202269323533069377854028512151415405647159931101362984355233648516171799322931603928079937332743834019887902707218015029755219636197181650551826766498043525488866528714972491839417984535199205321206907705638477872989467929564200933404416065672953370469755795005660224938584913675002703098385661382524581078619768488937970221844030333431889603140620308440161595608319938893105946745222799079961164315680188736851985359545743919311557620366610626082741415849933533588214629290545298757018325441444661947996347419719016587646985238919494885369622168935380136091039667579892682373455425547256058872990912658450242805003080184999495827256856281540141277202346320593476133280799966293411808088766229785247989977270920487858120041702732555579215698961605001611080350909869425891851002500464737648985561188235520357650718124587721510956030579455241890003626930791768759082044181165554172003945142514954060392533629533811746749728120883038401680270197987816820628320107936814775326656548348932340465838917045707361942013223561624899595434147762790909018087612914982176645308240725926529385065917281757786294671696940198619254175278103610892053880528543101721906431428452494452604335072106960191047138171733126158358747690178671851934935474403347435666165598282199961104298662217886211153837780176280426239288811010729649252784361592992717160016580016706006474468462072012972787077457530409731951803930138629983184470827739771241437773236358460934820548144374074453868379263749834455474422932083208765111542026038632709693759615556530972152115647140198005849550756069240397564027946567973982374470878485995773123172584724710911121715322581234893611157257180865907808606351556587343186599891515120742364659963809865296839985442766693947728610017920732148290111662035369534531752197423978839784999794667341185327825178775185490889231092846495"
 
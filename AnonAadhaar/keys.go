@@ -0,0 +1,159 @@
+package anonaadhaar
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/0xPolygonID/go-circuit-external/common"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// The circuit takes the RSA modulus and the signature as 17 words of 121 bits.
+const (
+	rsaWordSize  = 121
+	rsaWordCount = 17
+)
+
+// KeyEnvironment tags a UIDAI key as a production or a test key.
+type KeyEnvironment string
+
+const (
+	KeyProduction KeyEnvironment = "production"
+	KeyTest       KeyEnvironment = "test"
+)
+
+// Key registry failures in strict mode.
+var (
+	ErrUnknownKey = errors.New("unknown UIDAI public key")
+	ErrTestKey    = errors.New("UIDAI test key is not accepted")
+)
+
+// UIDAIKey is a UIDAI public key known to the registry.
+type UIDAIKey struct {
+	Environment KeyEnvironment
+	PublicKey   *rsa.PublicKey
+	// Hash is the Poseidon hash of the modulus, equal to the pubKeyHash
+	// output of the circuit
+	Hash *big.Int
+}
+
+// PubKeyHash returns the Poseidon hash of the RSA modulus the circuit
+// outputs as pubKeyHash. The modulus words are packed in pairs before
+// hashing, as PoseidonLarge of anon-aadhaar does.
+// https://github.com/anon-aadhaar/anon-aadhaar/blob/main/packages/circuits/src/helpers/signature.circom
+func PubKeyHash(pub *rsa.PublicKey) (*big.Int, error) {
+	words, err := splitRSAWords(pub.N)
+	if err != nil {
+		return nil, err
+	}
+	shift := new(big.Int).Lsh(big.NewInt(1), rsaWordSize)
+	packed := make([]*big.Int, 0, (len(words)+1)/2)
+	for i := 0; i < len(words); i += 2 {
+		value := new(big.Int).Set(words[i])
+		if i+1 < len(words) {
+			value.Add(value, new(big.Int).Mul(shift, words[i+1]))
+		}
+		packed = append(packed, value)
+	}
+	hash, err := poseidon.Hash(packed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash public key: %w", err)
+	}
+	return hash, nil
+}
+
+// KeyRegistry holds the UIDAI public keys accepted by the issuer. In strict
+// mode, test keys and keys missing from the registry are rejected. The
+// registry is safe for concurrent use.
+type KeyRegistry struct {
+	strict bool
+	mu     sync.RWMutex
+	keys   map[string]*UIDAIKey // by pubKeyHash
+}
+
+// NewKeyRegistry creates an empty key registry. The mode cannot be changed
+// afterwards.
+func NewKeyRegistry(strict bool) *KeyRegistry {
+	return &KeyRegistry{strict: strict, keys: map[string]*UIDAIKey{}}
+}
+
+// Strict reports whether the registry is in strict mode.
+func (r *KeyRegistry) Strict() bool {
+	return r.strict
+}
+
+// AddPEM adds the PEM encoded UIDAI public key or certificate. The validity
+// period of a certificate is not checked: the circuit does not check it, and
+// a QR code signed before the certificate expired stays valid.
+func (r *KeyRegistry) AddPEM(env KeyEnvironment, data []byte) (*UIDAIKey, error) {
+	if env != KeyProduction && env != KeyTest {
+		return nil, fmt.Errorf("unknown key environment '%s'", env)
+	}
+	pub, err := parseRSAPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	hash, err := PubKeyHash(pub)
+	if err != nil {
+		return nil, err
+	}
+	key := &UIDAIKey{Environment: env, PublicKey: pub, Hash: hash}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.keys[hash.String()]; ok && existing.Environment != env {
+		return nil, fmt.Errorf("public key is already registered as %s key", existing.Environment)
+	}
+	r.keys[hash.String()] = key
+	return key, nil
+}
+
+// LoadFile adds the UIDAI certificate or public key from a PEM file.
+func (r *KeyRegistry) LoadFile(env KeyEnvironment, path string) error {
+	//nolint:gosec // path is provided by the issuer configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read UIDAI key file '%s': %w", path, err)
+	}
+	if _, err = r.AddPEM(env, data); err != nil {
+		return fmt.Errorf("failed to load UIDAI key file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// CheckPEM looks up the PEM encoded public key. It returns nil for keys
+// missing from the registry outside of strict mode.
+func (r *KeyRegistry) CheckPEM(data []byte) (*UIDAIKey, error) {
+	pub, err := parseRSAPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	hash, err := PubKeyHash(pub)
+	if err != nil {
+		return nil, err
+	}
+	return r.CheckHash(hash.String())
+}
+
+// CheckHash looks up the public key by the pubKeyHash output of the
+// circuit. It returns nil for keys missing from the registry outside of
+// strict mode.
+func (r *KeyRegistry) CheckHash(hash string) (*UIDAIKey, error) {
+	r.mu.RLock()
+	key, ok := r.keys[hash]
+	r.mu.RUnlock()
+	switch {
+	case !ok && r.strict:
+		return nil, fmt.Errorf("%w: pubKeyHash %s", ErrUnknownKey, hash)
+	case ok && key.Environment == KeyTest && r.strict:
+		return nil, fmt.Errorf("%w: pubKeyHash %s", ErrTestKey, hash)
+	}
+	return key, nil
+}
+
+func splitRSAWords(n *big.Int) ([]*big.Int, error) {
+	return common.SplitToWords(n, big.NewInt(rsaWordSize), big.NewInt(rsaWordCount))
+}
@@ -0,0 +1,134 @@
+package anonaadhaar
+
+import (
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPubKeyHash = "15134874015316324267425466444584014077184337590635665158241104437045239495873"
+
+func TestPubKeyHash(t *testing.T) {
+	pub, err := parseRSAPublicKey(publicKeyPEM)
+	require.NoError(t, err)
+	hash, err := PubKeyHash(pub)
+	require.NoError(t, err)
+	require.Equal(t, testPubKeyHash, hash.String())
+}
+
+func TestKeyRegistry(t *testing.T) {
+	strict := NewKeyRegistry(true)
+	require.NoError(t, strict.LoadFile(KeyTest, "testdata/uidai_test_key.pem"))
+	require.NoError(t, strict.LoadFile(KeyProduction, "testdata/uidai_production.pem"))
+	lenient := NewKeyRegistry(false)
+	require.NoError(t, lenient.LoadFile(KeyTest, "testdata/uidai_test_key.pem"))
+	require.True(t, strict.Strict())
+	require.False(t, lenient.Strict())
+
+	productionPEM, err := os.ReadFile("testdata/uidai_production.pem")
+	require.NoError(t, err)
+	key, err := strict.CheckPEM(productionPEM)
+	require.NoError(t, err)
+	require.Equal(t, KeyProduction, key.Environment)
+
+	_, err = strict.CheckPEM(publicKeyPEM)
+	require.ErrorIs(t, err, ErrTestKey)
+	_, err = strict.CheckHash("1")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	key, err = lenient.CheckPEM(publicKeyPEM)
+	require.NoError(t, err)
+	require.Equal(t, KeyTest, key.Environment)
+	require.Equal(t, testPubKeyHash, key.Hash.String())
+	key, err = lenient.CheckPEM(productionPEM)
+	require.NoError(t, err)
+	require.Nil(t, key)
+
+	// The certificate of the test key has the same hash.
+	_, err = strict.AddPEM(KeyTest, testAssetsCertificatePEM)
+	require.NoError(t, err)
+	_, err = strict.AddPEM(KeyProduction, testAssetsCertificatePEM)
+	require.Error(t, err)
+	_, err = strict.AddPEM("staging", publicKeyPEM)
+	require.Error(t, err)
+	require.Error(t, strict.LoadFile(KeyTest, "testdata/missing.pem"))
+	require.Error(t, strict.LoadFile(KeyTest, "testdata/inputs.json"))
+}
+
+func TestKeyRegistry_ExpiredCertificate(t *testing.T) {
+	// testdata/uidai_production.pem expired on 2024-02-27.
+	productionPEM, err := os.ReadFile("testdata/uidai_production.pem")
+	require.NoError(t, err)
+
+	for _, registry := range []*KeyRegistry{NewKeyRegistry(true), NewKeyRegistry(false)} {
+		_, err = registry.AddPEM(KeyProduction, productionPEM)
+		require.NoError(t, err)
+		key, err := registry.CheckPEM(productionPEM)
+		require.NoError(t, err)
+		require.Equal(t, KeyProduction, key.Environment)
+	}
+}
+
+func TestKeyRegistry_Concurrent(t *testing.T) {
+	registry := NewKeyRegistry(true)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := registry.AddPEM(KeyProduction, publicKeyPEM)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := registry.CheckHash(testPubKeyHash)
+			if err != nil {
+				assert.ErrorIs(t, err, ErrUnknownKey)
+			}
+		}()
+	}
+	wg.Wait()
+	key, err := registry.CheckHash(testPubKeyHash)
+	require.NoError(t, err)
+	require.Equal(t, KeyProduction, key.Environment)
+}
+
+func TestAnonAadhaarV1_StrictKeyRegistry(t *testing.T) {
+	registry := NewKeyRegistry(true)
+	_, err := registry.AddPEM(KeyTest, publicKeyPEM)
+	require.NoError(t, err)
+
+	qrData, ok := big.NewInt(0).SetString(testdata, 10)
+	require.True(t, ok)
+	inputs := AnonAadhaarV1Inputs{
+		QRData:              qrData,
+		IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusID:  "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		PubKey:              string(publicKeyPEM),
+		KeyRegistry:         registry,
+	}
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrTestKey)
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrTestKey)
+
+	signals := &AnonAadhaarV1PubSignals{PubKeyHash: testPubKeyHash}
+	require.ErrorIs(t, signals.VerifyPubKeyHash(registry), ErrTestKey)
+	require.ErrorIs(t, signals.VerifyPubKeyHash(NewKeyRegistry(true)), ErrUnknownKey)
+	require.NoError(t, signals.VerifyPubKeyHash(nil))
+
+	lenient := NewKeyRegistry(false)
+	_, err = lenient.AddPEM(KeyTest, publicKeyPEM)
+	require.NoError(t, err)
+	inputs.KeyRegistry = lenient
+	_, err = inputs.InputsMarshal()
+	require.NoError(t, err)
+	_, err = inputs.W3CCredential()
+	require.NoError(t, err)
+	require.NoError(t, signals.VerifyPubKeyHash(lenient))
+}
@@ -0,0 +1,85 @@
+package anonaadhaar
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygonID/go-circuit-external/common"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// The photo follows the 18th delimiter of the QR data. The circuit packs it
+// into 32 field elements of 31 bytes.
+const (
+	photoPosition     = 18
+	photoPackSize     = 32
+	maxFieldByteSize  = 31
+	maxQRDataPadBytes = 512 * 3
+)
+
+// Nullifier computes the nullifier output of the circuit for the QR data
+// and the nullifier seed. The circuit takes the photo bytes from the 18th
+// delimiter up to the end of the SHA-256 padded data, packs them big-endian
+// into 31 byte field elements and hashes them with the seed:
+// Poseidon(seed, Poseidon(photo[0:16]), Poseidon(photo[16:32])).
+// https://github.com/anon-aadhaar/anon-aadhaar/blob/main/packages/circuits/src/helpers/nullifier.circom
+func (a *AnonAadhaarDataV2) Nullifier(nullifierSeed int) (*big.Int, error) {
+	photo, err := a.packPhoto()
+	if err != nil {
+		return nil, err
+	}
+	first, err := poseidon.Hash(photo[:photoPackSize/2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash photo: %w", err)
+	}
+	last, err := poseidon.Hash(photo[photoPackSize/2:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash photo: %w", err)
+	}
+	nullifier, err := poseidon.Hash([]*big.Int{big.NewInt(int64(nullifierSeed)), first, last})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash nullifier: %w", err)
+	}
+	return nullifier, nil
+}
+
+func (a *AnonAadhaarDataV2) packPhoto() ([]*big.Int, error) {
+	dataPadded, dataPaddedLen, err := common.SHA256Pad(a.rawdata, maxQRDataPadBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pad data: %w", err)
+	}
+	delimiterIndices := findDelimiters(a.rawdata)
+	if len(delimiterIndices) != photoPosition {
+		return nil, errors.New("photo delimiter not found in QR data")
+	}
+	photo := dataPadded[delimiterIndices[photoPosition-1]+1 : dataPaddedLen]
+	if len(photo) > photoPackSize*maxFieldByteSize {
+		return nil, fmt.Errorf("photo size %d exceeds %d bytes",
+			len(photo), photoPackSize*maxFieldByteSize)
+	}
+
+	packed := make([]*big.Int, photoPackSize)
+	for i := range packed {
+		chunk := make([]byte, maxFieldByteSize)
+		if start := i * maxFieldByteSize; start < len(photo) {
+			copy(chunk, photo[start:])
+		}
+		packed[i] = new(big.Int).SetBytes(chunk)
+	}
+	return packed, nil
+}
+
+// findDelimiters returns the indices of the delimiters preceding the photo.
+func findDelimiters(data []byte) []int {
+	var delimiterIndices []int
+	for i, b := range data {
+		if len(delimiterIndices) == photoPosition {
+			break
+		}
+		if b == delimiter {
+			delimiterIndices = append(delimiterIndices, i)
+		}
+	}
+	return delimiterIndices
+}
@@ -0,0 +1,39 @@
+package anonaadhaar
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullifier(t *testing.T) {
+	publicInputs, err := os.ReadFile("testdata/outputs.json")
+	require.NoError(t, err)
+	signals := &AnonAadhaarV1PubSignals{}
+	require.NoError(t, signals.PubSignalsUnmarshal(publicInputs))
+
+	qrData, ok := big.NewInt(0).SetString(testdata, 10)
+	require.True(t, ok)
+	qr := &AnonAadhaarDataV2{}
+	require.NoError(t, qr.UnmarshalQR(qrData))
+
+	nullifier, err := qr.Nullifier(signals.NullifierSeed)
+	require.NoError(t, err)
+	require.Equal(t, signals.Nullifier, nullifier.String())
+
+	other, err := qr.Nullifier(signals.NullifierSeed + 1)
+	require.NoError(t, err)
+	require.NotEqual(t, nullifier, other)
+
+	noPhoto := *qr
+	noPhoto.rawdata = []byte("V2\xff3\xffnot delimited")
+	_, err = noPhoto.Nullifier(signals.NullifierSeed)
+	require.Error(t, err)
+
+	largePhoto := *qr
+	largePhoto.rawdata = append(append([]byte{}, qr.rawdata...), make([]byte, 64)...)
+	_, err = largePhoto.Nullifier(signals.NullifierSeed)
+	require.Error(t, err)
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"compress/gzip"
 	"compress/zlib"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -13,6 +16,21 @@ import (
 	"time"
 )
 
+// ErrInvalidSignature is returned when the QR data is not signed with the
+// provided UIDAI key.
+var ErrInvalidSignature = errors.New("invalid QR signature")
+
+// Malformed QR data errors returned by UnmarshalQR.
+var (
+	ErrQRCompression        = errors.New("invalid QR compression")
+	ErrQRTooShort           = errors.New("QR data is too short")
+	ErrQRTooLarge           = errors.New("QR data is too large")
+	ErrQRMissingDelimiters  = errors.New("QR data is missing delimiters")
+	ErrQRInvalidReferenceID = errors.New("invalid QR reference ID")
+	ErrQRInvalidDate        = errors.New("invalid QR date")
+	ErrQRInvalidField       = errors.New("invalid QR field")
+)
+
 type GenderString string
 
 const (
@@ -61,6 +79,14 @@ const (
 	delimiter = byte(255)
 	istOffset = 19800
 
+	signatureSize = 256
+	// Uncompressed QR data is a few kilobytes, the limit guards against
+	// decompression bombs.
+	maxQRDataSize = 64 * 1024
+	// The reference ID starts with the last 4 digits of the Aadhaar number
+	// and the signed time, YYYYMMDDHH.
+	referenceIDMinLength = 14
+
 	mm_dd_yyyy_template = "02-01-2006"
 )
 
@@ -129,51 +155,110 @@ func createDecompressor(data []byte) (io.ReadCloser, error) {
 // verify check formats.
 func (a *AnonAadhaarDataV2) verify() error {
 	if a.SignedTime.IsZero() {
-		return errors.New("signed time is not set")
+		return fmt.Errorf("%w: signed time is not set", ErrQRInvalidField)
 	}
 	if !IsValidGenderString(GenderString(a.Gender)) {
-		return fmt.Errorf("invalid gender: '%s'", a.Gender)
+		return fmt.Errorf("%w: invalid gender: '%s'", ErrQRInvalidField, a.Gender)
 	}
 	if a.Address.PinCode == "" {
-		return errors.New("pin code is empty")
+		return fmt.Errorf("%w: pin code is empty", ErrQRInvalidField)
 	}
 	if a.Address.State == "" {
-		return errors.New("state is empty")
+		return fmt.Errorf("%w: state is empty", ErrQRInvalidField)
+	}
+	if len(a.signature) != signatureSize {
+		return fmt.Errorf("%w: signature length is not %d: %d",
+			ErrQRInvalidField, signatureSize, len(a.signature))
+	}
+	return nil
+}
+
+// VerifySignature checks the RSA PKCS #1 v1.5 SHA-256 signature of the QR
+// data with the PEM encoded UIDAI public key or certificate.
+func (a *AnonAadhaarDataV2) VerifySignature(pubKeyPEM []byte) error {
+	pub, err := parseRSAPublicKey(pubKeyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
 	}
-	if len(a.signature) != 256 {
-		return fmt.Errorf("signature length is not 256: %d", len(a.signature))
+	hashed := sha256.Sum256(a.rawdata)
+	if err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], a.signature); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
 	}
 	return nil
 }
 
+// VerifyQR unmarshals the QR data and checks its signature with the PEM
+// encoded UIDAI public key or certificate. Issuers can use it to check a QR
+// code without building circuit inputs.
+func VerifyQR(data *big.Int, pubKeyPEM []byte) (*AnonAadhaarDataV2, error) {
+	qr := &AnonAadhaarDataV2{}
+	if err := qr.UnmarshalQR(data); err != nil {
+		return nil, err
+	}
+	if err := qr.VerifySignature(pubKeyPEM); err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
+
+// UnmarshalQR decompresses and parses the QR data. Malformed data is
+// rejected with one of the ErrQR errors.
 func (a *AnonAadhaarDataV2) UnmarshalQR(data *big.Int) error {
+	if data == nil || data.Sign() <= 0 {
+		return fmt.Errorf("%w: no data", ErrQRTooShort)
+	}
 	r, err := createDecompressor(data.Bytes())
 	if err != nil {
-		return fmt.Errorf("failed to create zlib/gzip reader: %w", err)
+		return fmt.Errorf("%w: failed to create zlib/gzip reader: %w", ErrQRCompression, err)
 	}
 	//nolint:errcheck // Ignore close error
 	defer r.Close()
-	uncompressedData, err := io.ReadAll(r)
+	uncompressedData, err := io.ReadAll(io.LimitReader(r, maxQRDataSize+1))
 	if err != nil {
-		return fmt.Errorf("failed to read compressed data: %w", err)
+		return fmt.Errorf("%w: failed to read compressed data: %w", ErrQRCompression, err)
+	}
+	if len(uncompressedData) > maxQRDataSize {
+		return fmt.Errorf("%w: uncompressed data exceeds %d bytes", ErrQRTooLarge, maxQRDataSize)
 	}
+	return a.unmarshalQRData(uncompressedData)
+}
 
-	a.signature = uncompressedData[len(uncompressedData)-256:]
+func (a *AnonAadhaarDataV2) unmarshalQRData(uncompressedData []byte) error {
+	if len(uncompressedData) <= signatureSize {
+		return fmt.Errorf("%w: %d bytes", ErrQRTooShort, len(uncompressedData))
+	}
+	a.signature = uncompressedData[len(uncompressedData)-signatureSize:]
 
 	// remove signature
-	d := uncompressedData[:len(uncompressedData)-256]
+	d := uncompressedData[:len(uncompressedData)-signatureSize]
 	a.rawdata = d
 
 	// remove photo part
 	parts := bytes.Split(d, []byte{delimiter})
-	partsWithoutPhoto := parts[:18]
-	photo := parts[18:]
+	if len(parts) <= photoPosition {
+		return fmt.Errorf("%w: expected %d delimiters, got %d",
+			ErrQRMissingDelimiters, photoPosition, len(parts)-1)
+	}
+	partsWithoutPhoto := parts[:photoPosition]
+	photo := parts[photoPosition:]
+
+	referenceID := partsWithoutPhoto[2]
+	if len(referenceID) < referenceIDMinLength {
+		return fmt.Errorf("%w: '%s' is shorter than %d characters",
+			ErrQRInvalidReferenceID, referenceID, referenceIDMinLength)
+	}
+	for _, c := range referenceID {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("%w: '%s' is not numeric", ErrQRInvalidReferenceID, referenceID)
+		}
+	}
 
 	// convert dob to time
 	dob, err := time.Parse(mm_dd_yyyy_template, string(partsWithoutPhoto[4]))
 	if err != nil {
 		return fmt.Errorf(
-			"failed to parse date of birth '%s': %w",
+			"%w: failed to parse date of birth '%s': %w",
+			ErrQRInvalidDate,
 			string(partsWithoutPhoto[4]),
 			err,
 		)
@@ -181,15 +266,15 @@ func (a *AnonAadhaarDataV2) UnmarshalQR(data *big.Int) error {
 
 	a.Version = string(partsWithoutPhoto[0])
 	a.ContactIndecator = string(partsWithoutPhoto[1])
-	a.ReferenceID = string(partsWithoutPhoto[2])
-	a.PassportLastDigits = string(partsWithoutPhoto[2][:4])
+	a.ReferenceID = string(referenceID)
+	a.PassportLastDigits = string(referenceID[:4])
 	sigtime, err := time.Parse(
 		"2006010215",
-		string(partsWithoutPhoto[2][4:14]),
+		string(referenceID[4:14]),
 	) // format: YYYYMMDDHH (24 hours representation)
 	if err != nil {
-		return fmt.Errorf("failed to parse signed time '%s': %w",
-			string(partsWithoutPhoto[2][4:14]), err)
+		return fmt.Errorf("%w: failed to parse signed time '%s': %w",
+			ErrQRInvalidDate, string(referenceID[4:14]), err)
 	}
 	a.SignedTime = sigtime.Add(-istOffset * time.Second)
 	a.Name = string(partsWithoutPhoto[3])
@@ -213,16 +298,6 @@ func (a *AnonAadhaarDataV2) UnmarshalQR(data *big.Int) error {
 		(bytes.Join(photo, []byte{delimiter})),
 	)
 
-	var delimiterIndices []int
-	for i, b := range a.rawdata {
-		if b == 255 {
-			delimiterIndices = append(delimiterIndices, i)
-		}
-		if len(delimiterIndices) == 18 {
-			break
-		}
-	}
-
 	if err = a.verify(); err != nil {
 		return fmt.Errorf("failed to unmarshal from QR: %w", err)
 	}
@@ -1,6 +1,8 @@
 package anonaadhaar
 
 import (
+	"bytes"
+	"compress/zlib"
 	"encoding/json"
 	"math/big"
 	"testing"
@@ -9,10 +11,9 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestQRData(t *testing.T) {
-	// expected source
-	// https://github.com/anon-aadhaar/anon-aadhaar/blob/main/packages/circuits/assets/test.json
-	source := `{
+// expected source
+// https://github.com/anon-aadhaar/anon-aadhaar/blob/main/packages/circuits/assets/test.json
+const testQRSource = `{
   "testQRData": "8259163575998395410294216884136380469956685892150052488437065451544537272028823381012058331104900016170949862194992078675406411614673500428635982291062028490923003780630984433423301235105417377787131661931984964599727049255285879318792495236619560961528175330528230923044174467379560397653507051470100248395988491800303621193017585564341319296489036716964773497652480922601816247855475394255072055155630865556422666204858735313430441358989197942359085778977252174914218750831324262405073486188565379661087624387219845602810004409858067060582723830310306954192817345000453273623216177755588322887355541803994258569827849151852254798713294190907648230081077510592816508733759309449554841585969816906585446495429295879085493920989281432124264686964836843412063229868917416620953791606506977061635857869230322961966933040081525837759953018919032951374647284949205876501534604609019655215562471603371161946541957137254612227929914099003888563475886540925722627409182118438133540745135873329891527671405288082042105248606397547696373988012429641765957373215164223914638446054352114848994560273925881966532950058515181388759591132298529623987123194227812330918793321242727635507097775073470913531111624493576370385944481779207499154345692800114586514930018253620629510671404966362557857641999589084996038042041566831142437243583312354405809351126154203741507594162846336352179343954609499386129314707436439498170844738212884179575970184067773906023308700304595766504002059225628845131658391808799061443662643244237433587394532910535053813919941235917069445490288535690770254964055050593379778806503841922391399545982769046149777275410414936802378789310715386469101351083935892919710110707167748324213165552014082126981342105688852994389004329229607011870745132085215561769894535198146559591966109398235088570991674596662725913989312687633451215056181618710951495328959829445355475946382007067174484187843829682632075742981527603170017383558567515525142187900818201598762722436016733654029911649550767959827096893303631543278516100843293421762867984506025935441477253344987175034417916079625625371854046567321295833019522935272303724497663116543666998220335640687972062287455715754417604421981525656917538633268735987248122223688953567669389081610408785753661463406540493356023533353414845665187659296301626231138335599426042956724260221272505774023358411257317356159623167122090889680494391699098612891443839591040158364788967761812547722827975134074249498746600141474162623701225599279053137171665029207049665126886560795303043957258835332398217358504670108540440086553112969204911544457383791164248286490428962205833992719109104066885727003793530534239190322145789868094191824916635003885589340787668337395036427004773582592606136970224829885297327946705251124207201157878059391302841686982381058544860043878906081086431706218579038805094181604734876076823217950098303441193151451328000264195608403604158930298446358926216003325046476113401255186582872417002832207916555599681630457718321743339538760995883798622573729834417735834210649314285763784726287210633344224738479199260435876897373634088311958610480853339605575937005219222939598641467868545589588057047581729376964",
   "undefined": "V2",
   "Email_mobile_present_bit_indicator_value": "3",
@@ -34,8 +35,9 @@ func TestQRData(t *testing.T) {
   "PhoneNumberLast4": "1234"
 }`
 
+func TestQRData(t *testing.T) {
 	expected := map[string]string{}
-	err := json.Unmarshal([]byte(source), &expected)
+	err := json.Unmarshal([]byte(testQRSource), &expected)
 	require.NoError(t, err)
 
 	qrDataBI, ok := big.NewInt(0).SetString(expected["testQRData"], 10)
@@ -90,3 +92,142 @@ func TestQRData(t *testing.T) {
 		"Address mismatch",
 	)
 }
+
+func TestVerifySignature(t *testing.T) {
+	qrData, ok := big.NewInt(0).SetString(testdata, 10)
+	require.True(t, ok)
+
+	qr, err := VerifyQR(qrData, publicKeyPEM)
+	require.NoError(t, err)
+	require.Equal(t, "Sumit Kumar", qr.Name)
+
+	// Certificate PEM with the same key.
+	require.NoError(t, qr.VerifySignature(testAssetsCertificatePEM))
+
+	_, err = VerifyQR(qrData, certificatePEM)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	tampered := *qr
+	tampered.rawdata = append([]byte{}, qr.rawdata...)
+	tampered.rawdata[len(tampered.rawdata)-1] ^= 0x01
+	require.ErrorIs(t, tampered.VerifySignature(publicKeyPEM), ErrInvalidSignature)
+
+	err = qr.VerifySignature([]byte("not a PEM"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrInvalidSignature)
+}
+
+// compressQR encodes the uncompressed QR data as the QR code number.
+func compressQR(tb testing.TB, data []byte) *big.Int {
+	tb.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(tb, err)
+	require.NoError(tb, w.Close())
+	return new(big.Int).SetBytes(buf.Bytes())
+}
+
+// testQRSeeds returns the QR code numbers of the test data.
+func testQRSeeds(tb testing.TB) []*big.Int {
+	tb.Helper()
+	source := map[string]string{}
+	require.NoError(tb, json.Unmarshal([]byte(testQRSource), &source))
+	var seeds []*big.Int
+	for _, s := range []string{testdata, source["testQRData"]} {
+		qrData, ok := big.NewInt(0).SetString(s, 10)
+		require.True(tb, ok)
+		seeds = append(seeds, qrData)
+	}
+	return seeds
+}
+
+func TestUnmarshalQR_Malformed(t *testing.T) {
+	qr := &AnonAadhaarDataV2{}
+	require.NoError(t, qr.UnmarshalQR(testQRSeeds(t)[0]))
+	// withPart replaces a part of the signed data before the photo.
+	withPart := func(i int, value string) *big.Int {
+		parts := bytes.Split(qr.rawdata, []byte{delimiter})
+		parts[i] = []byte(value)
+		data := bytes.Join(parts, []byte{delimiter})
+		return compressQR(t, append(data, qr.signature...))
+	}
+	compressed := testQRSeeds(t)[0].Bytes()
+
+	tests := []struct {
+		name    string
+		input   *big.Int
+		wantErr error
+	}{
+		{name: "Nil", input: nil, wantErr: ErrQRTooShort},
+		{name: "Zero", input: big.NewInt(0), wantErr: ErrQRTooShort},
+		{name: "Not compressed", input: big.NewInt(12345), wantErr: ErrQRCompression},
+		{
+			name:    "Truncated",
+			input:   new(big.Int).SetBytes(compressed[:len(compressed)/2]),
+			wantErr: ErrQRCompression,
+		},
+		{
+			name:    "Decompression bomb",
+			input:   compressQR(t, make([]byte, maxQRDataSize+1)),
+			wantErr: ErrQRTooLarge,
+		},
+		{name: "Signature only", input: compressQR(t, qr.signature), wantErr: ErrQRTooShort},
+		{
+			name:    "Missing delimiters",
+			input:   compressQR(t, append([]byte("V2\xff3\xff2697"), qr.signature...)),
+			wantErr: ErrQRMissingDelimiters,
+		},
+		{name: "Short reference ID", input: withPart(2, "2697"), wantErr: ErrQRInvalidReferenceID},
+		{
+			name:    "Non-numeric reference ID",
+			input:   withPart(2, "2697ABCD0308114407437"),
+			wantErr: ErrQRInvalidReferenceID,
+		},
+		{
+			name:    "Invalid signed time",
+			input:   withPart(2, "269720191308114407437"),
+			wantErr: ErrQRInvalidDate,
+		},
+		{name: "Invalid date of birth", input: withPart(4, "31-02-1984"), wantErr: ErrQRInvalidDate},
+		{name: "Invalid gender", input: withPart(5, "Q"), wantErr: ErrQRInvalidField},
+		{name: "Empty pin code", input: withPart(11, ""), wantErr: ErrQRInvalidField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&AnonAadhaarDataV2{}).UnmarshalQR(tt.input)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func FuzzUnmarshalQR(f *testing.F) {
+	for _, seed := range testQRSeeds(f) {
+		f.Add(seed.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		qr := &AnonAadhaarDataV2{}
+		if err := qr.UnmarshalQR(new(big.Int).SetBytes(data)); err != nil {
+			return
+		}
+		_, _ = qr.Nullifier(1)
+	})
+}
+
+// FuzzUnmarshalQRData fuzzes the parser with uncompressed data, which the
+// compressed input rarely reaches.
+func FuzzUnmarshalQRData(f *testing.F) {
+	for _, seed := range testQRSeeds(f) {
+		qr := &AnonAadhaarDataV2{}
+		require.NoError(f, qr.UnmarshalQR(seed))
+		f.Add(append(append([]byte{}, qr.rawdata...), qr.signature...))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		qr := &AnonAadhaarDataV2{}
+		if err := qr.unmarshalQRData(data); err != nil {
+			return
+		}
+		_, _ = qr.Nullifier(1)
+		_, _ = prepareInputs(qr)
+	})
+}
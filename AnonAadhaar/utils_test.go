@@ -17,7 +17,7 @@ n5fefMD+XRROR0gT+0PsWD+BtO4yjCIWczSJjSELoBeibsaJQPBd8ivZzIa7w6Q1
 Q5I3LVZhZ3abc1uhLKNYD5GcG9i6cMTCqwrPKwm8L66YHzwClabh6fJI9QBzCU/6
 8QIDAQAB
 -----END PUBLIC KEY-----`)
-	//nolint:unused // This is a valid certificate PEM
+	// Certificate of the test key.
 	testAssetsCertificatePEM = []byte(`-----BEGIN CERTIFICATE-----
 MIID6jCCAtKgAwIBAgIBATANBgkqhkiG9w0BAQsFADBpMRQwEgYDVQQDEwtleGFt
 cGxlLm9yZzELMAkGA1UEBhMCVVMxETAPBgNVBAgTCFZpcmdpbmlhMRMwEQYDVQQH
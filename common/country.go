@@ -0,0 +1,82 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownCountry is returned for codes that are neither ISO 3166-1
+// alpha-3 codes nor ICAO 9303 codes.
+var ErrUnknownCountry = errors.New("unknown country code")
+
+// Country is an ISO 3166-1 country or an ICAO 9303 code of an organization
+// or a person without a state.
+type Country struct {
+	Code   string // Code as used in the MRZ, e.g. "D" for Germany
+	Alpha3 string // ISO 3166-1 alpha-3 code, empty for organizations
+	Alpha2 string // ISO 3166-1 alpha-2 code, empty for organizations
+	Name   string // English short name
+}
+
+var countryIndex = func() map[string]Country {
+	index := make(map[string]Country, len(countries))
+	for _, c := range countries {
+		index[c.Code] = c
+		if c.Alpha3 != "" && c.Alpha3 != c.Code {
+			if _, ok := index[c.Alpha3]; !ok {
+				index[c.Alpha3] = c
+			}
+		}
+	}
+	return index
+}()
+
+// specimenCountries are the codes of ICAO specimen documents. They are not
+// issued to anyone and resolve only with WithSpecimenCountries.
+var specimenCountries = map[string]Country{
+	"UTO": {"UTO", "", "", "Utopia (ICAO specimen documents)"},
+}
+
+// LookupOption configures LookupCountry.
+type LookupOption func(*lookupOptions)
+
+type lookupOptions struct {
+	specimen bool
+}
+
+// WithSpecimenCountries also resolves the codes of ICAO specimen documents,
+// such as "UTO". It is meant for tests only.
+func WithSpecimenCountries() LookupOption {
+	return func(o *lookupOptions) {
+		o.specimen = true
+	}
+}
+
+// LookupCountry returns the country of an MRZ or ISO 3166-1 alpha-3 code.
+// The code is normalized by removing the MRZ filler, so "D<<" and "DEU"
+// both resolve to Germany with the MRZ code "D".
+func LookupCountry(code string, opts ...LookupOption) (Country, error) {
+	options := &lookupOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	normalized := strings.ToUpper(strings.TrimRight(strings.TrimSpace(code), "<"))
+	c, ok := countryIndex[normalized]
+	if !ok && options.specimen {
+		c, ok = specimenCountries[normalized]
+	}
+	if !ok {
+		return Country{}, fmt.Errorf("%w: '%s'", ErrUnknownCountry, code)
+	}
+	return c, nil
+}
+
+// MustLookupCountry is like LookupCountry but panics for unknown codes.
+func MustLookupCountry(code string, opts ...LookupOption) Country {
+	c, err := LookupCountry(code, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,284 @@
+package common
+
+// countries is the table of ISO 3166-1 countries and ICAO 9303 part 3 codes.
+// The code of Germany in the MRZ is "D".
+var countries = []Country{
+	{"AFG", "AFG", "AF", "Afghanistan"},
+	{"ALA", "ALA", "AX", "Åland Islands"},
+	{"ALB", "ALB", "AL", "Albania"},
+	{"DZA", "DZA", "DZ", "Algeria"},
+	{"ASM", "ASM", "AS", "American Samoa"},
+	{"AND", "AND", "AD", "Andorra"},
+	{"AGO", "AGO", "AO", "Angola"},
+	{"AIA", "AIA", "AI", "Anguilla"},
+	{"ATA", "ATA", "AQ", "Antarctica"},
+	{"ATG", "ATG", "AG", "Antigua and Barbuda"},
+	{"ARG", "ARG", "AR", "Argentina"},
+	{"ARM", "ARM", "AM", "Armenia"},
+	{"ABW", "ABW", "AW", "Aruba"},
+	{"AUS", "AUS", "AU", "Australia"},
+	{"AUT", "AUT", "AT", "Austria"},
+	{"AZE", "AZE", "AZ", "Azerbaijan"},
+	{"BHS", "BHS", "BS", "Bahamas"},
+	{"BHR", "BHR", "BH", "Bahrain"},
+	{"BGD", "BGD", "BD", "Bangladesh"},
+	{"BRB", "BRB", "BB", "Barbados"},
+	{"BLR", "BLR", "BY", "Belarus"},
+	{"BEL", "BEL", "BE", "Belgium"},
+	{"BLZ", "BLZ", "BZ", "Belize"},
+	{"BEN", "BEN", "BJ", "Benin"},
+	{"BMU", "BMU", "BM", "Bermuda"},
+	{"BTN", "BTN", "BT", "Bhutan"},
+	{"BOL", "BOL", "BO", "Bolivia"},
+	{"BES", "BES", "BQ", "Bonaire, Sint Eustatius and Saba"},
+	{"BIH", "BIH", "BA", "Bosnia and Herzegovina"},
+	{"BWA", "BWA", "BW", "Botswana"},
+	{"BVT", "BVT", "BV", "Bouvet Island"},
+	{"BRA", "BRA", "BR", "Brazil"},
+	{"IOT", "IOT", "IO", "British Indian Ocean Territory"},
+	{"BRN", "BRN", "BN", "Brunei Darussalam"},
+	{"BGR", "BGR", "BG", "Bulgaria"},
+	{"BFA", "BFA", "BF", "Burkina Faso"},
+	{"BDI", "BDI", "BI", "Burundi"},
+	{"CPV", "CPV", "CV", "Cabo Verde"},
+	{"KHM", "KHM", "KH", "Cambodia"},
+	{"CMR", "CMR", "CM", "Cameroon"},
+	{"CAN", "CAN", "CA", "Canada"},
+	{"CYM", "CYM", "KY", "Cayman Islands"},
+	{"CAF", "CAF", "CF", "Central African Republic"},
+	{"TCD", "TCD", "TD", "Chad"},
+	{"CHL", "CHL", "CL", "Chile"},
+	{"CHN", "CHN", "CN", "China"},
+	{"CXR", "CXR", "CX", "Christmas Island"},
+	{"CCK", "CCK", "CC", "Cocos (Keeling) Islands"},
+	{"COL", "COL", "CO", "Colombia"},
+	{"COM", "COM", "KM", "Comoros"},
+	{"COG", "COG", "CG", "Congo"},
+	{"COD", "COD", "CD", "Congo, Democratic Republic of the"},
+	{"COK", "COK", "CK", "Cook Islands"},
+	{"CRI", "CRI", "CR", "Costa Rica"},
+	{"CIV", "CIV", "CI", "Côte d'Ivoire"},
+	{"HRV", "HRV", "HR", "Croatia"},
+	{"CUB", "CUB", "CU", "Cuba"},
+	{"CUW", "CUW", "CW", "Curaçao"},
+	{"CYP", "CYP", "CY", "Cyprus"},
+	{"CZE", "CZE", "CZ", "Czechia"},
+	{"DNK", "DNK", "DK", "Denmark"},
+	{"DJI", "DJI", "DJ", "Djibouti"},
+	{"DMA", "DMA", "DM", "Dominica"},
+	{"DOM", "DOM", "DO", "Dominican Republic"},
+	{"ECU", "ECU", "EC", "Ecuador"},
+	{"EGY", "EGY", "EG", "Egypt"},
+	{"SLV", "SLV", "SV", "El Salvador"},
+	{"GNQ", "GNQ", "GQ", "Equatorial Guinea"},
+	{"ERI", "ERI", "ER", "Eritrea"},
+	{"EST", "EST", "EE", "Estonia"},
+	{"SWZ", "SWZ", "SZ", "Eswatini"},
+	{"ETH", "ETH", "ET", "Ethiopia"},
+	{"FLK", "FLK", "FK", "Falkland Islands (Malvinas)"},
+	{"FRO", "FRO", "FO", "Faroe Islands"},
+	{"FJI", "FJI", "FJ", "Fiji"},
+	{"FIN", "FIN", "FI", "Finland"},
+	{"FRA", "FRA", "FR", "France"},
+	{"GUF", "GUF", "GF", "French Guiana"},
+	{"PYF", "PYF", "PF", "French Polynesia"},
+	{"ATF", "ATF", "TF", "French Southern Territories"},
+	{"GAB", "GAB", "GA", "Gabon"},
+	{"GMB", "GMB", "GM", "Gambia"},
+	{"GEO", "GEO", "GE", "Georgia"},
+	{"D", "DEU", "DE", "Germany"},
+	{"GHA", "GHA", "GH", "Ghana"},
+	{"GIB", "GIB", "GI", "Gibraltar"},
+	{"GRC", "GRC", "GR", "Greece"},
+	{"GRL", "GRL", "GL", "Greenland"},
+	{"GRD", "GRD", "GD", "Grenada"},
+	{"GLP", "GLP", "GP", "Guadeloupe"},
+	{"GUM", "GUM", "GU", "Guam"},
+	{"GTM", "GTM", "GT", "Guatemala"},
+	{"GGY", "GGY", "GG", "Guernsey"},
+	{"GIN", "GIN", "GN", "Guinea"},
+	{"GNB", "GNB", "GW", "Guinea-Bissau"},
+	{"GUY", "GUY", "GY", "Guyana"},
+	{"HTI", "HTI", "HT", "Haiti"},
+	{"HMD", "HMD", "HM", "Heard Island and McDonald Islands"},
+	{"VAT", "VAT", "VA", "Holy See"},
+	{"HND", "HND", "HN", "Honduras"},
+	{"HKG", "HKG", "HK", "Hong Kong"},
+	{"HUN", "HUN", "HU", "Hungary"},
+	{"ISL", "ISL", "IS", "Iceland"},
+	{"IND", "IND", "IN", "India"},
+	{"IDN", "IDN", "ID", "Indonesia"},
+	{"IRN", "IRN", "IR", "Iran"},
+	{"IRQ", "IRQ", "IQ", "Iraq"},
+	{"IRL", "IRL", "IE", "Ireland"},
+	{"IMN", "IMN", "IM", "Isle of Man"},
+	{"ISR", "ISR", "IL", "Israel"},
+	{"ITA", "ITA", "IT", "Italy"},
+	{"JAM", "JAM", "JM", "Jamaica"},
+	{"JPN", "JPN", "JP", "Japan"},
+	{"JEY", "JEY", "JE", "Jersey"},
+	{"JOR", "JOR", "JO", "Jordan"},
+	{"KAZ", "KAZ", "KZ", "Kazakhstan"},
+	{"KEN", "KEN", "KE", "Kenya"},
+	{"KIR", "KIR", "KI", "Kiribati"},
+	{"PRK", "PRK", "KP", "Korea, Democratic People's Republic of"},
+	{"KOR", "KOR", "KR", "Korea, Republic of"},
+	{"KWT", "KWT", "KW", "Kuwait"},
+	{"KGZ", "KGZ", "KG", "Kyrgyzstan"},
+	{"LAO", "LAO", "LA", "Lao People's Democratic Republic"},
+	{"LVA", "LVA", "LV", "Latvia"},
+	{"LBN", "LBN", "LB", "Lebanon"},
+	{"LSO", "LSO", "LS", "Lesotho"},
+	{"LBR", "LBR", "LR", "Liberia"},
+	{"LBY", "LBY", "LY", "Libya"},
+	{"LIE", "LIE", "LI", "Liechtenstein"},
+	{"LTU", "LTU", "LT", "Lithuania"},
+	{"LUX", "LUX", "LU", "Luxembourg"},
+	{"MAC", "MAC", "MO", "Macao"},
+	{"MDG", "MDG", "MG", "Madagascar"},
+	{"MWI", "MWI", "MW", "Malawi"},
+	{"MYS", "MYS", "MY", "Malaysia"},
+	{"MDV", "MDV", "MV", "Maldives"},
+	{"MLI", "MLI", "ML", "Mali"},
+	{"MLT", "MLT", "MT", "Malta"},
+	{"MHL", "MHL", "MH", "Marshall Islands"},
+	{"MTQ", "MTQ", "MQ", "Martinique"},
+	{"MRT", "MRT", "MR", "Mauritania"},
+	{"MUS", "MUS", "MU", "Mauritius"},
+	{"MYT", "MYT", "YT", "Mayotte"},
+	{"MEX", "MEX", "MX", "Mexico"},
+	{"FSM", "FSM", "FM", "Micronesia"},
+	{"MDA", "MDA", "MD", "Moldova"},
+	{"MCO", "MCO", "MC", "Monaco"},
+	{"MNG", "MNG", "MN", "Mongolia"},
+	{"MNE", "MNE", "ME", "Montenegro"},
+	{"MSR", "MSR", "MS", "Montserrat"},
+	{"MAR", "MAR", "MA", "Morocco"},
+	{"MOZ", "MOZ", "MZ", "Mozambique"},
+	{"MMR", "MMR", "MM", "Myanmar"},
+	{"NAM", "NAM", "NA", "Namibia"},
+	{"NRU", "NRU", "NR", "Nauru"},
+	{"NPL", "NPL", "NP", "Nepal"},
+	{"NLD", "NLD", "NL", "Netherlands"},
+	{"NCL", "NCL", "NC", "New Caledonia"},
+	{"NZL", "NZL", "NZ", "New Zealand"},
+	{"NIC", "NIC", "NI", "Nicaragua"},
+	{"NER", "NER", "NE", "Niger"},
+	{"NGA", "NGA", "NG", "Nigeria"},
+	{"NIU", "NIU", "NU", "Niue"},
+	{"NFK", "NFK", "NF", "Norfolk Island"},
+	{"MKD", "MKD", "MK", "North Macedonia"},
+	{"MNP", "MNP", "MP", "Northern Mariana Islands"},
+	{"NOR", "NOR", "NO", "Norway"},
+	{"OMN", "OMN", "OM", "Oman"},
+	{"PAK", "PAK", "PK", "Pakistan"},
+	{"PLW", "PLW", "PW", "Palau"},
+	{"PSE", "PSE", "PS", "Palestine, State of"},
+	{"PAN", "PAN", "PA", "Panama"},
+	{"PNG", "PNG", "PG", "Papua New Guinea"},
+	{"PRY", "PRY", "PY", "Paraguay"},
+	{"PER", "PER", "PE", "Peru"},
+	{"PHL", "PHL", "PH", "Philippines"},
+	{"PCN", "PCN", "PN", "Pitcairn"},
+	{"POL", "POL", "PL", "Poland"},
+	{"PRT", "PRT", "PT", "Portugal"},
+	{"PRI", "PRI", "PR", "Puerto Rico"},
+	{"QAT", "QAT", "QA", "Qatar"},
+	{"REU", "REU", "RE", "Réunion"},
+	{"ROU", "ROU", "RO", "Romania"},
+	{"RUS", "RUS", "RU", "Russian Federation"},
+	{"RWA", "RWA", "RW", "Rwanda"},
+	{"BLM", "BLM", "BL", "Saint Barthélemy"},
+	{"SHN", "SHN", "SH", "Saint Helena, Ascension and Tristan da Cunha"},
+	{"KNA", "KNA", "KN", "Saint Kitts and Nevis"},
+	{"LCA", "LCA", "LC", "Saint Lucia"},
+	{"MAF", "MAF", "MF", "Saint Martin (French part)"},
+	{"SPM", "SPM", "PM", "Saint Pierre and Miquelon"},
+	{"VCT", "VCT", "VC", "Saint Vincent and the Grenadines"},
+	{"WSM", "WSM", "WS", "Samoa"},
+	{"SMR", "SMR", "SM", "San Marino"},
+	{"STP", "STP", "ST", "Sao Tome and Principe"},
+	{"SAU", "SAU", "SA", "Saudi Arabia"},
+	{"SEN", "SEN", "SN", "Senegal"},
+	{"SRB", "SRB", "RS", "Serbia"},
+	{"SYC", "SYC", "SC", "Seychelles"},
+	{"SLE", "SLE", "SL", "Sierra Leone"},
+	{"SGP", "SGP", "SG", "Singapore"},
+	{"SXM", "SXM", "SX", "Sint Maarten (Dutch part)"},
+	{"SVK", "SVK", "SK", "Slovakia"},
+	{"SVN", "SVN", "SI", "Slovenia"},
+	{"SLB", "SLB", "SB", "Solomon Islands"},
+	{"SOM", "SOM", "SO", "Somalia"},
+	{"ZAF", "ZAF", "ZA", "South Africa"},
+	{"SGS", "SGS", "GS", "South Georgia and the South Sandwich Islands"},
+	{"SSD", "SSD", "SS", "South Sudan"},
+	{"ESP", "ESP", "ES", "Spain"},
+	{"LKA", "LKA", "LK", "Sri Lanka"},
+	{"SDN", "SDN", "SD", "Sudan"},
+	{"SUR", "SUR", "SR", "Suriname"},
+	{"SJM", "SJM", "SJ", "Svalbard and Jan Mayen"},
+	{"SWE", "SWE", "SE", "Sweden"},
+	{"CHE", "CHE", "CH", "Switzerland"},
+	{"SYR", "SYR", "SY", "Syrian Arab Republic"},
+	{"TWN", "TWN", "TW", "Taiwan"},
+	{"TJK", "TJK", "TJ", "Tajikistan"},
+	{"TZA", "TZA", "TZ", "Tanzania, United Republic of"},
+	{"THA", "THA", "TH", "Thailand"},
+	{"TLS", "TLS", "TL", "Timor-Leste"},
+	{"TGO", "TGO", "TG", "Togo"},
+	{"TKL", "TKL", "TK", "Tokelau"},
+	{"TON", "TON", "TO", "Tonga"},
+	{"TTO", "TTO", "TT", "Trinidad and Tobago"},
+	{"TUN", "TUN", "TN", "Tunisia"},
+	{"TUR", "TUR", "TR", "Türkiye"},
+	{"TKM", "TKM", "TM", "Turkmenistan"},
+	{"TCA", "TCA", "TC", "Turks and Caicos Islands"},
+	{"TUV", "TUV", "TV", "Tuvalu"},
+	{"UGA", "UGA", "UG", "Uganda"},
+	{"UKR", "UKR", "UA", "Ukraine"},
+	{"ARE", "ARE", "AE", "United Arab Emirates"},
+	{"GBR", "GBR", "GB", "United Kingdom of Great Britain and Northern Ireland"},
+	{"USA", "USA", "US", "United States of America"},
+	{"UMI", "UMI", "UM", "United States Minor Outlying Islands"},
+	{"URY", "URY", "UY", "Uruguay"},
+	{"UZB", "UZB", "UZ", "Uzbekistan"},
+	{"VUT", "VUT", "VU", "Vanuatu"},
+	{"VEN", "VEN", "VE", "Venezuela"},
+	{"VNM", "VNM", "VN", "Viet Nam"},
+	{"VGB", "VGB", "VG", "Virgin Islands (British)"},
+	{"VIR", "VIR", "VI", "Virgin Islands (U.S.)"},
+	{"WLF", "WLF", "WF", "Wallis and Futuna"},
+	{"ESH", "ESH", "EH", "Western Sahara"},
+	{"YEM", "YEM", "YE", "Yemen"},
+	{"ZMB", "ZMB", "ZM", "Zambia"},
+	{"ZWE", "ZWE", "ZW", "Zimbabwe"},
+
+	// ICAO 9303 part 3 codes for British nationals
+	{"GBD", "GBR", "GB", "British Overseas Territories Citizen"},
+	{"GBN", "GBR", "GB", "British National (Overseas)"},
+	{"GBO", "GBR", "GB", "British Overseas Citizen"},
+	{"GBP", "GBR", "GB", "British Protected Person"},
+	{"GBS", "GBR", "GB", "British Subject"},
+
+	// ICAO 9303 part 3 codes for organizations and persons without a state
+	{"RKS", "", "XK", "Kosovo"},
+	{"EUE", "", "", "European Union"},
+	{"UNO", "", "", "United Nations Organization"},
+	{"UNA", "", "", "Specialized Agency of the United Nations"},
+	{"UNK", "", "", "Resident of Kosovo with a travel document issued by UNMIK"},
+	{"XBA", "", "", "African Development Bank"},
+	{"XIM", "", "", "African Export-Import Bank"},
+	{"XCC", "", "", "Caribbean Community"},
+	{"XCE", "", "", "Council of Europe"},
+	{"XCO", "", "", "Common Market for Eastern and Southern Africa"},
+	{"XEC", "", "", "Economic Community of West African States"},
+	{"XES", "", "", "Organisation of Eastern Caribbean States"},
+	{"XMP", "", "", "Parliamentary Assembly of the Mediterranean"},
+	{"XOM", "", "", "Sovereign Military Order of Malta"},
+	{"XPO", "", "", "International Criminal Police Organization"},
+	{"XDC", "", "", "Southern African Development Community"},
+	{"XXA", "", "", "Stateless person"},
+	{"XXB", "", "", "Refugee as defined in the 1951 Convention"},
+	{"XXC", "", "", "Refugee other than defined in the 1951 Convention"},
+	{"XXX", "", "", "Person of unspecified nationality"},
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupCountry(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected Country
+	}{
+		{code: "UKR", expected: Country{"UKR", "UKR", "UA", "Ukraine"}},
+		{code: "IND", expected: Country{"IND", "IND", "IN", "India"}},
+		{code: "D<<", expected: Country{"D", "DEU", "DE", "Germany"}},
+		{code: "D", expected: Country{"D", "DEU", "DE", "Germany"}},
+		{code: "DEU", expected: Country{"D", "DEU", "DE", "Germany"}},
+		{code: "gbr", expected: Country{
+			"GBR", "GBR", "GB", "United Kingdom of Great Britain and Northern Ireland",
+		}},
+		{code: "GBD", expected: Country{"GBD", "GBR", "GB", "British Overseas Territories Citizen"}},
+		{code: "RKS", expected: Country{"RKS", "", "XK", "Kosovo"}},
+		{code: "UNO", expected: Country{"UNO", "", "", "United Nations Organization"}},
+		{code: "XXA", expected: Country{"XXA", "", "", "Stateless person"}},
+		{code: "XXX", expected: Country{"XXX", "", "", "Person of unspecified nationality"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			c, err := LookupCountry(tt.code)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, c)
+		})
+	}
+}
+
+func TestLookupCountry_Unknown(t *testing.T) {
+	for _, code := range []string{"", "<<<", "ZZZ", "DE", "UK"} {
+		_, err := LookupCountry(code)
+		require.ErrorIs(t, err, ErrUnknownCountry, code)
+	}
+	require.Panics(t, func() { MustLookupCountry("ZZZ") })
+}
+
+func TestLookupCountry_Specimen(t *testing.T) {
+	_, err := LookupCountry("UTO")
+	require.ErrorIs(t, err, ErrUnknownCountry)
+
+	c, err := LookupCountry("UTO", WithSpecimenCountries())
+	require.NoError(t, err)
+	require.Equal(t, Country{"UTO", "", "", "Utopia (ICAO specimen documents)"}, c)
+	require.Equal(t, "D", MustLookupCountry("DEU", WithSpecimenCountries()).Code)
+}
+
+func TestCountryTable(t *testing.T) {
+	codes := make(map[string]bool, len(countries))
+	alpha2 := make(map[string]bool, len(countries))
+	for _, c := range countries {
+		require.False(t, codes[c.Code], "duplicate code %s", c.Code)
+		codes[c.Code] = true
+		require.Regexp(t, `^[A-Z]{1,3}$`, c.Code)
+		require.NotEmpty(t, c.Name)
+		if c.Alpha3 == "" || (c.Code != c.Alpha3 && c.Code != "D") {
+			continue
+		}
+		require.False(t, alpha2[c.Alpha2], "duplicate alpha-2 code %s", c.Alpha2)
+		alpha2[c.Alpha2] = true
+	}
+	// ISO 3166-1 officially assigned codes
+	require.Len(t, alpha2, 249)
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+)
+
+// NewLinkNonce returns a random non-zero field element to use as a link nonce.
+// A zero nonce disables linking in the circuits.
+//
+// The linkId public signal is computed by the circuit only. This package does
+// not provide a LinkID computation: the iden3 construction
+// Poseidon(Poseidon(hashIndex, hashValue), linkNonce) does not reproduce the
+// linkId of the passport circuit testdata, and without the circuit source a
+// helper could not be checked against a known vector.
+func NewLinkNonce() (*big.Int, error) {
+	for {
+		nonce, err := rand.Int(rand.Reader, constants.Q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate link nonce: %w", err)
+		}
+		if nonce.Sign() != 0 {
+			return nonce, nil
+		}
+	}
+}
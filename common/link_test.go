@@ -0,0 +1,22 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLinkNonce(t *testing.T) {
+	first, err := NewLinkNonce()
+	require.NoError(t, err)
+	second, err := NewLinkNonce()
+	require.NoError(t, err)
+
+	require.NotEqual(t, first, second)
+	for _, nonce := range []*big.Int{first, second} {
+		require.Positive(t, nonce.Sign())
+		require.Negative(t, nonce.Cmp(constants.Q))
+	}
+}
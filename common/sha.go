@@ -0,0 +1,80 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// SplitToWords is a golang implementation of the splitToWords from AnonAadhaar utils
+// https://github.com/anon-aadhaar/anon-aadhaar/blob/e0cbde3d8e4a3969a6e44a2999ec539439e61d58/packages/core/src/utils.ts#L21
+func SplitToWords(number, wordsize, numberElement *big.Int) ([]*big.Int, error) {
+	t := new(big.Int).Set(number)
+	words := []*big.Int{}
+
+	power := new(big.Int).Exp(big.NewInt(2), wordsize, nil)
+	for i := big.NewInt(0); i.Cmp(numberElement) < 0; i.Add(i, big.NewInt(1)) {
+		mod := new(big.Int).Mod(t, power)
+		words = append(words, mod)
+		t.Div(t, power)
+	}
+
+	if t.Cmp(big.NewInt(0)) != 0 {
+		return nil, fmt.Errorf(
+			"number %s does not fit in %d bits",
+			number.String(),
+			new(big.Int).Mul(wordsize, numberElement).Uint64(),
+		)
+	}
+
+	return words, nil
+}
+
+// SHA256Pad is a golang implementation of sha256Pad from zk-email helpers
+// https://github.com/zkemail/zk-email-verify/blob/e1084969fbee16317290e4380b3837af74fea616/packages/helpers/src/sha-utils.ts#L88
+// The same padding is used by SHA-1 and SHA-224.
+func SHA256Pad(m []byte, maxShaBytes int) (paddedMessage []byte, messageLen int, err error) {
+	return shaPad(m, 64, 8, maxShaBytes)
+}
+
+// SHA512Pad pads the message the same way as SHA256Pad, but with 1024 bit
+// blocks and a 128 bit message length used by SHA-384 and SHA-512.
+func SHA512Pad(m []byte, maxShaBytes int) (paddedMessage []byte, messageLen int, err error) {
+	return shaPad(m, 128, 16, maxShaBytes)
+}
+
+func shaPad(
+	m []byte, blockSize, lengthSize, maxShaBytes int,
+) (paddedMessage []byte, messageLen int, err error) {
+	// do not modify the original message
+	paddedMessage = make([]byte, len(m))
+	copy(paddedMessage, m)
+
+	msgLen := len(paddedMessage) * 8
+	msgLenBytes := make([]byte, lengthSize-8, lengthSize)
+	msgLenBytes = append(msgLenBytes, Int64ToBytes(int64(msgLen))...)
+
+	paddedMessage = append(paddedMessage, 0x80)
+	for (len(paddedMessage)+len(msgLenBytes))%blockSize != 0 {
+		paddedMessage = append(paddedMessage, 0x00)
+	}
+
+	paddedMessage = append(paddedMessage, msgLenBytes...)
+	if len(paddedMessage)%blockSize != 0 {
+		return nil, 0, errors.New("padding did not complete properly")
+	}
+
+	messageLen = len(paddedMessage)
+	for len(paddedMessage) < maxShaBytes {
+		paddedMessage = append(paddedMessage, Int64ToBytes(0)...)
+	}
+	if len(paddedMessage) != maxShaBytes {
+		return nil, 0, fmt.Errorf(
+			"padding to max length did not complete properly: got %d, expected %d",
+			len(paddedMessage),
+			maxShaBytes,
+		)
+	}
+
+	return paddedMessage, messageLen, nil
+}
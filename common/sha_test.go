@@ -0,0 +1,56 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSHAPad(t *testing.T) {
+	tests := []struct {
+		name        string
+		pad         func([]byte, int) ([]byte, int, error)
+		message     []byte
+		maxShaBytes int
+		expectedLen int
+	}{
+		{name: "SHA-256 abc", pad: SHA256Pad, message: []byte("abc"), maxShaBytes: 128, expectedLen: 64},
+		{
+			name: "SHA-256 two blocks", pad: SHA256Pad,
+			message: make([]byte, 56), maxShaBytes: 128, expectedLen: 128,
+		},
+		{name: "SHA-512 abc", pad: SHA512Pad, message: []byte("abc"), maxShaBytes: 256, expectedLen: 128},
+		{
+			name: "SHA-512 two blocks", pad: SHA512Pad,
+			message: make([]byte, 112), maxShaBytes: 256, expectedLen: 256,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			padded, messageLen, err := tt.pad(tt.message, tt.maxShaBytes)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedLen, messageLen)
+			require.Len(t, padded, tt.maxShaBytes)
+			require.Equal(t, tt.message, padded[:len(tt.message)])
+			require.Equal(t, byte(0x80), padded[len(tt.message)])
+
+			bitLen := new(big.Int).SetBytes(padded[messageLen-8 : messageLen])
+			require.Equal(t, int64(len(tt.message)*8), bitLen.Int64())
+			require.Equal(t, make([]byte, tt.maxShaBytes-messageLen), padded[messageLen:])
+		})
+	}
+
+	_, _, err := SHA256Pad(make([]byte, 100), 64)
+	require.Error(t, err)
+}
+
+func TestSplitToWords(t *testing.T) {
+	words, err := SplitToWords(big.NewInt(0x1234), big.NewInt(8), big.NewInt(3))
+	require.NoError(t, err)
+	require.Equal(t, []string{"52", "18", "0"}, BigIntListToStrings(words))
+
+	_, err = SplitToWords(big.NewInt(0x1234), big.NewInt(4), big.NewInt(3))
+	require.Error(t, err)
+}
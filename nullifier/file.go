@@ -0,0 +1,142 @@
+package nullifier
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrFileLocked is returned when the nullifier file is open in another
+// FileStore, possibly of another process.
+var ErrFileLocked = errors.New("nullifier file is locked by another store")
+
+// FileStore keeps the nullifiers in memory and appends them to a file, one
+// per line. Every insert is synced to disk before it is reported as Fresh.
+// The store holds an exclusive lock of the file until it is closed, so
+// issuer instances sharing nullifiers need a database store instead.
+type FileStore struct {
+	mu         sync.Mutex
+	file       *os.File
+	size       int64 // Size of the complete lines of the file
+	nullifiers map[Nullifier]struct{}
+}
+
+// OpenFileStore locks the file and loads the nullifiers from it, creating it
+// when it does not exist. File locking is only implemented on unix systems,
+// elsewhere, e.g. on Windows, OpenFileStore always fails with
+// errors.ErrUnsupported and a database store must be used instead.
+func OpenFileStore(path string) (*FileStore, error) {
+	//nolint:gosec // path is provided by the issuer configuration
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open nullifier file '%s': %w", path, err)
+	}
+	if err = lockFile(f); err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("failed to lock nullifier file '%s': %w", path, err), f.Close())
+	}
+	s := &FileStore{file: f, nullifiers: map[Nullifier]struct{}{}}
+	if err = s.load(); err != nil {
+		return nil, errors.Join(
+			fmt.Errorf("failed to load nullifier file '%s': %w", path, err), f.Close())
+	}
+	return s, nil
+}
+
+func (s *FileStore) load() error {
+	reader := bufio.NewReader(s.file)
+	for line := 1; ; line++ {
+		text, err := reader.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			return s.loadLastLine(text)
+		}
+		if err != nil {
+			return err
+		}
+		s.size += int64(len(text))
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		n := Nullifier(text)
+		if err = n.Validate(); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		s.nullifiers[n] = struct{}{}
+	}
+}
+
+// loadLastLine handles a last line without a newline. A valid nullifier is
+// kept, as refusing a document is safer than issuing it twice, and the line
+// is terminated. Otherwise the line was torn by a crash before it was synced,
+// so it was never reported as Fresh and is removed.
+func (s *FileStore) loadLastLine(text string) error {
+	if text == "" {
+		return nil
+	}
+	n := Nullifier(strings.TrimSpace(text))
+	if n.Validate() != nil {
+		return s.truncate()
+	}
+	if _, err := s.file.WriteString("\n"); err != nil {
+		return errors.Join(fmt.Errorf("failed to terminate last line: %w", err), s.truncate())
+	}
+	s.size += int64(len(text)) + 1
+	s.nullifiers[n] = struct{}{}
+	return nil
+}
+
+// CheckAndInsert records the nullifier and reports whether it was already
+// used. The nullifier is not recorded when writing to the file fails, and
+// the file is truncated back to its previous size.
+func (s *FileStore) CheckAndInsert(ctx context.Context, n Nullifier) (Status, error) {
+	if err := n.Validate(); err != nil {
+		return Used, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Used, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return Used, os.ErrClosed
+	}
+	if _, ok := s.nullifiers[n]; ok {
+		return Used, nil
+	}
+	line := string(n) + "\n"
+	if _, err := s.file.WriteString(line); err != nil {
+		return Used, errors.Join(fmt.Errorf("failed to write nullifier: %w", err), s.truncate())
+	}
+	if err := s.file.Sync(); err != nil {
+		return Used, errors.Join(fmt.Errorf("failed to sync nullifier file: %w", err), s.truncate())
+	}
+	s.size += int64(len(line))
+	s.nullifiers[n] = struct{}{}
+	return Fresh, nil
+}
+
+// truncate removes a partially written line from the file.
+func (s *FileStore) truncate() error {
+	if err := s.file.Truncate(s.size); err != nil {
+		return fmt.Errorf("failed to truncate nullifier file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,105 @@
+package nullifier
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nullifiers")
+	s, err := OpenFileStore(path)
+	require.NoError(t, err)
+	testStore(t, s)
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+
+	_, err = s.CheckAndInsert(context.Background(), "passport:1")
+	require.ErrorIs(t, err, os.ErrClosed)
+
+	// The nullifiers survive reopening the store.
+	s, err = OpenFileStore(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+	n, err := New(TypePassport, big.NewInt(2))
+	require.NoError(t, err)
+	status, err := s.CheckAndInsert(context.Background(), n)
+	require.NoError(t, err)
+	require.Equal(t, Used, status)
+	n, err = New(TypePassport, big.NewInt(3))
+	require.NoError(t, err)
+	status, err = s.CheckAndInsert(context.Background(), n)
+	require.NoError(t, err)
+	require.Equal(t, Fresh, status)
+}
+
+func TestFileStore_Locked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nullifiers")
+	s, err := OpenFileStore(path)
+	require.NoError(t, err)
+	_, err = OpenFileStore(path)
+	require.ErrorIs(t, err, ErrFileLocked)
+
+	require.NoError(t, s.Close())
+	s, err = OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+}
+
+func TestFileStore_LastLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+		used     Nullifier
+	}{
+		{
+			name:     "Torn line",
+			contents: "passport:1\npassport:",
+			want:     "passport:1\npassport:3\n",
+		},
+		{
+			name:     "Line without newline",
+			contents: "passport:1\npassport:2",
+			want:     "passport:1\npassport:2\npassport:3\n",
+			used:     "passport:2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nullifiers")
+			require.NoError(t, os.WriteFile(path, []byte(tt.contents), 0o600))
+			s, err := OpenFileStore(path)
+			require.NoError(t, err)
+			if tt.used != "" {
+				status, err := s.CheckAndInsert(context.Background(), tt.used)
+				require.NoError(t, err)
+				require.Equal(t, Used, status)
+			}
+			status, err := s.CheckAndInsert(context.Background(), "passport:3")
+			require.NoError(t, err)
+			require.Equal(t, Fresh, status)
+			require.NoError(t, s.Close())
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(contents))
+		})
+	}
+}
+
+func TestOpenFileStore_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	_, err := OpenFileStore(filepath.Join(dir, "missing", "nullifiers"))
+	require.Error(t, err)
+
+	path := filepath.Join(dir, "nullifiers")
+	require.NoError(t, os.WriteFile(path, []byte("passport:1\n\nnot a nullifier\n"), 0o600))
+	_, err = OpenFileStore(path)
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+	require.ErrorContains(t, err, "line 3")
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package nullifier
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile is not implemented outside of unix, where the file cannot be
+// protected from other processes.
+func lockFile(*os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,36 @@
+package nullifier
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps the nullifiers in memory. It is meant for tests and
+// single instance issuers which do not need to survive a restart.
+type MemoryStore struct {
+	mu         sync.Mutex
+	nullifiers map[Nullifier]struct{}
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nullifiers: map[Nullifier]struct{}{}}
+}
+
+// CheckAndInsert records the nullifier and reports whether it was already
+// used.
+func (s *MemoryStore) CheckAndInsert(ctx context.Context, n Nullifier) (Status, error) {
+	if err := n.Validate(); err != nil {
+		return Used, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Used, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.nullifiers[n]; ok {
+		return Used, nil
+	}
+	s.nullifiers[n] = struct{}{}
+	return Fresh, nil
+}
@@ -0,0 +1,7 @@
+package nullifier
+
+import "testing"
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
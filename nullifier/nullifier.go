@@ -0,0 +1,143 @@
+// Package nullifier records the nullifiers of the documents credentials were
+// issued for, so that an issuer refuses a second credential for the same
+// Aadhaar or passport.
+package nullifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	anonaadhaar "github.com/0xPolygonID/go-circuit-external/AnonAadhaar"
+	"github.com/0xPolygonID/go-circuit-external/common"
+	"github.com/0xPolygonID/go-circuit-external/passport"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// Document types of the nullifiers. Nullifiers of different document types
+// never collide.
+const (
+	TypeAnonAadhaar = "anonaadhaar"
+	TypePassport    = "passport"
+)
+
+// Status is the result of a nullifier check.
+type Status int
+
+const (
+	Fresh Status = iota // The nullifier was not used and is now recorded
+	Used                // The nullifier was already recorded
+)
+
+func (s Status) String() string {
+	switch s {
+	case Fresh:
+		return "fresh"
+	case Used:
+		return "used"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// ErrInvalidNullifier is returned for an empty or malformed nullifier.
+var ErrInvalidNullifier = errors.New("invalid nullifier")
+
+// Nullifier identifies a document: the document type and a decimal field
+// element, e.g. "anonaadhaar:1379...".
+type Nullifier string
+
+// New returns the nullifier of the document type and value. The document
+// type is made of lowercase letters, digits, '_' and '-'.
+func New(docType string, value *big.Int) (Nullifier, error) {
+	if !validDocType(docType) {
+		return "", fmt.Errorf("%w: document type %q", ErrInvalidNullifier, docType)
+	}
+	if value == nil || value.Sign() < 0 {
+		return "", fmt.Errorf("%w: value '%v'", ErrInvalidNullifier, value)
+	}
+	return Nullifier(docType + ":" + value.String()), nil
+}
+
+// Validate checks that the nullifier has a document type accepted by New
+// and a decimal value.
+func (n Nullifier) Validate() error {
+	docType, value, ok := strings.Cut(string(n), ":")
+	if !ok || !validDocType(docType) {
+		return fmt.Errorf("%w: %q", ErrInvalidNullifier, string(n))
+	}
+	if v, ok := new(big.Int).SetString(value, 10); !ok || v.Sign() < 0 || v.String() != value {
+		return fmt.Errorf("%w: %q", ErrInvalidNullifier, string(n))
+	}
+	return nil
+}
+
+// validDocType reports whether the document type matches [a-z0-9_-]+, so
+// that a nullifier is always a single line without spaces.
+func validDocType(docType string) bool {
+	if docType == "" {
+		return false
+	}
+	for _, c := range docType {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromAnonAadhaar returns the nullifier output of the AnonAadhaar circuit.
+// The nullifier depends on the nullifier seed, so the issuer must use the
+// same seed for all proofs.
+func FromAnonAadhaar(signals *anonaadhaar.AnonAadhaarV1PubSignals) (Nullifier, error) {
+	value, ok := new(big.Int).SetString(signals.Nullifier, 10)
+	if !ok {
+		return "", fmt.Errorf("%w: failed to parse '%s' as integer",
+			ErrInvalidNullifier, signals.Nullifier)
+	}
+	return New(TypeAnonAadhaar, value)
+}
+
+// FromPassport derives the document nullifier from the issuing country, the
+// document number and the date of birth of the DG1 data, keyed with the
+// issuer secret: Poseidon(secret, hash(issuingCountry), hash(documentNumber),
+// hash(dateOfBirth)). The fields are easy to enumerate, so without the
+// secret a leaked nullifier would reveal the document. The issuer must keep
+// the secret private and use the same one for all documents.
+func FromPassport(p *passport.Passport, secret *big.Int) (Nullifier, error) {
+	if secret == nil || secret.Sign() <= 0 {
+		return "", fmt.Errorf("%w: secret is missing", ErrInvalidNullifier)
+	}
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"issuing country", p.IssuingCountry},
+		{"document number", p.DocumentNumber},
+		{"date of birth", p.DateOfBirth},
+	}
+	hashes := make([]*big.Int, 0, len(fields)+1)
+	hashes = append(hashes, secret)
+	for _, field := range fields {
+		if field.value == "" {
+			return "", fmt.Errorf("%w: %s is missing", ErrInvalidNullifier, field.name)
+		}
+		h, err := common.HashValue(field.value)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", field.name, err)
+		}
+		hashes = append(hashes, h)
+	}
+	value, err := poseidon.Hash(hashes)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash document nullifier: %w", err)
+	}
+	return New(TypePassport, value)
+}
+
+// Store records used nullifiers. CheckAndInsert is atomic: of concurrent
+// calls with the same nullifier exactly one returns Fresh.
+type Store interface {
+	CheckAndInsert(ctx context.Context, n Nullifier) (Status, error)
+}
@@ -0,0 +1,169 @@
+package nullifier
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	anonaadhaar "github.com/0xPolygonID/go-circuit-external/AnonAadhaar"
+	"github.com/0xPolygonID/go-circuit-external/passport"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore checks the check-and-insert semantics of the store, including
+// concurrent inserts of the same nullifier.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	n, err := New(TypePassport, big.NewInt(1))
+	require.NoError(t, err)
+
+	status, err := s.CheckAndInsert(ctx, n)
+	require.NoError(t, err)
+	require.Equal(t, Fresh, status)
+	status, err = s.CheckAndInsert(ctx, n)
+	require.NoError(t, err)
+	require.Equal(t, Used, status)
+
+	// The same value of another document type is fresh.
+	other, err := New(TypeAnonAadhaar, big.NewInt(1))
+	require.NoError(t, err)
+	status, err = s.CheckAndInsert(ctx, other)
+	require.NoError(t, err)
+	require.Equal(t, Fresh, status)
+
+	_, err = s.CheckAndInsert(ctx, "passport:abc")
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.CheckAndInsert(canceled, n)
+	require.ErrorIs(t, err, context.Canceled)
+
+	concurrent, err := New(TypePassport, big.NewInt(2))
+	require.NoError(t, err)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		fresh int
+	)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := s.CheckAndInsert(ctx, concurrent)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if status == Fresh {
+				fresh++
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, fresh)
+}
+
+func TestNullifier_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   Nullifier
+		wantErr bool
+	}{
+		{name: "Valid", input: "passport:123"},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Missing type", input: ":123", wantErr: true},
+		{name: "Missing value", input: "passport:", wantErr: true},
+		{name: "Not decimal", input: "passport:0x12", wantErr: true},
+		{name: "Leading zero", input: "passport:0123", wantErr: true},
+		{name: "Negative", input: "passport:-1", wantErr: true},
+		{name: "Type with digits and separators", input: "id_card-2:123"},
+		{name: "Uppercase type", input: "Passport:123", wantErr: true},
+		{name: "Line break in type", input: "pass\nport:123", wantErr: true},
+		{name: "Spaces around type", input: " passport :123", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidNullifier)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	for _, docType := range []string{"", "pass:port", "passport\n", " passport", "pass port", "паспорт"} {
+		_, err := New(docType, big.NewInt(1))
+		require.ErrorIs(t, err, ErrInvalidNullifier, docType)
+	}
+	_, err := New("id_card-2", big.NewInt(1))
+	require.NoError(t, err)
+	_, err = New(TypePassport, big.NewInt(-1))
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+}
+
+func TestFromAnonAadhaar(t *testing.T) {
+	publicInputs, err := os.ReadFile("../AnonAadhaar/testdata/outputs.json")
+	require.NoError(t, err)
+	signals := &anonaadhaar.AnonAadhaarV1PubSignals{}
+	require.NoError(t, signals.PubSignalsUnmarshal(publicInputs))
+
+	n, err := FromAnonAadhaar(signals)
+	require.NoError(t, err)
+	require.Equal(t, Nullifier("anonaadhaar:"+signals.Nullifier), n)
+
+	_, err = FromAnonAadhaar(&anonaadhaar.AnonAadhaarV1PubSignals{Nullifier: "abc"})
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+}
+
+func TestFromPassport(t *testing.T) {
+	p := &passport.Passport{
+		IssuingCountry: "UTO",
+		DocumentNumber: "L898902C3",
+		DateOfBirth:    "740812",
+		HolderName:     "ERIKSSON ANNA MARIA",
+	}
+	secret := big.NewInt(42)
+	n, err := FromPassport(p, secret)
+	require.NoError(t, err)
+	require.NoError(t, n.Validate())
+
+	// Another issuer secret gives another nullifier.
+	other, err := FromPassport(p, big.NewInt(43))
+	require.NoError(t, err)
+	require.NotEqual(t, n, other)
+
+	// Only the issuing country, document number and birth date are used.
+	renamed := *p
+	renamed.HolderName = "ERIKSSON ANNA"
+	same, err := FromPassport(&renamed, secret)
+	require.NoError(t, err)
+	require.Equal(t, n, same)
+
+	for _, change := range []func(p *passport.Passport){
+		func(p *passport.Passport) { p.IssuingCountry = "D" },
+		func(p *passport.Passport) { p.DocumentNumber = "L898902C4" },
+		func(p *passport.Passport) { p.DateOfBirth = "740813" },
+	} {
+		changed := *p
+		change(&changed)
+		other, err := FromPassport(&changed, secret)
+		require.NoError(t, err)
+		require.NotEqual(t, n, other)
+	}
+
+	missing := *p
+	missing.DocumentNumber = ""
+	_, err = FromPassport(&missing, secret)
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+	_, err = FromPassport(p, nil)
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+	_, err = FromPassport(p, big.NewInt(0))
+	require.ErrorIs(t, err, ErrInvalidNullifier)
+}
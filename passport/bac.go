@@ -0,0 +1,171 @@
+package passport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // SHA-1 is required by ICAO 9303 BAC
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+)
+
+// Key derivation counters.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 60
+const (
+	kdfCounterEnc  = 1
+	kdfCounterMAC  = 2
+	kdfCounterPACE = 3
+)
+
+const (
+	bacNonceSize = 8
+	bacKeySize   = 16
+)
+
+// ErrMutualAuthentication is returned when the chip fails to prove knowledge
+// of the access keys.
+var ErrMutualAuthentication = errors.New("mutual authentication failed")
+
+// BACKey is the document basic access key printed in the MRZ.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 22
+type BACKey struct {
+	DocumentNumber string // Document number without check digit
+	DateOfBirth    string // Date of birth, YYMMDD
+	DateOfExpiry   string // Date of expiry, YYMMDD
+}
+
+// BACKey returns the basic access key of the document.
+func (p *Passport) BACKey() BACKey {
+	return BACKey{
+		DocumentNumber: p.DocumentNumber,
+		DateOfBirth:    p.DateOfBirth,
+		DateOfExpiry:   p.DateOfExpiry,
+	}
+}
+
+// mrzInformation returns the document number, date of birth and date of
+// expiry with their check digits. Document numbers shorter than nine
+// characters are padded with '<'.
+func (k BACKey) mrzInformation() (string, error) {
+	number := k.DocumentNumber
+	if len(number) < 9 {
+		number += strings.Repeat("<", 9-len(number))
+	}
+	if len(k.DateOfBirth) != 6 || len(k.DateOfExpiry) != 6 {
+		return "", errors.New("invalid BAC key: dates should be YYMMDD")
+	}
+	var info strings.Builder
+	for _, field := range []string{number, k.DateOfBirth, k.DateOfExpiry} {
+		checkDigit, err := calculateCheckDigit(field)
+		if err != nil {
+			return "", fmt.Errorf("invalid BAC key: %w", err)
+		}
+		info.WriteString(field + checkDigit)
+	}
+	return info.String(), nil
+}
+
+// Keys derives the 3DES encryption and MAC keys Kenc and Kmac.
+func (k BACKey) Keys() (kEnc, kMac []byte, err error) {
+	info, err := k.mrzInformation()
+	if err != nil {
+		return nil, nil, err
+	}
+	//nolint:gosec // SHA-1 is required by ICAO 9303
+	digest := sha1.Sum([]byte(info))
+	seed := digest[:bacKeySize]
+	return deriveTDESKey(seed, kdfCounterEnc), deriveTDESKey(seed, kdfCounterMAC), nil
+}
+
+// deriveTDESKey derives a two key 3DES key from the seed with SHA-1 and
+// adjusts the parity bits.
+func deriveTDESKey(seed []byte, counter uint32) []byte {
+	//nolint:gosec // SHA-1 is required by ICAO 9303
+	digest := sha1.Sum(binary.BigEndian.AppendUint32(append([]byte{}, seed...), counter))
+	key := digest[:bacKeySize]
+	for i, b := range key {
+		// DES uses odd parity in the least significant bit of every byte.
+		key[i] = b&0xFE | ^byte(bits.OnesCount8(b>>1))&1
+	}
+	return key
+}
+
+// PerformBAC runs Basic Access Control with the chip and returns the
+// secure messaging session. The eMRTD application should be selected first,
+// see SelectApplication.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 22
+func PerformBAC(t Transport, key BACKey) (*SecureMessaging, error) {
+	return performBAC(t, key, rand.Reader)
+}
+
+func performBAC(t Transport, key BACKey, random io.Reader) (*SecureMessaging, error) {
+	kEnc, kMac, err := key.Keys()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := newTDESCipher(kEnc, kMac)
+	if err != nil {
+		return nil, err
+	}
+
+	rndIC, err := transmit(t, []byte{0x00, 0x84, 0x00, 0x00, bacNonceSize})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+	if len(rndIC) != bacNonceSize {
+		return nil, fmt.Errorf("invalid challenge length %d", len(rndIC))
+	}
+	rndIFD := make([]byte, bacNonceSize)
+	kIFD := make([]byte, bacKeySize)
+	if _, err = io.ReadFull(random, rndIFD); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	if _, err = io.ReadFull(random, kIFD); err != nil {
+		return nil, fmt.Errorf("failed to generate key material: %w", err)
+	}
+
+	eIFD := enc.encrypt(nil, concatBytes(rndIFD, rndIC, kIFD))
+	mIFD := enc.mac(pad(eIFD, enc.blockSize()))
+	cmdData := concatBytes(eIFD, mIFD)
+	apdu := append([]byte{0x00, 0x82, 0x00, 0x00, byte(len(cmdData))}, cmdData...)
+	response, err := transmit(t, append(apdu, byte(len(cmdData))))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMutualAuthentication, err)
+	}
+	if len(response) != len(cmdData) {
+		return nil, fmt.Errorf("%w: invalid response length %d",
+			ErrMutualAuthentication, len(response))
+	}
+	eIC, mIC := response[:len(eIFD)], response[len(eIFD):]
+	if subtle.ConstantTimeCompare(mIC, enc.mac(pad(eIC, enc.blockSize()))) != 1 {
+		return nil, fmt.Errorf("%w: MAC does not match", ErrMutualAuthentication)
+	}
+	r := enc.decrypt(nil, eIC)
+	if !bytes.Equal(r[:bacNonceSize], rndIC) ||
+		!bytes.Equal(r[bacNonceSize:2*bacNonceSize], rndIFD) {
+		return nil, fmt.Errorf("%w: nonces do not match", ErrMutualAuthentication)
+	}
+	kIC := r[2*bacNonceSize:]
+
+	seed := make([]byte, bacKeySize)
+	subtle.XORBytes(seed, kIFD, kIC)
+	session, err := newTDESCipher(
+		deriveTDESKey(seed, kdfCounterEnc), deriveTDESKey(seed, kdfCounterMAC))
+	if err != nil {
+		return nil, err
+	}
+	ssc := concatBytes(rndIC[4:], rndIFD[4:])
+	return newSecureMessaging(t, session, ssc), nil
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package passport
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ICAO 9303 part 11 appendix D.2 and D.3 worked example.
+var testBACKey = BACKey{
+	DocumentNumber: "L898902C",
+	DateOfBirth:    "690806",
+	DateOfExpiry:   "940623",
+}
+
+func TestBACKey_Keys(t *testing.T) {
+	info, err := testBACKey.mrzInformation()
+	require.NoError(t, err)
+	require.Equal(t, "L898902C<369080619406236", info)
+
+	kEnc, kMac, err := testBACKey.Keys()
+	require.NoError(t, err)
+	require.Equal(t, "AB94FDECF2674FDFB9B391F85D7F76F2", strings.ToUpper(hex.EncodeToString(kEnc)))
+	require.Equal(t, "7962D9ECE03D1ACD4C76089DCE131543", strings.ToUpper(hex.EncodeToString(kMac)))
+
+	dg1, err := ParseMRZ("P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+		"L898902C36UTO7408122F1204159ZE184226B<<<<<10")
+	require.NoError(t, err)
+	require.Equal(t, BACKey{"L898902C3", "740812", "120415"}, dg1.BACKey())
+
+	for _, key := range []BACKey{
+		{DocumentNumber: "L898902C", DateOfBirth: "6908", DateOfExpiry: "940623"},
+		{DocumentNumber: "l898902c", DateOfBirth: "690806", DateOfExpiry: "940623"},
+	} {
+		_, _, err = key.Keys()
+		require.Error(t, err)
+	}
+}
+
+func TestPerformBAC(t *testing.T) {
+	rndIFD := mustDecodeHex(t, "781723860C06C226")
+	kIFD := mustDecodeHex(t, "0B795240CB7049B01C19B33E32804F0B")
+	card := &testCard{t: t, exchanges: append([]apduExchange{
+		{command: "0084000008", response: "4608F919887022129000"},
+		{
+			command: "0082000028" +
+				"72C29C2371CC9BDB65B779B8E8D37B29ECC154AA56A8799FAE2F498F76ED92F2" +
+				"5F1448EEA8AD90A7" + "28",
+			response: "46B9342A41396CD7386BF5803104D7CEDC122B9132139BAF2EEDC94EE178534F" +
+				"2F2D235D074D7449" + "9000",
+		},
+	}, testReadCOMExchanges...)}
+
+	sm, err := performBAC(card, testBACKey, bytes.NewReader(concatBytes(rndIFD, kIFD)))
+	require.NoError(t, err)
+	require.Equal(t, testSSC, strings.ToUpper(hex.EncodeToString(sm.ssc)))
+
+	file, err := ReadFile(sm, FileCOM)
+	require.NoError(t, err)
+	require.Equal(t, "60145F0104303130365F36063034303030305C026175",
+		strings.ToUpper(hex.EncodeToString(file)))
+}
+
+func TestPerformBAC_Invalid(t *testing.T) {
+	random := "781723860C06C226" + "0B795240CB7049B01C19B33E32804F0B"
+	authenticate := "0082000028" +
+		"72C29C2371CC9BDB65B779B8E8D37B29ECC154AA56A8799FAE2F498F76ED92F2" +
+		"5F1448EEA8AD90A7" + "28"
+
+	tests := []struct {
+		name      string
+		exchanges []apduExchange
+		err       error
+	}{
+		{
+			name: "Wrong access key",
+			exchanges: []apduExchange{
+				{command: "0084000008", response: "4608F919887022129000"},
+				{command: authenticate, response: "6300"},
+			},
+			err: ErrMutualAuthentication,
+		},
+		{
+			name: "Wrong MAC",
+			exchanges: []apduExchange{
+				{command: "0084000008", response: "4608F919887022129000"},
+				{
+					command: authenticate,
+					response: "46B9342A41396CD7386BF5803104D7CEDC122B9132139BAF2EEDC94EE178534F" +
+						"2F2D235D074D744A" + "9000",
+				},
+			},
+			err: ErrMutualAuthentication,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := &testCard{t: t, exchanges: tt.exchanges}
+			_, err := performBAC(card, testBACKey, bytes.NewReader(mustDecodeHex(t, random)))
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	card := &testCard{t: t, exchanges: []apduExchange{
+		{command: "0084000008", response: "6D00"},
+	}}
+	_, err := performBAC(card, testBACKey, bytes.NewReader(mustDecodeHex(t, random)))
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+}
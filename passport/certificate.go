@@ -0,0 +1,207 @@
+package passport
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	oidExtensionSubjectKeyID     = asn1.ObjectIdentifier{2, 5, 29, 14}
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionAuthorityKeyID   = asn1.ObjectIdentifier{2, 5, 29, 35}
+)
+
+// errNotECCertificate is returned by parseECCertificate for certificates
+// without an EC public key.
+var errNotECCertificate = errors.New("public key is not an EC key")
+
+// ECPublicKey is an EC public key on a curve crypto/ecdsa does not support,
+// e.g. brainpoolP256r1. crypto/x509 can not parse certificates with such
+// keys or with explicit EC domain parameters, so the SOD and the trust store
+// parse them with the curves of this package. The public key of these
+// certificates is an *ECPublicKey, or an *ecdsa.PublicKey for NIST curves
+// with explicit parameters. The names, validity, key identifiers, key usage
+// and basic constraints are filled, and CheckSignature and the other
+// methods of x509.Certificate do not support them.
+type ECPublicKey struct {
+	CurveName string // Name of the curve, "explicit" for unknown explicit parameters
+	X, Y      *big.Int
+	curve     *ecCurve
+}
+
+// RFC 5280 certificate structures.
+type certificate struct {
+	TBSCertificate     asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+type tbsCertificate struct {
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           validity
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	IssuerUniqueID     asn1.BitString   `asn1:"optional,tag:1"`
+	SubjectUniqueID    asn1.BitString   `asn1:"optional,tag:2"`
+	Extensions         []pkix.Extension `asn1:"optional,explicit,tag:3"`
+}
+
+type validity struct {
+	NotBefore, NotAfter time.Time
+}
+
+type authorityKeyID struct {
+	ID []byte `asn1:"optional,tag:0"`
+}
+
+type basicConstraints struct {
+	IsCA       bool `asn1:"optional"`
+	MaxPathLen int  `asn1:"optional,default:-1"`
+}
+
+// parseCertificates parses a sequence of DER encoded certificates, e.g. the
+// certificates of a CMS SignedData.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for len(data) > 0 {
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &raw)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := parseCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+		data = rest
+	}
+	return certs, nil
+}
+
+// parseCertificate parses a DER encoded certificate. Certificates with EC
+// keys crypto/x509 rejects, which many Document Signer and CSCA certificates
+// use, are parsed with the curves of ecc.go, see ECPublicKey.
+func parseCertificate(der []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err == nil {
+		return cert, nil
+	}
+	cert, ecErr := parseECCertificate(der)
+	switch {
+	case errors.Is(ecErr, errNotECCertificate):
+		return nil, err
+	case ecErr != nil:
+		return nil, ecErr
+	}
+	return cert, nil
+}
+
+func parseECCertificate(der []byte) (*x509.Certificate, error) {
+	var c certificate
+	if err := unmarshalDER(der, &c); err != nil {
+		return nil, errNotECCertificate
+	}
+	var tbs tbsCertificate
+	if err := unmarshalDER(c.TBSCertificate.FullBytes, &tbs); err != nil {
+		return nil, errNotECCertificate
+	}
+	var spki subjectPublicKeyInfo
+	if err := unmarshalDER(tbs.PublicKey.FullBytes, &spki); err != nil {
+		return nil, errNotECCertificate
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidECPublicKey) {
+		return nil, errNotECCertificate
+	}
+	curve, err := parseECParameters(spki.Algorithm.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate public key: %w", err)
+	}
+	point, err := curve.unmarshal(spki.PublicKey.RightAlign())
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate public key: %w", err)
+	}
+
+	cert := &x509.Certificate{
+		Raw:                     der,
+		RawTBSCertificate:       c.TBSCertificate.FullBytes,
+		RawSubjectPublicKeyInfo: tbs.PublicKey.FullBytes,
+		RawSubject:              tbs.Subject.FullBytes,
+		RawIssuer:               tbs.Issuer.FullBytes,
+		Signature:               c.SignatureValue.RightAlign(),
+		PublicKeyAlgorithm:      x509.ECDSA,
+		Version:                 tbs.Version + 1,
+		SerialNumber:            tbs.SerialNumber,
+		NotBefore:               tbs.Validity.NotBefore,
+		NotAfter:                tbs.Validity.NotAfter,
+		Extensions:              tbs.Extensions,
+	}
+	if curve.nist != nil {
+		cert.PublicKey = &ecdsa.PublicKey{Curve: curve.nist, X: point.x, Y: point.y}
+	} else {
+		cert.PublicKey = &ECPublicKey{CurveName: curve.name, X: point.x, Y: point.y, curve: curve}
+	}
+	for _, name := range []struct {
+		raw  []byte
+		dest *pkix.Name
+	}{
+		{tbs.Issuer.FullBytes, &cert.Issuer},
+		{tbs.Subject.FullBytes, &cert.Subject},
+	} {
+		var rdn pkix.RDNSequence
+		if err = unmarshalDER(name.raw, &rdn); err != nil {
+			return nil, fmt.Errorf("invalid name: %w", err)
+		}
+		name.dest.FillFromRDNSequence(&rdn)
+	}
+	if err = parseCertificateExtensions(cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// parseCertificateExtensions fills the certificate fields of the extensions
+// used by passive authentication.
+func parseCertificateExtensions(cert *x509.Certificate) error {
+	for _, ext := range cert.Extensions {
+		var err error
+		switch {
+		case ext.Id.Equal(oidExtensionSubjectKeyID):
+			err = unmarshalDER(ext.Value, &cert.SubjectKeyId)
+		case ext.Id.Equal(oidExtensionAuthorityKeyID):
+			var aki authorityKeyID
+			err = unmarshalDER(ext.Value, &aki)
+			cert.AuthorityKeyId = aki.ID
+		case ext.Id.Equal(oidExtensionKeyUsage):
+			var bits asn1.BitString
+			err = unmarshalDER(ext.Value, &bits)
+			for i := 0; i < bits.BitLength && i < 9; i++ {
+				if bits.At(i) != 0 {
+					cert.KeyUsage |= 1 << i
+				}
+			}
+		case ext.Id.Equal(oidExtensionBasicConstraints):
+			bc := basicConstraints{MaxPathLen: -1}
+			err = unmarshalDER(ext.Value, &bc)
+			cert.IsCA, cert.MaxPathLen, cert.BasicConstraintsValid = bc.IsCA, bc.MaxPathLen, true
+		default:
+			if ext.Critical {
+				cert.UnhandledCriticalExtensions = append(cert.UnhandledCriticalExtensions, ext.Id)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid certificate extension '%s': %w", ext.Id, err)
+		}
+	}
+	return nil
+}
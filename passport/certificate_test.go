@@ -0,0 +1,191 @@
+package passport
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCurveKey is a private key on a curve of ecc.go which crypto/ecdsa does
+// not support. It signs with DER encoded signatures like ecdsa.PrivateKey.
+type testCurveKey struct {
+	t     *testing.T
+	curve *ecCurve
+	d     *big.Int
+	point ecPoint
+}
+
+func newTestCurveKey(t *testing.T, curve *ecCurve) *testCurveKey {
+	t.Helper()
+	d, point, err := curve.generateKey(rand.Reader, curve.g)
+	require.NoError(t, err)
+	return &testCurveKey{t: t, curve: curve, d: d, point: point}
+}
+
+func (k *testCurveKey) Public() crypto.PublicKey {
+	return &ECPublicKey{CurveName: k.curve.name, X: k.point.x, Y: k.point.y, curve: k.curve}
+}
+
+func (k *testCurveKey) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	signature := signPlainECDSA(k.t, k.curve, k.d, digest)
+	size := len(signature) / 2
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(signature[:size]),
+		new(big.Int).SetBytes(signature[size:]),
+	})
+}
+
+// newTestCurveSigner issues a certificate for a key x509.CreateCertificate
+// does not support, with the named curve or with explicit domain parameters
+// when curveOID is nil. The certificate is a self-signed CSCA when parent is
+// nil, or a Document Signer certificate otherwise.
+func newTestCurveSigner(
+	t *testing.T, key *testCurveKey, curveOID asn1.ObjectIdentifier,
+	parent *testSigner, serial int64, notAfter time.Time,
+) *testSigner {
+	t.Helper()
+	keyID := []byte{byte(serial), 1, 2, 3}
+	subject := pkix.Name{Country: []string{"UTO"}, CommonName: "Test Document Signer"}
+	extensions := []pkix.Extension{
+		{Id: oidExtensionSubjectKeyID, Value: mustMarshal(t, keyID, "")},
+	}
+	var signer crypto.Signer = key
+	var issuer []byte
+	if parent == nil {
+		subject.CommonName = "Test CSCA"
+		issuer = mustMarshal(t, subject.ToRDNSequence(), "")
+		extensions = append(extensions,
+			pkix.Extension{
+				Id: oidExtensionKeyUsage, Critical: true,
+				// keyCertSign and cRLSign
+				Value: mustMarshal(t, asn1.BitString{Bytes: []byte{0x06}, BitLength: 7}, ""),
+			},
+			pkix.Extension{
+				Id: oidExtensionBasicConstraints, Critical: true,
+				Value: mustMarshal(t, basicConstraints{IsCA: true, MaxPathLen: -1}, ""),
+			},
+		)
+	} else {
+		signer, issuer = parent.key, parent.cert.RawSubject
+		extensions = append(extensions,
+			pkix.Extension{
+				Id: oidExtensionKeyUsage, Critical: true,
+				// digitalSignature
+				Value: mustMarshal(t, asn1.BitString{Bytes: []byte{0x80}, BitLength: 1}, ""),
+			},
+			pkix.Extension{
+				Id:    oidExtensionAuthorityKeyID,
+				Value: mustMarshal(t, authorityKeyID{ID: parent.cert.SubjectKeyId}, ""),
+			},
+		)
+	}
+
+	signatureAlgorithm := pkix.AlgorithmIdentifier{
+		Algorithm: testSignatureOID(t, signer, crypto.SHA256),
+	}
+	tbs := mustMarshal(t, tbsCertificate{
+		Version:            2,
+		SerialNumber:       big.NewInt(serial),
+		SignatureAlgorithm: signatureAlgorithm,
+		Issuer:             asn1.RawValue{FullBytes: issuer},
+		Validity: validity{
+			NotBefore: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:  notAfter,
+		},
+		Subject:    asn1.RawValue{FullBytes: mustMarshal(t, subject.ToRDNSequence(), "")},
+		PublicKey:  asn1.RawValue{FullBytes: testECPublicKeyInfo(t, key.curve, curveOID, key.point)},
+		Extensions: extensions,
+	}, "")
+	digest := crypto.SHA256.New()
+	digest.Write(tbs)
+	signature, err := signer.Sign(rand.Reader, digest.Sum(nil), crypto.SHA256)
+	require.NoError(t, err)
+
+	der := mustMarshal(t, certificate{
+		TBSCertificate:     asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: signatureAlgorithm,
+		SignatureValue:     asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	}, "")
+	cert, err := parseCertificate(der)
+	require.NoError(t, err)
+	return &testSigner{key: key, cert: cert}
+}
+
+var oidTestBrainpoolP256r1 = asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 7}
+
+func TestParseCertificate(t *testing.T) {
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	csca := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+
+	tests := []struct {
+		name     string
+		curve    *ecCurve
+		curveOID asn1.ObjectIdentifier
+	}{
+		{name: "Named brainpoolP256r1", curve: standardCurves[13], curveOID: oidTestBrainpoolP256r1},
+		{name: "Explicit brainpoolP384r1", curve: standardCurves[16]},
+		{name: "Explicit P-256", curve: standardCurves[12]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := newTestCurveKey(t, tt.curve)
+			ds := newTestCurveSigner(t, key, tt.curveOID, csca, 2, notAfter)
+			_, err := x509.ParseCertificate(ds.cert.Raw)
+			require.Error(t, err)
+
+			cert, err := parseCertificate(ds.cert.Raw)
+			require.NoError(t, err)
+			require.Equal(t, 3, cert.Version)
+			require.Equal(t, "Test Document Signer", cert.Subject.CommonName)
+			require.Equal(t, csca.cert.RawSubject, cert.RawIssuer)
+			require.Equal(t, "Test CSCA", cert.Issuer.CommonName)
+			require.Equal(t, big.NewInt(2), cert.SerialNumber)
+			require.Equal(t, notAfter, cert.NotAfter)
+			require.Equal(t, []byte{2, 1, 2, 3}, cert.SubjectKeyId)
+			require.Equal(t, csca.cert.SubjectKeyId, cert.AuthorityKeyId)
+			require.Equal(t, x509.KeyUsageDigitalSignature, cert.KeyUsage)
+			require.False(t, cert.IsCA)
+			require.Empty(t, cert.UnhandledCriticalExtensions)
+
+			if tt.curve.nist != nil {
+				pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+				require.True(t, ok)
+				require.Equal(t, key.point.x, pub.X)
+				require.Equal(t, tt.curve.nist, pub.Curve)
+			} else {
+				require.Equal(t, key.Public(), cert.PublicKey)
+			}
+		})
+	}
+
+	t.Run("CSCA", func(t *testing.T) {
+		ecCSCA := newTestCurveSigner(t, newTestCurveKey(t, standardCurves[17]), nil, nil, 3, notAfter)
+		require.True(t, ecCSCA.cert.IsCA)
+		require.True(t, ecCSCA.cert.BasicConstraintsValid)
+		require.Equal(t, x509.KeyUsageCertSign|x509.KeyUsageCRLSign, ecCSCA.cert.KeyUsage)
+		require.Equal(t, ecCSCA.cert.RawSubject, ecCSCA.cert.RawIssuer)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		key := newTestCurveKey(t, standardCurves[13])
+		ds := newTestCurveSigner(t, key, nil, csca, 2, notAfter)
+		offCurve := bytes.Clone(ds.cert.Raw)
+		offCurve[bytes.Index(offCurve, key.curve.marshal(key.point))+1] ^= 0x01
+		_, err := parseCertificate(offCurve)
+		require.Error(t, err)
+
+		_, err = parseCertificate(csca.cert.Raw[:len(csca.cert.Raw)-1])
+		require.Error(t, err)
+	})
+}
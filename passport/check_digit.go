@@ -0,0 +1,157 @@
+package passport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fields protected by check digits.
+const (
+	FieldDocumentNumber = "documentNumber"
+	FieldDateOfBirth    = "dateOfBirth"
+	FieldDateOfExpiry   = "dateOfExpiry"
+	FieldPersonalNumber = "personalNumber"
+	FieldComposite      = "composite"
+)
+
+// CheckDigitError is returned when a check digit does not match
+// the value it protects.
+type CheckDigitError struct {
+	Field    string // Name of the protected field
+	Expected string // Check digit calculated from the field value
+	Actual   string // Check digit stored in the MRZ
+}
+
+func (e *CheckDigitError) Error() string {
+	return fmt.Sprintf(
+		"invalid check digit for %s: expected '%s', got '%s'",
+		e.Field, e.Expected, e.Actual,
+	)
+}
+
+// span is a [start, end) range of characters in the MRZ without line separators.
+type span struct {
+	start, end int
+}
+
+// compositeLayout describes the parts of the MRZ covered by the composite
+// check digit and the position of the check digit itself.
+type compositeLayout struct {
+	spans []span
+	check int
+}
+
+// ICAO 9303 part 4.2.2.2
+var compositeLayouts = map[DocumentFormat]compositeLayout{
+	FormatTD1: {
+		spans: []span{{5, 30}, {30, 37}, {38, 45}, {48, 59}},
+		check: 59,
+	},
+	FormatTD2: {
+		spans: []span{{36, 46}, {49, 56}, {57, 71}},
+		check: 71,
+	},
+	FormatTD3: {
+		spans: []span{{44, 54}, {57, 64}, {65, 87}},
+		check: 87,
+	},
+}
+
+var checkDigitWeights = [3]int{7, 3, 1}
+
+// calculateCheckDigit calculates the check digit of the value using
+// the 7-3-1 weighting from ICAO 9303 part 3 section 4.9.
+func calculateCheckDigit(value string) (string, error) {
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		v, err := charValue(value[i])
+		if err != nil {
+			return "", err
+		}
+		sum += v * checkDigitWeights[i%3]
+	}
+	return strconv.Itoa(sum % 10), nil
+}
+
+func charValue(c byte) (int, error) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), nil
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, nil
+	case c == '<':
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("invalid MRZ character '%c'", c)
+	}
+}
+
+func verifyCheckDigit(field, value, checkDigit string) error {
+	expected, err := calculateCheckDigit(value)
+	if err != nil {
+		return fmt.Errorf("failed to calculate check digit for %s: %w", field, err)
+	}
+	if expected != checkDigit {
+		return &CheckDigitError{Field: field, Expected: expected, Actual: checkDigit}
+	}
+	return nil
+}
+
+// ValidateCheckDigits verifies the check digits of the document number,
+// date of birth, date of expiry, personal number (TD3 only) and the composite
+// check digit (not present on visas). All failures are returned joined together, each one as
+// a *CheckDigitError.
+func (p *Passport) ValidateCheckDigits() error {
+	layout, hasComposite := compositeLayouts[p.Format]
+	if !hasComposite && !p.IsVisa() {
+		return fmt.Errorf("unsupported document format '%s'", p.Format)
+	}
+	if len(p.mrz) <= layout.check {
+		return errors.New("MRZ is not available")
+	}
+
+	var errs []error
+	fields := []struct {
+		name       string
+		value      string
+		checkDigit string
+	}{
+		{FieldDocumentNumber, p.DocumentNumber, p.CheckDigitNumber},
+		{FieldDateOfBirth, p.DateOfBirth, p.CheckDigitDOB},
+		{FieldDateOfExpiry, p.DateOfExpiry, p.CheckDigitExpiry},
+	}
+	for _, f := range fields {
+		if err := verifyCheckDigit(f.name, f.value, f.checkDigit); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// The personal number check digit may be '<' when the field is empty.
+	if p.Format == FormatTD3 &&
+		(strings.Trim(p.PersonalNumber, "<") != "" || p.CheckDigitPersonal != "") {
+		err := verifyCheckDigit(FieldPersonalNumber, p.PersonalNumber, p.CheckDigitPersonal)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if !hasComposite {
+		return errors.Join(errs...)
+	}
+	var composite strings.Builder
+	for _, s := range layout.spans {
+		composite.WriteString(p.mrz[s.start:s.end])
+	}
+	err := verifyCheckDigit(
+		FieldComposite,
+		composite.String(),
+		p.mrz[layout.check:layout.check+1],
+	)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package passport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateCheckDigit(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"L898902C3", "6"},
+		{"740812", "2"},
+		{"120415", "9"},
+		{"ZE184226B<<<<<", "1"},
+		{"L898902C3674081221204159ZE184226B<<<<<1", "0"},
+		{"<<<<<<<<<<<<<<", "0"},
+		{"D23145890", "7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			actual, err := calculateCheckDigit(tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+
+	_, err := calculateCheckDigit("L898 02C3")
+	require.Error(t, err)
+}
+
+func TestValidateCheckDigits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "TD1",
+			input: "I<UTOD231458907<<<<<<<<<<<<<<<" +
+				"7408122F1204159UTO<<<<<<<<<<<6" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+		},
+		{
+			name: "TD2",
+			input: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F1204159<<<<<<<6",
+		},
+		{
+			name: "TD3",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L898902C36UTO7408122F1204159ZE184226B<<<<<10",
+		},
+		{
+			name: "TD3 without personal number",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L898902C36UTO7408122F1204159<<<<<<<<<<<<<<08",
+		},
+		{
+			name: "MRV-A",
+			input: "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L8988901C4XXX4009078F9612109ZE184226B<<<<<<<",
+		},
+		{
+			name: "MRV-B",
+			input: "VCUTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F3004157<<<<<<<<",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseDG1(mrzToDg1(tt.input), WithStrictMode())
+			require.NoError(t, err)
+			require.NoError(t, p.ValidateCheckDigits())
+		})
+	}
+}
+
+func TestValidateCheckDigits_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fields []string
+	}{
+		{
+			name: "TD3 document number",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L898902C46UTO7408122F1204159ZE184226B<<<<<10",
+			fields: []string{FieldDocumentNumber, FieldComposite},
+		},
+		{
+			name: "TD3 date of birth",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L898902C36UTO7408123F1204159ZE184226B<<<<<10",
+			fields: []string{FieldDateOfBirth, FieldComposite},
+		},
+		{
+			name: "TD3 personal number",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L898902C36UTO7408122F1204159ZE184226C<<<<<10",
+			fields: []string{FieldPersonalNumber, FieldComposite},
+		},
+		{
+			name: "TD1 date of expiry",
+			input: "I<UTOD231458907<<<<<<<<<<<<<<<" +
+				"7408122F1204169UTO<<<<<<<<<<<6" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+			fields: []string{FieldDateOfExpiry, FieldComposite},
+		},
+		{
+			name: "TD2 composite",
+			input: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F1204159<<<<<<<5",
+			fields: []string{FieldComposite},
+		},
+		{
+			name: "MRV-A date of birth",
+			input: "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L8988901C4XXX4009068F9612109ZE184226B<<<<<<<",
+			fields: []string{FieldDateOfBirth},
+		},
+		{
+			name: "MRV-B document number",
+			input: "VCUTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458917UTO7408122F3004157<<<<<<<<",
+			fields: []string{FieldDocumentNumber},
+		},
+		{
+			name: "Test passport",
+			input: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<" +
+				"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02",
+			fields: []string{FieldComposite},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG1(mrzToDg1(tt.input), WithStrictMode())
+			require.Error(t, err)
+
+			p, err := ParseDG1(mrzToDg1(tt.input))
+			require.NoError(t, err)
+			err = p.ValidateCheckDigits()
+			require.Error(t, err)
+
+			var joined interface{ Unwrap() []error }
+			require.ErrorAs(t, err, &joined)
+			fields := make([]string, 0, len(joined.Unwrap()))
+			for _, e := range joined.Unwrap() {
+				var cdErr *CheckDigitError
+				require.True(t, errors.As(e, &cdErr))
+				fields = append(fields, cdErr.Field)
+			}
+			require.Equal(t, tt.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package passport
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Chip authenticity failures of Active Authentication and Chip
+// Authentication.
+var (
+	ErrActiveAuthentication = errors.New("active authentication failed")
+	ErrChipAuthentication   = errors.New("chip authentication failed")
+)
+
+// AAChallengeSize is the size of the Active Authentication challenge.
+const AAChallengeSize = 8
+
+// ISO/IEC 9796-2 digital signature scheme 1 with partial message recovery.
+const (
+	iso9796Header          = 0x6A // Partial recovery, no padding
+	iso9796TrailerSHA1     = 0xBC // Implicit SHA-1 trailer
+	iso9796TrailerExplicit = 0xCC // Trailer preceded by the hash identifier
+)
+
+// iso9796HashIDs maps ISO/IEC 10118-3 hash function identifiers of explicit
+// trailers to hash functions.
+var iso9796HashIDs = map[byte]crypto.Hash{
+	0x33: crypto.SHA1,
+	0x34: crypto.SHA256,
+	0x35: crypto.SHA512,
+	0x36: crypto.SHA384,
+	0x38: crypto.SHA224,
+}
+
+// plainECDSAAlgorithms lists BSI TR-03111 ecdsa-plain-signatures algorithms
+// which may be announced in ActiveAuthenticationInfo next to the X9.62 ones.
+var plainECDSAAlgorithms = []struct {
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}{
+	{asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 1, 1, 4, 1, 1}, crypto.SHA1},
+	{asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 1, 1, 4, 1, 2}, crypto.SHA224},
+	{asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 1, 1, 4, 1, 3}, crypto.SHA256},
+	{asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 1, 1, 4, 1, 4}, crypto.SHA384},
+	{asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 1, 1, 4, 1, 5}, crypto.SHA512},
+}
+
+// caKeySizes maps Chip Authentication protocols to session key sizes. Zero
+// means two key 3DES.
+var caKeySizes = map[string]int{
+	oidCAECDH3DES.String():   0,
+	oidCAECDHAES128.String(): 16,
+	oidCAECDHAES192.String(): 24,
+	oidCAECDHAES256.String(): 32,
+}
+
+// Chip Authentication data objects.
+const (
+	caEphemeralKeyTag = 0x91 // Ephemeral public key of MSE:Set KAT
+	caKeyIDTag        = 0x84 // Reference of a private key
+	caEphemeralKeyAT  = 0x80 // Ephemeral public key of GENERAL AUTHENTICATE
+)
+
+// ActiveAuthenticate sends the challenge to the chip with INTERNAL
+// AUTHENTICATE and returns the Active Authentication signature. The
+// challenge should be chosen by the party verifying the signature.
+func ActiveAuthenticate(t Transport, challenge []byte) ([]byte, error) {
+	if len(challenge) != AAChallengeSize {
+		return nil, fmt.Errorf("invalid challenge length %d", len(challenge))
+	}
+	apdu := append([]byte{0x00, 0x88, 0x00, 0x00, AAChallengeSize}, challenge...)
+	signature, err := transmit(t, append(apdu, 0x00))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrActiveAuthentication, err)
+	}
+	return signature, nil
+}
+
+// VerifyActiveAuthentication checks the Active Authentication signature of
+// the challenge with the public key of DG15. RSA signatures use ISO/IEC
+// 9796-2 scheme 1. ECDSA signatures are in plain format and hashed with the
+// algorithm announced in DG14, which is required for EC keys.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 35
+func VerifyActiveAuthentication(dg15 *DG15, dg14 *DG14, challenge, signature []byte) error {
+	if len(challenge) != AAChallengeSize {
+		return fmt.Errorf("%w: invalid challenge length %d", ErrActiveAuthentication, len(challenge))
+	}
+	if dg15 == nil || dg15.PublicKey == nil {
+		return fmt.Errorf("%w: DG15 with the public key is required", ErrActiveAuthentication)
+	}
+	key := dg15.PublicKey
+	if key.RSA != nil {
+		return verifyISO9796Signature(key, challenge, signature)
+	}
+
+	if dg14 == nil || dg14.AASignatureAlgorithm == nil {
+		return fmt.Errorf("%w: DG14 with the signature algorithm is required for EC keys",
+			ErrActiveAuthentication)
+	}
+	hash, err := lookupAAHash(dg14.AASignatureAlgorithm)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrActiveAuthentication, err)
+	}
+	digest := hash.New()
+	digest.Write(challenge)
+	if !key.curve.verify(key.point, digest.Sum(nil), signature) {
+		return fmt.Errorf("%w: ECDSA verification error", ErrActiveAuthentication)
+	}
+	return nil
+}
+
+func lookupAAHash(algorithm asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for _, alg := range plainECDSAAlgorithms {
+		if alg.oid.Equal(algorithm) {
+			return alg.hash, nil
+		}
+	}
+	scheme, hash, err := lookupSignatureAlgorithm(pkix.AlgorithmIdentifier{Algorithm: algorithm}, 0)
+	if err != nil {
+		return 0, err
+	}
+	if scheme != schemeECDSA || hash == 0 {
+		return 0, fmt.Errorf("unsupported signature algorithm '%s'", algorithm)
+	}
+	return hash, nil
+}
+
+// verifyISO9796Signature recovers the message representative of the RSA
+// signature and checks that its hash covers the recovered part M1 and the
+// challenge.
+func verifyISO9796Signature(key *ChipPublicKey, challenge, signature []byte) error {
+	n := key.RSA.N
+	s := new(big.Int).SetBytes(signature)
+	if s.Cmp(n) >= 0 {
+		return fmt.Errorf("%w: signature is out of range", ErrActiveAuthentication)
+	}
+	f := new(big.Int).Exp(s, big.NewInt(int64(key.RSA.E)), n)
+	// The signer may have returned n - J^d, whose representative does not
+	// end with the nibble C.
+	if f.Bit(0) != 0 || f.Bit(1) != 0 || f.Bit(2) != 1 || f.Bit(3) != 1 {
+		f.Sub(n, f)
+	}
+	k := (n.BitLen() + 7) / 8
+	if k < 1+crypto.SHA1.Size()+1 {
+		return fmt.Errorf("%w: RSA modulus is too short", ErrActiveAuthentication)
+	}
+	representative := f.FillBytes(make([]byte, k))
+
+	hash := crypto.SHA1
+	trailerSize := 1
+	switch trailer := representative[k-1]; trailer {
+	case iso9796TrailerSHA1:
+	case iso9796TrailerExplicit:
+		var ok bool
+		if hash, ok = iso9796HashIDs[representative[k-2]]; !ok {
+			return fmt.Errorf("%w: unsupported hash identifier 0x%X",
+				ErrActiveAuthentication, representative[k-2])
+		}
+		trailerSize = 2
+	default:
+		return fmt.Errorf("%w: invalid trailer 0x%X", ErrActiveAuthentication, trailer)
+	}
+	if !hash.Available() {
+		return fmt.Errorf("%w: hash function %s is not available", ErrActiveAuthentication, hash)
+	}
+	if representative[0] != iso9796Header || k < 1+hash.Size()+trailerSize {
+		return fmt.Errorf("%w: invalid message representative header", ErrActiveAuthentication)
+	}
+
+	digestStart := k - trailerSize - hash.Size()
+	m1 := representative[1:digestStart]
+	digest := hash.New()
+	digest.Write(m1)
+	digest.Write(challenge)
+	if subtle.ConstantTimeCompare(digest.Sum(nil), representative[digestStart:k-trailerSize]) != 1 {
+		return fmt.Errorf("%w: message digest does not match", ErrActiveAuthentication)
+	}
+	return nil
+}
+
+// PerformChipAuthentication runs Chip Authentication in ECDH mode over the
+// secure messaging session established by BAC or PACE and returns the
+// session with the keys agreed with the static key of DG14. The chip proves
+// possession of the private key by protecting responses with the new keys,
+// which is checked by selecting EF.COM. DG14 must be verified against the
+// SOD first, otherwise a cloned chip may announce its own key.
+//
+// Chip Authentication is checked by the reader only: the session leaves no
+// evidence the issuer can verify offline. An issuer which does not trust the
+// reader should require Active Authentication of its own challenge instead.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 64
+func PerformChipAuthentication(sm *SecureMessaging, dg14 *DG14) (*SecureMessaging, error) {
+	return performChipAuthentication(sm, dg14, rand.Reader)
+}
+
+func performChipAuthentication(
+	sm *SecureMessaging, dg14 *DG14, random io.Reader,
+) (*SecureMessaging, error) {
+	var info *ChipAuthenticationInfo
+	for i := range dg14.ChipAuthentication {
+		if _, ok := caKeySizes[dg14.ChipAuthentication[i].Protocol.String()]; ok {
+			info = &dg14.ChipAuthentication[i]
+			break
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("%w: no supported protocol in DG14", ErrChipAuthentication)
+	}
+	key, err := dg14.chipAuthenticationKey(*info)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChipAuthentication, err)
+	}
+	curve := key.curve
+	privateKey, publicKey, err := curve.generateKey(random, curve.g)
+	if err != nil {
+		return nil, err
+	}
+
+	keySize := caKeySizes[info.Protocol.String()]
+	var keyID []byte
+	if info.KeyID != nil {
+		keyID = encodeTLV(caKeyIDTag, keyIDBytes(info.KeyID))
+	}
+	if keySize == 0 {
+		err = setKeyAgreementTemplate(sm, curve.marshal(publicKey), keyID)
+	} else {
+		err = chipAuthenticateAES(sm, info.Protocol, curve.marshal(publicKey), keyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChipAuthentication, err)
+	}
+
+	shared := curve.scalarMult(key.point, privateKey)
+	if shared.infinity() {
+		return nil, fmt.Errorf("%w: invalid shared secret", ErrChipAuthentication)
+	}
+	secret := make([]byte, curve.byteSize())
+	shared.x.FillBytes(secret)
+
+	var session smCipher
+	var ssc []byte
+	if keySize == 0 {
+		session, err = newTDESCipher(
+			deriveTDESKey(secret, kdfCounterEnc), deriveTDESKey(secret, kdfCounterMAC))
+		ssc = make([]byte, 8)
+	} else {
+		session, err = newAESCipher(
+			deriveAESKey(secret, kdfCounterEnc, keySize), deriveAESKey(secret, kdfCounterMAC, keySize))
+		ssc = make([]byte, aes.BlockSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	caSM := newSecureMessaging(sm.transport, session, ssc)
+	selectCOM := []byte{0x00, 0xA4, 0x02, 0x0C, 0x02, 0x01, 0x1E} // SELECT EF.COM
+	if _, err = transmit(caSM, selectCOM); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChipAuthentication, err)
+	}
+	return caSM, nil
+}
+
+// setKeyAgreementTemplate sends the ephemeral public key of the terminal with
+// MSE:Set KAT for Chip Authentication with 3DES.
+func setKeyAgreementTemplate(t Transport, publicKey, keyID []byte) error {
+	data := concatBytes(encodeTLV(caEphemeralKeyTag, publicKey), keyID)
+	apdu := append([]byte{0x00, 0x22, 0x41, 0xA6, byte(len(data))}, data...)
+	if _, err := transmit(t, apdu); err != nil {
+		return fmt.Errorf("failed to set key agreement template: %w", err)
+	}
+	return nil
+}
+
+// chipAuthenticateAES selects the protocol with MSE:Set AT and sends the
+// ephemeral public key of the terminal with GENERAL AUTHENTICATE.
+func chipAuthenticateAES(
+	t Transport, protocol asn1.ObjectIdentifier, publicKey, keyID []byte,
+) error {
+	oid, err := asn1.Marshal(protocol)
+	if err != nil {
+		return fmt.Errorf("failed to encode protocol %s: %w", protocol, err)
+	}
+	data := concatBytes(encodeTLV(cryptoMechanismTag, oid[2:]), keyID)
+	apdu := append([]byte{0x00, 0x22, 0x41, 0xA4, byte(len(data))}, data...)
+	if _, err = transmit(t, apdu); err != nil {
+		return fmt.Errorf("failed to set authentication template: %w", err)
+	}
+
+	data = encodeTLV(dynamicAuthDataTag, encodeTLV(caEphemeralKeyAT, publicKey))
+	apdu = append([]byte{0x00, 0x86, 0x00, 0x00, byte(len(data))}, data...)
+	if _, err = transmit(t, append(apdu, 0x00)); err != nil {
+		return fmt.Errorf("failed to send ephemeral public key: %w", err)
+	}
+	return nil
+}
+
+// keyIDBytes encodes the key ID as an unsigned big-endian integer.
+func keyIDBytes(keyID *big.Int) []byte {
+	if b := keyID.Bytes(); len(b) != 0 {
+		return b
+	}
+	return []byte{0x00}
+}
@@ -0,0 +1,350 @@
+package passport
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testAAChallenge = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+// signISO9796 returns the Active Authentication signature of the challenge
+// with ISO/IEC 9796-2 scheme 1. A zero hash ID selects the implicit SHA-1
+// trailer.
+func signISO9796(
+	t *testing.T, key *rsa.PrivateKey, hash crypto.Hash, hashID byte, challenge []byte,
+) []byte {
+	t.Helper()
+	trailer := []byte{iso9796TrailerSHA1}
+	if hashID != 0 {
+		trailer = []byte{hashID, iso9796TrailerExplicit}
+	}
+	m1 := make([]byte, key.Size()-1-hash.Size()-len(trailer))
+	_, err := rand.Read(m1)
+	require.NoError(t, err)
+	digest := hash.New()
+	digest.Write(m1)
+	digest.Write(challenge)
+	representative := concatBytes([]byte{iso9796Header}, m1, digest.Sum(nil), trailer)
+	s := new(big.Int).Exp(new(big.Int).SetBytes(representative), key.D, key.N)
+	return s.FillBytes(make([]byte, key.Size()))
+}
+
+func newTestRSADG15(t *testing.T, key *rsa.PrivateKey) *DG15 {
+	t.Helper()
+	info, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	dg15, err := ParseDG15(testDataGroup(dg15Tag, info))
+	require.NoError(t, err)
+	return dg15
+}
+
+func TestVerifyActiveAuthentication(t *testing.T) {
+	rsaKey := newTestAAKey(t)
+	rsaDG15 := newTestRSADG15(t, rsaKey)
+	sha1Signature := signISO9796(t, rsaKey, crypto.SHA1, 0, testAAChallenge)
+	complement := new(big.Int).Sub(rsaKey.N, new(big.Int).SetBytes(sha1Signature))
+
+	hashed := sha256.Sum256(testAAChallenge)
+	p256 := standardCurves[12]
+	p256Key, p256Pub, err := p256.generateKey(rand.Reader, p256.g)
+	require.NoError(t, err)
+	p256DG15, err := ParseDG15(testDataGroup(dg15Tag,
+		testECPublicKeyInfo(t, p256, oidTestP256, p256Pub)))
+	require.NoError(t, err)
+	x962DG14 := &DG14{AASignatureAlgorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}}
+	p256Signature := signPlainECDSA(t, p256, p256Key, hashed[:])
+
+	brainpool := standardCurves[13]
+	brainpoolKey, brainpoolPub, err := brainpool.generateKey(rand.Reader, brainpool.g)
+	require.NoError(t, err)
+	brainpoolDG15, err := ParseDG15(testDataGroup(dg15Tag,
+		testECPublicKeyInfo(t, brainpool, nil, brainpoolPub)))
+	require.NoError(t, err)
+	plainDG14 := &DG14{AASignatureAlgorithm: oidTestECDSAPlainSHA256}
+
+	tests := []struct {
+		name      string
+		dg15      *DG15
+		dg14      *DG14
+		challenge []byte
+		signature []byte
+		valid     bool
+	}{
+		{
+			name:      "RSA SHA-1",
+			dg15:      rsaDG15,
+			signature: sha1Signature,
+			valid:     true,
+		},
+		{
+			name:      "RSA SHA-256",
+			dg15:      rsaDG15,
+			signature: signISO9796(t, rsaKey, crypto.SHA256, 0x34, testAAChallenge),
+			valid:     true,
+		},
+		{
+			name:      "RSA SHA-512",
+			dg15:      rsaDG15,
+			signature: signISO9796(t, rsaKey, crypto.SHA512, 0x35, testAAChallenge),
+			valid:     true,
+		},
+		{
+			name:      "RSA complement of the signature",
+			dg15:      rsaDG15,
+			signature: complement.Bytes(),
+			valid:     true,
+		},
+		{
+			name:      "RSA other challenge",
+			dg15:      rsaDG15,
+			challenge: []byte("ABCDEFGH"),
+			signature: sha1Signature,
+		},
+		{
+			name:      "RSA wrong hash identifier",
+			dg15:      rsaDG15,
+			signature: signISO9796(t, rsaKey, crypto.SHA256, 0x36, testAAChallenge),
+		},
+		{
+			name:      "RSA other key",
+			dg15:      newTestRSADG15(t, newTestAAKey(t)),
+			signature: sha1Signature,
+		},
+		{
+			name:      "ECDSA X9.62 algorithm",
+			dg15:      p256DG15,
+			dg14:      x962DG14,
+			signature: p256Signature,
+			valid:     true,
+		},
+		{
+			name:      "ECDSA plain algorithm",
+			dg15:      brainpoolDG15,
+			dg14:      plainDG14,
+			signature: signPlainECDSA(t, brainpool, brainpoolKey, hashed[:]),
+			valid:     true,
+		},
+		{
+			name:      "ECDSA other key",
+			dg15:      brainpoolDG15,
+			dg14:      plainDG14,
+			signature: signPlainECDSA(t, brainpool, p256Key, hashed[:]),
+		},
+		{
+			name:      "ECDSA without DG14",
+			dg15:      p256DG15,
+			signature: p256Signature,
+		},
+		{
+			name:      "ECDSA with RSA algorithm",
+			dg15:      p256DG15,
+			dg14:      &DG14{AASignatureAlgorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}},
+			signature: p256Signature,
+		},
+		{
+			name:      "Short challenge",
+			dg15:      rsaDG15,
+			challenge: testAAChallenge[1:],
+			signature: sha1Signature,
+		},
+		{
+			name:      "Without DG15",
+			signature: sha1Signature,
+		},
+		{
+			name:      "RSA tiny modulus",
+			dg15:      &DG15{PublicKey: &ChipPublicKey{RSA: &rsa.PublicKey{N: big.NewInt(253), E: 3}}},
+			signature: []byte{0x02},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge := tt.challenge
+			if challenge == nil {
+				challenge = testAAChallenge
+			}
+			err := VerifyActiveAuthentication(tt.dg15, tt.dg14, challenge, tt.signature)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrActiveAuthentication)
+			}
+		})
+	}
+}
+
+func TestActiveAuthenticate(t *testing.T) {
+	card := &testCard{t: t, exchanges: []apduExchange{
+		{command: "0088000008010203040506070800", response: "A1B2C3D49000"},
+		{command: "0088000008010203040506070800", response: "6300"},
+	}}
+	signature, err := ActiveAuthenticate(card, testAAChallenge)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xA1, 0xB2, 0xC3, 0xD4}, signature)
+
+	_, err = ActiveAuthenticate(card, testAAChallenge)
+	require.ErrorIs(t, err, ErrActiveAuthentication)
+	require.Empty(t, card.exchanges)
+
+	_, err = ActiveAuthenticate(card, testAAChallenge[1:])
+	require.Error(t, err)
+}
+
+// testProtectedExchange wraps the command with the session of the terminal
+// and protects the 9000 response of the chip with the same keys.
+func testProtectedExchange(t *testing.T, sm *SecureMessaging, command []byte) apduExchange {
+	t.Helper()
+	protected, err := sm.Wrap(command)
+	require.NoError(t, err)
+	ssc := sm.incrementSSC()
+	do99 := encodeTLV(smStatusTag, []byte{0x90, 0x00})
+	mac := sm.cipher.mac(pad(concatBytes(ssc, do99), sm.cipher.blockSize()))
+	return apduExchange{
+		command:  hex.EncodeToString(protected),
+		response: hex.EncodeToString(concatBytes(do99, encodeTLV(smMACTag, mac), []byte{0x90, 0x00})),
+	}
+}
+
+func TestPerformChipAuthentication(t *testing.T) {
+	terminalKey := big.NewInt(0x0102030405060708)
+	random := terminalKey.FillBytes(make([]byte, 32))
+	selectCOM := mustDecodeHex(t, "00A4020C02011E")
+
+	tests := []struct {
+		name     string
+		curve    *ecCurve
+		protocol asn1.ObjectIdentifier
+		keyID    *big.Int
+	}{
+		{name: "3DES", curve: standardCurves[13], protocol: oidCAECDH3DES},
+		{name: "AES-128", curve: standardCurves[12], protocol: oidCAECDHAES128, keyID: big.NewInt(1)},
+		{name: "AES-256", curve: standardCurves[13], protocol: oidCAECDHAES256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curve := tt.curve
+			chipKey, chipPub, err := curve.generateKey(rand.Reader, curve.g)
+			require.NoError(t, err)
+			dg14 := &DG14{
+				ChipAuthentication: []ChipAuthenticationInfo{
+					{Protocol: tt.protocol, Version: 1, KeyID: tt.keyID},
+				},
+				PublicKeys: []ChipAuthenticationPublicKey{{
+					PublicKey: &ChipPublicKey{CurveName: curve.name, curve: curve, point: chipPub},
+					KeyID:     tt.keyID,
+				}},
+			}
+
+			// Commands under the BAC session.
+			bac := newTestSecureMessaging(t, nil)
+			terminalPub := curve.marshal(curve.scalarMult(curve.g, terminalKey))
+			var keyID []byte
+			if tt.keyID != nil {
+				keyID = encodeTLV(caKeyIDTag, tt.keyID.Bytes())
+			}
+			var exchanges []apduExchange
+			if tt.protocol.Equal(oidCAECDH3DES) {
+				data := concatBytes(encodeTLV(0x91, terminalPub), keyID)
+				exchanges = append(exchanges, testProtectedExchange(t, bac,
+					concatBytes([]byte{0x00, 0x22, 0x41, 0xA6, byte(len(data))}, data)))
+			} else {
+				data := concatBytes([]byte{0x80, 0x0A}, mustMarshal(t, tt.protocol, "")[2:], keyID)
+				exchanges = append(exchanges, testProtectedExchange(t, bac,
+					concatBytes([]byte{0x00, 0x22, 0x41, 0xA4, byte(len(data))}, data)))
+				data = encodeTLV(0x7C, encodeTLV(0x80, terminalPub))
+				exchanges = append(exchanges, testProtectedExchange(t, bac,
+					concatBytes([]byte{0x00, 0x86, 0x00, 0x00, byte(len(data))}, data, []byte{0x00})))
+			}
+
+			// Session keys derived by the chip.
+			secret := curve.scalarMult(curve.scalarMult(curve.g, terminalKey), chipKey).x.
+				FillBytes(make([]byte, curve.byteSize()))
+			var session smCipher
+			ssc := make([]byte, 8)
+			if size := caKeySizes[tt.protocol.String()]; size == 0 {
+				session, err = newTDESCipher(
+					deriveTDESKey(secret, kdfCounterEnc), deriveTDESKey(secret, kdfCounterMAC))
+			} else {
+				session, err = newAESCipher(
+					deriveAESKey(secret, kdfCounterEnc, size), deriveAESKey(secret, kdfCounterMAC, size))
+				ssc = make([]byte, 16)
+			}
+			require.NoError(t, err)
+			chipSM := newSecureMessaging(nil, session, ssc)
+			exchanges = append(exchanges, testProtectedExchange(t, chipSM, selectCOM))
+
+			card := &testCard{t: t, exchanges: append([]apduExchange{}, exchanges...)}
+			sm, err := performChipAuthentication(newTestSecureMessaging(t, card), dg14,
+				bytes.NewReader(random))
+			require.NoError(t, err)
+			require.Empty(t, card.exchanges)
+			require.Equal(t, session, sm.cipher)
+			require.Equal(t, chipSM.ssc, sm.ssc)
+
+			// The chip does not know the private key of DG14.
+			last := &exchanges[len(exchanges)-1]
+			for _, response := range []string{"6988", flipLastMACByte(t, last.response)} {
+				last.response = response
+				card = &testCard{t: t, exchanges: append([]apduExchange{}, exchanges...)}
+				_, err = performChipAuthentication(newTestSecureMessaging(t, card), dg14,
+					bytes.NewReader(random))
+				require.ErrorIs(t, err, ErrChipAuthentication)
+				require.Empty(t, card.exchanges)
+			}
+		})
+	}
+}
+
+func flipLastMACByte(t *testing.T, response string) string {
+	t.Helper()
+	b := mustDecodeHex(t, response)
+	b[len(b)-3] ^= 0x01
+	return hex.EncodeToString(b)
+}
+
+func TestPerformChipAuthentication_Invalid(t *testing.T) {
+	curve := standardCurves[12]
+	_, pub, err := curve.generateKey(rand.Reader, curve.g)
+	require.NoError(t, err)
+	key := ChipAuthenticationPublicKey{PublicKey: &ChipPublicKey{curve: curve, point: pub}}
+	rsaKey := ChipAuthenticationPublicKey{PublicKey: newTestRSADG15(t, newTestAAKey(t)).PublicKey}
+	aes := []ChipAuthenticationInfo{{Protocol: oidCAECDHAES128, Version: 1}}
+
+	tests := []struct {
+		name string
+		dg14 *DG14
+	}{
+		{name: "No protocol", dg14: &DG14{PublicKeys: []ChipAuthenticationPublicKey{key}}},
+		{name: "No key", dg14: &DG14{ChipAuthentication: aes}},
+		{
+			name: "Unknown key ID",
+			dg14: &DG14{
+				ChipAuthentication: []ChipAuthenticationInfo{
+					{Protocol: oidCAECDHAES128, Version: 1, KeyID: big.NewInt(2)},
+				},
+				PublicKeys: []ChipAuthenticationPublicKey{key},
+			},
+		},
+		{
+			name: "RSA key",
+			dg14: &DG14{ChipAuthentication: aes, PublicKeys: []ChipAuthenticationPublicKey{rsaKey}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := &testCard{t: t}
+			_, err := performChipAuthentication(newTestSecureMessaging(t, card), tt.dg14, rand.Reader)
+			require.ErrorIs(t, err, ErrChipAuthentication)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package passport
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// DG11 data object tags.
+const (
+	dg11Tag                 = 0x6B   // DG11 application template
+	otherNamesTag           = 0xA0   // Content specific constructed template
+	fullNameTag             = 0x5F0E // Name of holder in full
+	otherNameTag            = 0x5F0F // Other name
+	personalNumberTag       = 0x5F10 // Personal number
+	placeOfBirthTag         = 0x5F11 // Place of birth
+	telephoneTag            = 0x5F12 // Telephone
+	professionTag           = 0x5F13 // Profession
+	titleTag                = 0x5F14 // Title
+	personalSummaryTag      = 0x5F15 // Personal summary
+	proofOfCitizenshipTag   = 0x5F16 // Proof of citizenship image
+	otherTravelDocumentsTag = 0x5F17 // Other valid TD numbers
+	custodyInformationTag   = 0x5F18 // Custody information
+	fullDateOfBirthTag      = 0x5F2B // Full date of birth
+	permanentAddressTag     = 0x5F42 // Permanent address
+)
+
+// DG11 represents the additional personal details of the holder.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 44
+type DG11 struct {
+	FullName             string   // Full name of the holder without MRZ truncation
+	OtherNames           []string // Other names of the holder
+	PersonalNumber       string   // Personal number
+	FullDateOfBirth      string   // Date of birth in YYYYMMDD format
+	PlaceOfBirth         []string // Place of birth elements, e.g. city and country
+	Address              []string // Permanent address lines
+	Telephone            string   // Telephone number
+	Profession           string   // Profession of the holder
+	Title                string   // Title of the holder
+	PersonalSummary      string   // Personal summary
+	ProofOfCitizenship   []byte   // Image of the proof of citizenship
+	OtherTravelDocuments []string // Numbers of other valid travel documents
+	CustodyInformation   string   // Custody information
+	Raw                  []byte   // Raw data including group tag
+}
+
+// ParseDG11 parses the provided hex encoded DG11 data.
+func ParseDG11(data string) (*DG11, error) {
+	dg11Raw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG11 format: data should be a hexadecimal string: %w", err)
+	}
+	objs, err := decodeDataGroup(dg11Raw, "DG11", dg11Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG11 format: %w", err)
+	}
+
+	dg11 := &DG11{Raw: dg11Raw}
+	for _, obj := range objs {
+		if set, ok := dg11Fields[obj.tag]; ok {
+			if err := set(dg11, obj); err != nil {
+				return nil, fmt.Errorf("invalid DG11 data element 0x%X: %w", obj.tag, err)
+			}
+		}
+	}
+
+	return dg11, nil
+}
+
+// dg11Fields maps DG11 data element tags to the fields they fill.
+var dg11Fields = map[uint32]func(*DG11, tlv) error{
+	fullNameTag: func(d *DG11, obj tlv) error {
+		d.FullName = parseHolderName(string(obj.value))
+		return nil
+	},
+	otherNamesTag: func(d *DG11, obj tlv) (err error) {
+		d.OtherNames, err = parseNameList(obj, otherNameTag)
+		return err
+	},
+	personalNumberTag: func(d *DG11, obj tlv) error {
+		d.PersonalNumber = trimPlaceholder(string(obj.value))
+		return nil
+	},
+	fullDateOfBirthTag: func(d *DG11, obj tlv) error {
+		d.FullDateOfBirth = parseFullDate(obj.value)
+		return nil
+	},
+	placeOfBirthTag: func(d *DG11, obj tlv) error {
+		d.PlaceOfBirth = splitFields(string(obj.value))
+		return nil
+	},
+	permanentAddressTag: func(d *DG11, obj tlv) error {
+		d.Address = splitFields(string(obj.value))
+		return nil
+	},
+	telephoneTag: func(d *DG11, obj tlv) error {
+		d.Telephone = string(obj.value)
+		return nil
+	},
+	professionTag: func(d *DG11, obj tlv) error {
+		d.Profession = string(obj.value)
+		return nil
+	},
+	titleTag: func(d *DG11, obj tlv) error {
+		d.Title = string(obj.value)
+		return nil
+	},
+	personalSummaryTag: func(d *DG11, obj tlv) error {
+		d.PersonalSummary = string(obj.value)
+		return nil
+	},
+	proofOfCitizenshipTag: func(d *DG11, obj tlv) error {
+		d.ProofOfCitizenship = obj.value
+		return nil
+	},
+	otherTravelDocumentsTag: func(d *DG11, obj tlv) error {
+		d.OtherTravelDocuments = splitFields(string(obj.value))
+		return nil
+	},
+	custodyInformationTag: func(d *DG11, obj tlv) error {
+		d.CustodyInformation = string(obj.value)
+		return nil
+	},
+}
+
+// parseNameList parses a template with the number of names (tag 0x02)
+// followed by the name data elements.
+func parseNameList(template tlv, nameTag uint32) ([]string, error) {
+	objs, err := template.children()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, obj := range objs {
+		if obj.tag == nameTag {
+			names = append(names, parseHolderName(string(obj.value)))
+		}
+	}
+	return names, nil
+}
+
+// parseFullDate returns the YYYYMMDD date stored either as ASCII digits or,
+// by some issuers, as 4 bytes of packed BCD.
+func parseFullDate(value []byte) string {
+	if len(value) == 4 {
+		return hex.EncodeToString(value)
+	}
+	return string(value)
+}
+
+// splitFields splits a value with '<' separated elements.
+func splitFields(value string) []string {
+	var fields []string
+	for _, f := range strings.Split(value, "<") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,115 @@
+package passport
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDataGroup(tag uint32, objs ...[]byte) string {
+	var value []byte
+	for _, obj := range objs {
+		value = append(value, obj...)
+	}
+	return hex.EncodeToString(encodeTLV(tag, value))
+}
+
+func TestParseDG11(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *DG11
+	}{
+		{
+			name: "All text fields",
+			input: testDataGroup(dg11Tag,
+				encodeTLV(0x5C, []byte{0x5F, 0x0E, 0x5F, 0x10, 0x5F, 0x2B, 0x5F, 0x11, 0x5F, 0x42}),
+				encodeTLV(fullNameTag, []byte("KUZNETSOV<MELENDEZ<<VALERIY<ALEXANDROVYCH")),
+				encodeTLV(otherNamesTag, append(
+					encodeTLV(0x02, []byte{0x02}),
+					append(
+						encodeTLV(otherNameTag, []byte("KUZNETSOV<<VALERA")),
+						encodeTLV(otherNameTag, []byte("KUZNETSOV<<VAL"))...,
+					)...,
+				)),
+				encodeTLV(personalNumberTag, []byte("123456789<<<<")),
+				encodeTLV(fullDateOfBirthTag, []byte("19960309")),
+				encodeTLV(placeOfBirthTag, []byte("KYIV<UKRAINE")),
+				encodeTLV(permanentAddressTag, []byte("KHRESHCHATYK 1<KYIV<<UKRAINE")),
+				encodeTLV(telephoneTag, []byte("+380441234567")),
+				encodeTLV(professionTag, []byte("ENGINEER")),
+				encodeTLV(titleTag, []byte("DR")),
+				encodeTLV(personalSummaryTag, []byte("SUMMARY")),
+				encodeTLV(otherTravelDocumentsTag, []byte("AB1234567<CD7654321")),
+				encodeTLV(custodyInformationTag, []byte("NONE")),
+			),
+			expected: &DG11{
+				FullName:             "KUZNETSOV MELENDEZ VALERIY ALEXANDROVYCH",
+				OtherNames:           []string{"KUZNETSOV VALERA", "KUZNETSOV VAL"},
+				PersonalNumber:       "123456789",
+				FullDateOfBirth:      "19960309",
+				PlaceOfBirth:         []string{"KYIV", "UKRAINE"},
+				Address:              []string{"KHRESHCHATYK 1", "KYIV", "UKRAINE"},
+				Telephone:            "+380441234567",
+				Profession:           "ENGINEER",
+				Title:                "DR",
+				PersonalSummary:      "SUMMARY",
+				OtherTravelDocuments: []string{"AB1234567", "CD7654321"},
+				CustodyInformation:   "NONE",
+			},
+		},
+		{
+			name: "BCD date and image",
+			input: testDataGroup(dg11Tag,
+				encodeTLV(fullNameTag, []byte("ERIKSSON<<ANNA<MARIA")),
+				encodeTLV(fullDateOfBirthTag, []byte{0x19, 0x74, 0x08, 0x12}),
+				encodeTLV(proofOfCitizenshipTag, make([]byte, 300)),
+			),
+			expected: &DG11{
+				FullName:           "ERIKSSON ANNA MARIA",
+				FullDateOfBirth:    "19740812",
+				ProofOfCitizenship: make([]byte, 300),
+			},
+		},
+		{
+			name:     "Empty",
+			input:    testDataGroup(dg11Tag),
+			expected: &DG11{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dg11, err := ParseDG11(tt.input)
+			require.NoError(t, err)
+			raw, err := hex.DecodeString(tt.input)
+			require.NoError(t, err)
+			tt.expected.Raw = raw
+			require.Equal(t, tt.expected, dg11)
+		})
+	}
+}
+
+func TestParseDG11_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not hex", input: "zz"},
+		{name: "Wrong tag", input: testDataGroup(dg12Tag)},
+		{name: "Truncated", input: "6b06" + hex.EncodeToString(encodeTLV(fullNameTag, []byte("AB")))},
+		{name: "Trailing bytes", input: testDataGroup(dg11Tag) + "00"},
+		{
+			name:  "Malformed other names",
+			input: testDataGroup(dg11Tag, encodeTLV(otherNamesTag, []byte{0x5F})),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG11(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package passport
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// DG12 data object tags.
+const (
+	dg12Tag                  = 0x6C   // DG12 application template
+	otherPersonsTag          = 0xA0   // Content specific constructed template
+	issuingAuthorityTag      = 0x5F19 // Issuing authority
+	otherPersonNameTag       = 0x5F1A // Name of other person
+	endorsementsTag          = 0x5F1B // Endorsements and observations
+	taxExitRequirementsTag   = 0x5F1C // Tax and exit requirements
+	imageFrontTag            = 0x5F1D // Image of front of document
+	imageRearTag             = 0x5F1E // Image of rear of document
+	dateOfIssueTag           = 0x5F26 // Date of issue
+	personalizationTimeTag   = 0x5F55 // Date and time of personalization
+	personalizationSerialTag = 0x5F56 // Serial number of personalization system
+)
+
+// DG12 represents the additional document details.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 46
+type DG12 struct {
+	IssuingAuthority      string   // Issuing authority
+	DateOfIssue           string   // Date of issue in YYYYMMDD format
+	OtherPersons          []string // Names of other persons included in the document
+	Endorsements          string   // Endorsements and observations
+	TaxExitRequirements   string   // Tax and exit requirements
+	ImageFront            []byte   // Image of the front of the document
+	ImageRear             []byte   // Image of the rear of the document
+	PersonalizationTime   string   // Date and time of personalization in YYYYMMDDhhmmss format
+	PersonalizationSerial string   // Serial number of the personalization system
+	Raw                   []byte   // Raw data including group tag
+}
+
+// ParseDG12 parses the provided hex encoded DG12 data.
+func ParseDG12(data string) (*DG12, error) {
+	dg12Raw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG12 format: data should be a hexadecimal string: %w", err)
+	}
+	objs, err := decodeDataGroup(dg12Raw, "DG12", dg12Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG12 format: %w", err)
+	}
+
+	dg12 := &DG12{Raw: dg12Raw}
+	for _, obj := range objs {
+		if set, ok := dg12Fields[obj.tag]; ok {
+			if err := set(dg12, obj); err != nil {
+				return nil, fmt.Errorf("invalid DG12 data element 0x%X: %w", obj.tag, err)
+			}
+		}
+	}
+
+	return dg12, nil
+}
+
+// dg12Fields maps DG12 data element tags to the fields they fill.
+var dg12Fields = map[uint32]func(*DG12, tlv) error{
+	issuingAuthorityTag: func(d *DG12, obj tlv) error {
+		d.IssuingAuthority = string(obj.value)
+		return nil
+	},
+	dateOfIssueTag: func(d *DG12, obj tlv) error {
+		d.DateOfIssue = parseFullDate(obj.value)
+		return nil
+	},
+	otherPersonsTag: func(d *DG12, obj tlv) (err error) {
+		d.OtherPersons, err = parseNameList(obj, otherPersonNameTag)
+		return err
+	},
+	endorsementsTag: func(d *DG12, obj tlv) error {
+		d.Endorsements = string(obj.value)
+		return nil
+	},
+	taxExitRequirementsTag: func(d *DG12, obj tlv) error {
+		d.TaxExitRequirements = string(obj.value)
+		return nil
+	},
+	imageFrontTag: func(d *DG12, obj tlv) error {
+		d.ImageFront = obj.value
+		return nil
+	},
+	imageRearTag: func(d *DG12, obj tlv) error {
+		d.ImageRear = obj.value
+		return nil
+	},
+	personalizationTimeTag: func(d *DG12, obj tlv) error {
+		// 7 bytes of packed BCD or 14 ASCII digits
+		d.PersonalizationTime = string(obj.value)
+		if len(obj.value) == 7 {
+			d.PersonalizationTime = hex.EncodeToString(obj.value)
+		}
+		return nil
+	},
+	personalizationSerialTag: func(d *DG12, obj tlv) error {
+		d.PersonalizationSerial = string(obj.value)
+		return nil
+	},
+}
@@ -0,0 +1,90 @@
+package passport
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDG12(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *DG12
+	}{
+		{
+			name: "All fields",
+			input: testDataGroup(dg12Tag,
+				encodeTLV(0x5C, []byte{0x5F, 0x19, 0x5F, 0x26}),
+				encodeTLV(issuingAuthorityTag, []byte("MINISTRY OF INTERIOR")),
+				encodeTLV(dateOfIssueTag, []byte("20250803")),
+				encodeTLV(otherPersonsTag, append(
+					encodeTLV(0x02, []byte{0x01}),
+					encodeTLV(otherPersonNameTag, []byte("KUZNETSOVA<<OLENA"))...,
+				)),
+				encodeTLV(endorsementsTag, []byte("NONE")),
+				encodeTLV(taxExitRequirementsTag, []byte("PAID")),
+				encodeTLV(imageFrontTag, []byte{0xFF, 0xD8, 0xFF}),
+				encodeTLV(imageRearTag, []byte{0xFF, 0xD8, 0xFE}),
+				encodeTLV(personalizationTimeTag, []byte("20250803101500")),
+				encodeTLV(personalizationSerialTag, []byte("SN-0001")),
+			),
+			expected: &DG12{
+				IssuingAuthority:      "MINISTRY OF INTERIOR",
+				DateOfIssue:           "20250803",
+				OtherPersons:          []string{"KUZNETSOVA OLENA"},
+				Endorsements:          "NONE",
+				TaxExitRequirements:   "PAID",
+				ImageFront:            []byte{0xFF, 0xD8, 0xFF},
+				ImageRear:             []byte{0xFF, 0xD8, 0xFE},
+				PersonalizationTime:   "20250803101500",
+				PersonalizationSerial: "SN-0001",
+			},
+		},
+		{
+			name: "BCD dates",
+			input: testDataGroup(dg12Tag,
+				encodeTLV(dateOfIssueTag, []byte{0x20, 0x25, 0x08, 0x03}),
+				encodeTLV(personalizationTimeTag, []byte{0x20, 0x25, 0x08, 0x03, 0x10, 0x15, 0x00}),
+			),
+			expected: &DG12{
+				DateOfIssue:         "20250803",
+				PersonalizationTime: "20250803101500",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dg12, err := ParseDG12(tt.input)
+			require.NoError(t, err)
+			raw, err := hex.DecodeString(tt.input)
+			require.NoError(t, err)
+			tt.expected.Raw = raw
+			require.Equal(t, tt.expected, dg12)
+		})
+	}
+}
+
+func TestParseDG12_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not hex", input: "zz"},
+		{name: "Wrong tag", input: testDataGroup(dg11Tag)},
+		{name: "Trailing bytes", input: testDataGroup(dg12Tag) + "00"},
+		{
+			name:  "Malformed other persons",
+			input: testDataGroup(dg12Tag, encodeTLV(otherPersonsTag, []byte{0x5F})),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG12(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
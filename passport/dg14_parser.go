@@ -0,0 +1,147 @@
+package passport
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// dg14Tag is the tag of the DG14 application template.
+const dg14Tag = 0x6E
+
+var (
+	oidCA               = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 3, 2}
+	oidCAECDH3DES       = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 3, 2, 1}
+	oidCAECDHAES128     = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 3, 2, 2}
+	oidCAECDHAES192     = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 3, 2, 3}
+	oidCAECDHAES256     = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 3, 2, 4}
+	oidPKECDH           = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 1, 2}
+	oidAA               = asn1.ObjectIdentifier{2, 23, 136, 1, 1, 5}
+	caInfoProtocolDepth = len(oidCAECDH3DES)
+)
+
+// ChipAuthenticationInfo is a Chip Authentication protocol supported by the
+// chip.
+type ChipAuthenticationInfo struct {
+	Protocol asn1.ObjectIdentifier // CA protocol, e.g. id-CA-ECDH-AES-CBC-CMAC-128
+	Version  int                   // Protocol version, 1
+	KeyID    *big.Int              // Optional identifier of the CA public key
+}
+
+// ChipAuthenticationPublicKey is a static Chip Authentication key of the chip.
+type ChipAuthenticationPublicKey struct {
+	PublicKey *ChipPublicKey
+	KeyID     *big.Int // Optional key identifier, nil when the chip has a single key
+}
+
+// DG14 represents the security options of the chip: Chip Authentication
+// protocols and keys and the Active Authentication signature algorithm.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 55
+type DG14 struct {
+	ChipAuthentication []ChipAuthenticationInfo
+	PublicKeys         []ChipAuthenticationPublicKey
+	// Signature algorithm of Active Authentication with ECDSA keys, nil when
+	// ActiveAuthenticationInfo is not present
+	AASignatureAlgorithm asn1.ObjectIdentifier
+	Raw                  []byte // Raw data including group tag
+}
+
+// ParseDG14 parses the provided hex encoded DG14 data. Unknown security
+// infos are skipped.
+func ParseDG14(data string) (*DG14, error) {
+	dg14Raw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG14 format: data should be a hexadecimal string: %w", err)
+	}
+	dg, rest, err := decodeTLV(dg14Raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG14 format: %w", err)
+	}
+	if len(rest) != 0 || dg.tag != dg14Tag {
+		return nil, fmt.Errorf("invalid DG14 format: %w: expected DG14 tag 0x%X",
+			ErrMalformedTLV, dg14Tag)
+	}
+
+	var infos []securityInfo
+	rest, err = asn1.UnmarshalWithParams(dg.value, &infos, "set")
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG14 format: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("invalid DG14 format: %d trailing bytes", len(rest))
+	}
+
+	dg14 := &DG14{Raw: dg14Raw}
+	for _, info := range infos {
+		if err = dg14.addSecurityInfo(info); err != nil {
+			return nil, fmt.Errorf("invalid DG14 format: %w", err)
+		}
+	}
+	return dg14, nil
+}
+
+func (d *DG14) addSecurityInfo(info securityInfo) error {
+	switch {
+	case info.Protocol.Equal(oidPKECDH):
+		key, err := parseChipPublicKey(info.RequiredData.FullBytes)
+		if err != nil {
+			return fmt.Errorf("invalid ChipAuthenticationPublicKeyInfo: %w", err)
+		}
+		keyID, err := parseOptionalKeyID(info.OptionalData)
+		if err != nil {
+			return fmt.Errorf("invalid ChipAuthenticationPublicKeyInfo: %w", err)
+		}
+		d.PublicKeys = append(d.PublicKeys, ChipAuthenticationPublicKey{PublicKey: key, KeyID: keyID})
+	case len(info.Protocol) == caInfoProtocolDepth && info.Protocol[:len(oidCA)].Equal(oidCA):
+		ca := ChipAuthenticationInfo{Protocol: info.Protocol}
+		if _, err := asn1.Unmarshal(info.RequiredData.FullBytes, &ca.Version); err != nil {
+			return fmt.Errorf("invalid ChipAuthenticationInfo version: %w", err)
+		}
+		keyID, err := parseOptionalKeyID(info.OptionalData)
+		if err != nil {
+			return fmt.Errorf("invalid ChipAuthenticationInfo: %w", err)
+		}
+		ca.KeyID = keyID
+		d.ChipAuthentication = append(d.ChipAuthentication, ca)
+	case info.Protocol.Equal(oidAA):
+		var algorithm asn1.ObjectIdentifier
+		if len(info.OptionalData.FullBytes) != 0 {
+			if _, err := asn1.Unmarshal(info.OptionalData.FullBytes, &algorithm); err != nil {
+				return fmt.Errorf("invalid ActiveAuthenticationInfo: %w", err)
+			}
+		}
+		if algorithm == nil {
+			return errors.New("invalid ActiveAuthenticationInfo: signature algorithm is missing")
+		}
+		d.AASignatureAlgorithm = algorithm
+	}
+	return nil
+}
+
+func parseOptionalKeyID(data asn1.RawValue) (*big.Int, error) {
+	if len(data.FullBytes) == 0 {
+		return nil, nil
+	}
+	var keyID *big.Int
+	if _, err := asn1.Unmarshal(data.FullBytes, &keyID); err != nil {
+		return nil, fmt.Errorf("invalid key ID: %w", err)
+	}
+	return keyID, nil
+}
+
+// chipAuthenticationKey returns the public key for the CA protocol: the key
+// with the same key ID or the only key of the chip.
+func (d *DG14) chipAuthenticationKey(info ChipAuthenticationInfo) (*ChipPublicKey, error) {
+	for _, key := range d.PublicKeys {
+		if info.KeyID == nil && len(d.PublicKeys) == 1 ||
+			info.KeyID != nil && key.KeyID != nil && info.KeyID.Cmp(key.KeyID) == 0 {
+			if key.PublicKey.curve == nil {
+				return nil, errors.New("chip authentication key is not an EC key")
+			}
+			return key.PublicKey, nil
+		}
+	}
+	return nil, errors.New("chip authentication key not found in DG14")
+}
@@ -0,0 +1,114 @@
+package passport
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var oidTestECDSAPlainSHA256 = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 1, 1, 4, 1, 3}
+
+// testSecurityInfo encodes a SecurityInfo with the required data and the
+// optional data when it is not nil.
+func testSecurityInfo(
+	t *testing.T, protocol asn1.ObjectIdentifier, required, optional interface{},
+) []byte {
+	t.Helper()
+	info := securityInfo{
+		Protocol:     protocol,
+		RequiredData: asn1.RawValue{FullBytes: testDER(t, required)},
+	}
+	if optional != nil {
+		info.OptionalData = asn1.RawValue{FullBytes: testDER(t, optional)}
+	}
+	return mustMarshal(t, info, "")
+}
+
+func testDER(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	if der, ok := v.([]byte); ok {
+		return der
+	}
+	return mustMarshal(t, v, "")
+}
+
+// testDG14 encodes the security infos as DG14.
+func testDG14(t *testing.T, infos ...[]byte) string {
+	t.Helper()
+	return testDataGroup(dg14Tag, encodeTLV(0x31, concatBytes(infos...)))
+}
+
+func TestParseDG14(t *testing.T) {
+	curve := standardCurves[13]
+	_, pub, err := curve.generateKey(rand.Reader, curve.g)
+	require.NoError(t, err)
+	keyInfo := testECPublicKeyInfo(t, curve, nil, pub)
+
+	dg14, err := ParseDG14(testDG14(t,
+		testSecurityInfo(t, oidCAECDHAES128, 1, 7),
+		testSecurityInfo(t, oidPKECDH, keyInfo, 7),
+		testSecurityInfo(t, oidAA, 1, oidTestECDSAPlainSHA256),
+		// PACEInfo is skipped.
+		testSecurityInfo(t, oidPACEECDHGMAES128, 2, 13),
+	))
+	require.NoError(t, err)
+	require.Equal(t, []ChipAuthenticationInfo{
+		{Protocol: oidCAECDHAES128, Version: 1, KeyID: big.NewInt(7)},
+	}, dg14.ChipAuthentication)
+	require.Len(t, dg14.PublicKeys, 1)
+	require.Equal(t, big.NewInt(7), dg14.PublicKeys[0].KeyID)
+	require.Equal(t, "brainpoolP256r1", dg14.PublicKeys[0].PublicKey.CurveName)
+	require.Equal(t, oidTestECDSAPlainSHA256, dg14.AASignatureAlgorithm)
+
+	key, err := dg14.chipAuthenticationKey(dg14.ChipAuthentication[0])
+	require.NoError(t, err)
+	require.Equal(t, pub, key.point)
+
+	// A single key without key ID.
+	dg14, err = ParseDG14(testDG14(t,
+		testSecurityInfo(t, oidCAECDH3DES, 1, nil),
+		testSecurityInfo(t, oidPKECDH, keyInfo, nil),
+	))
+	require.NoError(t, err)
+	require.Nil(t, dg14.AASignatureAlgorithm)
+	_, err = dg14.chipAuthenticationKey(dg14.ChipAuthentication[0])
+	require.NoError(t, err)
+	_, err = dg14.chipAuthenticationKey(ChipAuthenticationInfo{KeyID: big.NewInt(1)})
+	require.Error(t, err)
+}
+
+func TestParseDG14_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not hex", input: "6EZZ"},
+		{name: "Wrong tag", input: testDataGroup(dg15Tag, encodeTLV(0x31, nil))},
+		{name: "Not a set", input: testDataGroup(dg14Tag, encodeTLV(0x30, nil))},
+		{
+			name:  "Trailing data",
+			input: testDataGroup(dg14Tag, encodeTLV(0x31, nil), []byte{0x00}),
+		},
+		{
+			name:  "Invalid public key",
+			input: testDG14(t, testSecurityInfo(t, oidPKECDH, []byte{0x30, 0x00}, nil)),
+		},
+		{
+			name:  "Invalid CA version",
+			input: testDG14(t, testSecurityInfo(t, oidCAECDHAES128, "1", nil)),
+		},
+		{
+			name:  "Missing AA algorithm",
+			input: testDG14(t, testSecurityInfo(t, oidAA, 1, nil)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG14(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
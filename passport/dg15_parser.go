@@ -0,0 +1,182 @@
+package passport
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// dg15Tag is the tag of the DG15 application template.
+const dg15Tag = 0x6F
+
+// minRSAKeySize is the smallest accepted size of RSA chip keys in bits.
+const minRSAKeySize = 1024
+
+var (
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPrimeField  = asn1.ObjectIdentifier{1, 2, 840, 10045, 1, 1}
+)
+
+// namedCurves maps curve object identifiers to the standardized domain
+// parameter IDs of standardCurves.
+var namedCurves = map[string]int{
+	"1.3.36.3.3.2.8.1.1.3":  9,  // brainpoolP192r1
+	"1.3.132.0.33":          10, // secp224r1
+	"1.3.36.3.3.2.8.1.1.5":  11, // brainpoolP224r1
+	"1.2.840.10045.3.1.7":   12, // secp256r1
+	"1.3.36.3.3.2.8.1.1.7":  13, // brainpoolP256r1
+	"1.3.36.3.3.2.8.1.1.9":  14, // brainpoolP320r1
+	"1.3.132.0.34":          15, // secp384r1
+	"1.3.36.3.3.2.8.1.1.11": 16, // brainpoolP384r1
+	"1.3.36.3.3.2.8.1.1.13": 17, // brainpoolP512r1
+	"1.3.132.0.35":          18, // secp521r1
+}
+
+// ecParameters are explicit EC domain parameters, RFC 3279 section 2.3.5.
+// Passports often encode their keys with explicit brainpool parameters.
+type ecParameters struct {
+	Version  int
+	FieldID  ecFieldID
+	Curve    ecCurveCoefficients
+	Base     []byte
+	Order    *big.Int
+	Cofactor *big.Int `asn1:"optional"`
+}
+
+type ecFieldID struct {
+	FieldType asn1.ObjectIdentifier
+	Prime     *big.Int
+}
+
+type ecCurveCoefficients struct {
+	A    []byte
+	B    []byte
+	Seed asn1.BitString `asn1:"optional"`
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// ChipPublicKey is a public key of the chip stored in DG14 or DG15.
+type ChipPublicKey struct {
+	RSA       *rsa.PublicKey // RSA key, nil for EC keys
+	CurveName string         // Name of the curve of EC keys
+	curve     *ecCurve
+	point     ecPoint
+}
+
+// parseChipPublicKey parses a DER encoded SubjectPublicKeyInfo with an RSA
+// or an EC key. EC keys may use named curves or explicit domain parameters.
+func parseChipPublicKey(der []byte) (*ChipPublicKey, error) {
+	var spki subjectPublicKeyInfo
+	if err := unmarshalDER(der, &spki); err != nil {
+		return nil, fmt.Errorf("invalid public key info: %w", err)
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidECPublicKey) {
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+		if size := rsaKey.N.BitLen(); size < minRSAKeySize {
+			return nil, fmt.Errorf("RSA key size %d is below %d bits", size, minRSAKeySize)
+		}
+		return &ChipPublicKey{RSA: rsaKey}, nil
+	}
+
+	curve, err := parseECParameters(spki.Algorithm.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	point, err := curve.unmarshal(spki.PublicKey.RightAlign())
+	if err != nil {
+		return nil, fmt.Errorf("invalid EC public key: %w", err)
+	}
+	return &ChipPublicKey{CurveName: curve.name, curve: curve, point: point}, nil
+}
+
+func parseECParameters(params asn1.RawValue) (*ecCurve, error) {
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params.FullBytes, &oid); err == nil {
+		id, ok := namedCurves[oid.String()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported named curve '%s'", oid)
+		}
+		return standardCurves[id], nil
+	}
+
+	var explicit ecParameters
+	if err := unmarshalDER(params.FullBytes, &explicit); err != nil {
+		return nil, fmt.Errorf("invalid EC domain parameters: %w", err)
+	}
+	if !explicit.FieldID.FieldType.Equal(oidPrimeField) {
+		return nil, fmt.Errorf("unsupported EC field type '%s'", explicit.FieldID.FieldType)
+	}
+	curve := &ecCurve{
+		name: "explicit",
+		p:    explicit.FieldID.Prime,
+		a:    new(big.Int).SetBytes(explicit.Curve.A),
+		b:    new(big.Int).SetBytes(explicit.Curve.B),
+		n:    explicit.Order,
+	}
+	if err := curve.validate(); err != nil {
+		return nil, fmt.Errorf("invalid EC domain parameters: %w", err)
+	}
+	g, err := curve.unmarshal(explicit.Base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EC base point: %w", err)
+	}
+	curve.g = g
+	if err = curve.validateBase(); err != nil {
+		return nil, fmt.Errorf("invalid EC base point: %w", err)
+	}
+	// Use the name and the shared parameters of a standardized curve.
+	for _, std := range standardCurves {
+		if std.p.Cmp(curve.p) == 0 && std.a.Cmp(curve.a) == 0 && std.b.Cmp(curve.b) == 0 &&
+			std.n.Cmp(curve.n) == 0 && std.g.x.Cmp(g.x) == 0 && std.g.y.Cmp(g.y) == 0 {
+			return std, nil
+		}
+	}
+	return curve, nil
+}
+
+// DG15 represents the Active Authentication public key of the chip.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 56
+type DG15 struct {
+	PublicKey *ChipPublicKey // Active Authentication public key
+	Raw       []byte         // Raw data including group tag
+}
+
+// ParseDG15 parses the provided hex encoded DG15 data.
+func ParseDG15(data string) (*DG15, error) {
+	dg15Raw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG15 format: data should be a hexadecimal string: %w", err)
+	}
+	dg, rest, err := decodeTLV(dg15Raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG15 format: %w", err)
+	}
+	if len(rest) != 0 || dg.tag != dg15Tag {
+		return nil, fmt.Errorf("invalid DG15 format: %w: expected DG15 tag 0x%X",
+			ErrMalformedTLV, dg15Tag)
+	}
+	if len(dg.value) == 0 {
+		return nil, errors.New("invalid DG15 format: public key is missing")
+	}
+	key, err := parseChipPublicKey(dg.value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG15 format: %w", err)
+	}
+	return &DG15{PublicKey: key, Raw: dg15Raw}, nil
+}
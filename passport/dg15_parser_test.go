@@ -0,0 +1,162 @@
+package passport
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testECPublicKeyInfo encodes the public key as SubjectPublicKeyInfo with
+// the named curve or with explicit domain parameters when curveOID is nil.
+func testECPublicKeyInfo(
+	t *testing.T, curve *ecCurve, curveOID asn1.ObjectIdentifier, pub ecPoint,
+) []byte {
+	t.Helper()
+	var params []byte
+	if curveOID != nil {
+		params = mustMarshal(t, curveOID, "")
+	} else {
+		size := curve.byteSize()
+		params = mustMarshal(t, ecParameters{
+			Version: 1,
+			FieldID: ecFieldID{FieldType: oidPrimeField, Prime: curve.p},
+			Curve: ecCurveCoefficients{
+				A: curve.a.FillBytes(make([]byte, size)),
+				B: curve.b.FillBytes(make([]byte, size)),
+			},
+			Base:     curve.marshal(curve.g),
+			Order:    curve.n,
+			Cofactor: big.NewInt(1),
+		}, "")
+	}
+	point := curve.marshal(pub)
+	return mustMarshal(t, subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidECPublicKey,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	}, "")
+}
+
+var oidTestP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+func newTestAAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	return key
+}
+
+func TestParseDG15(t *testing.T) {
+	rsaKey := newTestAAKey(t)
+	rsaInfo, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	dg15, err := ParseDG15(testDataGroup(dg15Tag, rsaInfo))
+	require.NoError(t, err)
+	require.Equal(t, &rsaKey.PublicKey, dg15.PublicKey.RSA)
+	require.Empty(t, dg15.PublicKey.CurveName)
+	require.Equal(t, testDataGroup(dg15Tag, rsaInfo), hex.EncodeToString(dg15.Raw))
+
+	tests := []struct {
+		name     string
+		curve    *ecCurve
+		curveOID asn1.ObjectIdentifier
+	}{
+		{name: "Named P-256", curve: standardCurves[12], curveOID: oidTestP256},
+		{
+			name:     "Named brainpoolP320r1",
+			curve:    standardCurves[14],
+			curveOID: asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 9},
+		},
+		{name: "Explicit brainpoolP256r1", curve: standardCurves[13]},
+		{name: "Explicit brainpoolP512r1", curve: standardCurves[17]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, pub, err := tt.curve.generateKey(rand.Reader, tt.curve.g)
+			require.NoError(t, err)
+			dg15, err := ParseDG15(testDataGroup(dg15Tag,
+				testECPublicKeyInfo(t, tt.curve, tt.curveOID, pub)))
+			require.NoError(t, err)
+			require.Nil(t, dg15.PublicKey.RSA)
+			require.Equal(t, tt.curve.name, dg15.PublicKey.CurveName)
+			require.Same(t, tt.curve, dg15.PublicKey.curve)
+			require.Equal(t, pub, dg15.PublicKey.point)
+		})
+	}
+
+	// Explicit parameters of a curve which is not standardized.
+	secp256k1 := newBrainpoolCurve("secp256k1",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F",
+		"00", "07",
+		"79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+		"483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+	)
+	dg15, err = ParseDG15(testDataGroup(dg15Tag, testECPublicKeyInfo(t, secp256k1, nil, secp256k1.g)))
+	require.NoError(t, err)
+	require.Equal(t, "explicit", dg15.PublicKey.CurveName)
+}
+
+func TestParseDG15_Invalid(t *testing.T) {
+	p256 := standardCurves[12]
+	offCurve := testECPublicKeyInfo(t, p256, oidTestP256, ecPoint{big.NewInt(1), big.NewInt(1)})
+	unknownCurve := mustMarshal(t, subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidECPublicKey,
+			Parameters: asn1.RawValue{FullBytes: mustMarshal(t, asn1.ObjectIdentifier{1, 2, 3}, "")},
+		},
+		PublicKey: asn1.BitString{Bytes: []byte{0x04}, BitLength: 8},
+	}, "")
+	shortRSA, err := x509.MarshalPKIXPublicKey(&rsa.PublicKey{
+		N: new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 511), big.NewInt(1)),
+		E: 65537,
+	})
+	require.NoError(t, err)
+
+	explicit := func(modify func(curve *ecCurve)) string {
+		curve := *standardCurves[13]
+		modify(&curve)
+		return testDataGroup(dg15Tag, testECPublicKeyInfo(t, &curve, nil, curve.g))
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not hex", input: "6FZZ"},
+		{name: "Zero order", input: explicit(func(c *ecCurve) { c.n = big.NewInt(0) })},
+		{name: "Order one", input: explicit(func(c *ecCurve) { c.n = big.NewInt(1) })},
+		{name: "Wrong order", input: explicit(func(c *ecCurve) { c.n = standardCurves[12].n })},
+		{name: "Composite modulus", input: explicit(func(c *ecCurve) {
+			c.p = new(big.Int).Add(c.p, big.NewInt(2))
+		})},
+		{name: "Singular curve", input: explicit(func(c *ecCurve) {
+			c.a, c.b = big.NewInt(0), big.NewInt(0)
+			c.g = ecPoint{big.NewInt(0), big.NewInt(0)}
+		})},
+		{name: "Base point not on curve", input: explicit(func(c *ecCurve) { c.b = big.NewInt(7) })},
+		{name: "Wrong tag", input: testDataGroup(dg11Tag, []byte{0x30, 0x00})},
+		{name: "Trailing data", input: testDataGroup(dg15Tag, []byte{0x30, 0x00}) + "00"},
+		{name: "Empty", input: testDataGroup(dg15Tag)},
+		{name: "Not public key info", input: testDataGroup(dg15Tag, []byte{0x30, 0x00})},
+		{name: "Point not on curve", input: testDataGroup(dg15Tag, offCurve)},
+		{name: "Unknown named curve", input: testDataGroup(dg15Tag, unknownCurve)},
+		{name: "Short RSA key", input: testDataGroup(dg15Tag, shortRSA)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG15(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
@@ -7,8 +7,18 @@ import (
 	"strings"
 )
 
-// dg1TagSize is the size of the group tag in bytes.
-const dg1TagSize = 5
+// DG1 data object tags.
+const (
+	dg1Tag     = 0x61   // DG1 application template
+	mrzInfoTag = 0x5F1F // MRZ data element
+)
+
+// MRZ lengths without line separators.
+const (
+	td1Length = 90 // 3 lines x 30 characters
+	td2Length = 72 // 2 lines x 36 characters
+	td3Length = 88 // 2 lines x 44 characters
+)
 
 // Sex represents the gender in a passport.
 type Sex string
@@ -19,89 +29,436 @@ const (
 	Other  Sex = "X"
 )
 
-// Passport represents the data structure for a TD3 type passport.
+// DocumentFormat is the size format of a machine readable travel document.
+type DocumentFormat string
+
+const (
+	FormatTD1 DocumentFormat = "TD1" // ID cards and residence permits, 3x30
+	FormatTD2 DocumentFormat = "TD2" // Official travel documents, 2x36
+	FormatTD3 DocumentFormat = "TD3" // Passports, 2x44
+
+	FormatMRVA DocumentFormat = "MRV-A" // Visas, 2x44
+	FormatMRVB DocumentFormat = "MRV-B" // Visas, 2x36
+)
+
+// DataSource is the origin of the DG1 data.
+type DataSource string
+
+const (
+	SourceChip DataSource = "chip" // DG1 sent as read from the document chip, not verified
+	SourceMRZ  DataSource = "mrz"  // DG1 built from the printed MRZ, e.g. by OCR
+)
+
+// Passport represents the data structure for a TD1, TD2 or TD3 type document
+// or an MRV-A or MRV-B visa.
 type Passport struct {
-	DocumentType       string // Document type (P for passport)
-	IssuingCountry     string // Country code of the issuing state
-	DocumentNumber     string // Passport number
-	HolderName         string // Full name of the holder
-	Nationality        string // Nationality of the holder
-	DateOfBirth        string // Date of birth in YYMMDD format
-	Sex                Sex    // Sex (M, F or X)
-	DateOfExpiry       string // Date of expiry in YYMMDD format
-	PersonalNumber     string // Personal number or other identification elements
-	CheckDigitNumber   string // Check digit for document number
-	CheckDigitDOB      string // Check digit for date of birth
-	CheckDigitExpiry   string // Check digit for date of expiry
-	CheckDigitPersonal string // Check digit for personal number
-	CheckDigitFinal    string // Final check digit (for all data)
-	Raw                []byte // Raw data including group tag
+	Format              DocumentFormat // MRZ size format
+	DocumentType        string         // Document type (P for passport, I/A/C for ID cards)
+	IssuingCountry      string         // Country code of the issuing state
+	DocumentNumber      string         // Passport number
+	HolderName          string         // Full name of the holder
+	PrimaryIdentifier   string         // Surname of the holder
+	SecondaryIdentifier string         // Given names of the holder
+	Nationality         string         // Nationality of the holder
+	DateOfBirth         string         // Date of birth in YYMMDD format, "<<" for unknown parts
+	Sex                 Sex            // Sex (M, F or X)
+	DateOfExpiry        string         // Date of expiry in YYMMDD format
+	PersonalNumber      string         // Personal number or other identification elements
+	OptionalData        string         // Second optional data element (TD1 and visas)
+	CheckDigitNumber    string         // Check digit for document number
+	CheckDigitDOB       string         // Check digit for date of birth
+	CheckDigitExpiry    string         // Check digit for date of expiry
+	CheckDigitPersonal  string         // Check digit for personal number (TD3 only)
+	CheckDigitFinal     string         // Final check digit (for all data)
+	Raw                 []byte         // Raw data including group tag
+	Source              DataSource     // Origin of the DG1 data
+
+	mrz string // MRZ without line separators
+}
+
+// nameFields are the positions of the name field in the MRZ without line separators.
+var nameFields = map[DocumentFormat]span{
+	FormatTD1: {60, 90},
+	FormatTD2: {5, 36},
+	FormatTD3: {5, 44},
+
+	FormatMRVA: {5, 44},
+	FormatMRVB: {5, 36},
+}
+
+// ParseOption configures ParseDG1.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	strict bool
+}
+
+// WithStrictMode enables check digit validation of the parsed DG1.
+func WithStrictMode() ParseOption {
+	return func(o *parseOptions) {
+		o.strict = true
+	}
 }
 
 // ParseDG1 parses the provided DG1 data and returns a Passport struct.
-func ParseDG1(data string) (*Passport, error) {
+// The MRZ format is detected from the length of the DG1 content.
+func ParseDG1(data string, opts ...ParseOption) (*Passport, error) {
+	options := &parseOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	dg1Raw, err := hex.DecodeString(data)
 	if err != nil {
-		return nil, fmt.Errorf("invalid TD3 format: data should be a hexadecimal string: %w", err)
+		return nil, fmt.Errorf("invalid DG1 format: data should be a hexadecimal string: %w", err)
+	}
+	mrz, err := decodeDG1(dg1Raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG1 format: %w", err)
+	}
+
+	passport, err := parseMRZ(string(mrz))
+	if err != nil {
+		return nil, err
+	}
+	passport.Raw = dg1Raw
+	passport.Source = SourceChip
+
+	if options.strict {
+		if err := passport.ValidateCheckDigits(); err != nil {
+			return nil, fmt.Errorf("invalid DG1 check digits: %w", err)
+		}
+	}
+
+	return passport, nil
+}
+
+// ParseMRZ parses the printed MRZ of a TD1, TD2 or TD3 document or an MRV-A
+// or MRV-B visa and builds the DG1 envelope from it. Lines can be separated
+// by line breaks or concatenated. Check digits are always validated, and the
+// result is marked with SourceMRZ because the data is not read from the chip.
+// Support of visas and TD1 and TD2 documents stops at parsing: the circuit
+// proves TD3 passports only, so PassportV1Inputs rejects them with
+// ErrUnsupportedFormat.
+func ParseMRZ(text string) (*Passport, error) {
+	mrz, err := normalizeMRZ(text)
+	if err != nil {
+		return nil, err
+	}
+	passport, err := parseMRZ(mrz)
+	if err != nil {
+		return nil, err
+	}
+	passport.Raw = encodeDG1(mrz)
+	passport.Source = SourceMRZ
+
+	if err := passport.ValidateCheckDigits(); err != nil {
+		return nil, fmt.Errorf("invalid MRZ check digits: %w", err)
+	}
+	return passport, nil
+}
+
+// normalizeMRZ joins the MRZ lines and checks that the line layout
+// matches one of the TD formats.
+func normalizeMRZ(text string) (string, error) {
+	lines := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r'
+	})
+	var mrz strings.Builder
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 && len(line) != len(strings.TrimSpace(lines[0])) {
+			return "", fmt.Errorf("invalid MRZ format: line %d has %d characters, expected %d",
+				i+1, len(line), len(strings.TrimSpace(lines[0])))
+		}
+		mrz.WriteString(line)
+	}
+
+	if len(lines) > 1 {
+		lineLength := mrz.Len() / len(lines)
+		if layout, ok := mrzLineLayouts[lineLength]; !ok || layout != len(lines) {
+			return "", fmt.Errorf(
+				"invalid MRZ format: %d lines of %d characters do not match TD1, TD2 or TD3",
+				len(lines), lineLength)
+		}
+	}
+	for i, r := range mrz.String() {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '<' {
+			return "", fmt.Errorf("invalid MRZ format: unexpected character %q at position %d",
+				r, i)
+		}
+	}
+	return mrz.String(), nil
+}
+
+// mrzLineLayouts maps the MRZ line length to the number of lines.
+var mrzLineLayouts = map[int]int{
+	td1Length / 3: 3,
+	td2Length / 2: 2,
+	td3Length / 2: 2,
+}
+
+// encodeDG1 builds the DG1 envelope of the MRZ:
+// 0x61 L { 0x5F1F L MRZ }.
+func encodeDG1(mrz string) []byte {
+	return encodeTLV(dg1Tag, encodeTLV(mrzInfoTag, []byte(mrz)))
+}
+
+// decodeDG1 extracts the MRZ from the DG1 envelope:
+// 0x61 L { 0x5F1F L MRZ }.
+func decodeDG1(data []byte) ([]byte, error) {
+	objs, err := decodeDataGroup(data, "DG1", dg1Tag)
+	if err != nil {
+		return nil, err
+	}
+	mrz, ok := findTLV(objs, mrzInfoTag)
+	if !ok {
+		return nil, fmt.Errorf("%w: MRZ data element 0x%X not found", ErrMalformedTLV, mrzInfoTag)
+	}
+	return mrz.value, nil
+}
+
+// mrzFormat detects the format of the MRZ from its length and document code.
+func mrzFormat(mrz string) (DocumentFormat, bool) {
+	switch len(mrz) {
+	case td1Length:
+		return FormatTD1, true
+	case td2Length:
+		if isVisaCode(mrz[0]) {
+			return FormatMRVB, true
+		}
+		return FormatTD2, true
+	case td3Length:
+		if isVisaCode(mrz[0]) {
+			return FormatMRVA, true
+		}
+		return FormatTD3, true
+	default:
+		return "", false
 	}
-	dg1RawWithoutTag := dg1Raw[dg1TagSize:]
+}
 
-	dg1 := string(dg1RawWithoutTag)
-	if len(dg1) != 88 {
+func parseMRZ(mrz string) (passport *Passport, err error) {
+	format, _ := mrzFormat(mrz)
+	switch format {
+	case FormatTD1:
+		passport, err = parseTD1(mrz)
+	case FormatTD2:
+		passport, err = parseTD2(mrz)
+	case FormatTD3:
+		passport, err = parseTD3(mrz)
+	case FormatMRVA, FormatMRVB:
+		passport, err = parseMRV(mrz, format)
+	default:
 		return nil, fmt.Errorf(
-			"invalid TD3 format: data should be 88 characters long: %d",
-			len(dg1),
+			"invalid MRZ format: data should be %d (TD1), %d (TD2) or %d (TD3) characters long: %d",
+			td1Length, td2Length, td3Length, len(mrz),
 		)
 	}
+	if err != nil {
+		return nil, err
+	}
+	passport.mrz = mrz
+	name := nameFields[passport.Format]
+	passport.PrimaryIdentifier, passport.SecondaryIdentifier = parseNameIdentifiers(
+		mrz[name.start:name.end],
+	)
+	return passport, nil
+}
+
+// TD1 page 30
+// https://www.icao.int/publications/Documents/9303_p5_cons_en.pdf
+func parseTD1(mrz string) (*Passport, error) {
+	line1 := mrz[:30]
+	line2 := mrz[30:60]
+	line3 := mrz[60:90]
+
+	if !isIDCardCode(line1[0]) {
+		return nil, errors.New(
+			"invalid TD1 format: first character should be 'I', 'A' or 'C' for ID card",
+		)
+	}
+
+	documentNumber, checkDigitNumber, optionalData := splitDocumentNumber(
+		line1[5:14], line1[14:15], line1[15:30],
+	)
+
+	return &Passport{
+		Format:           FormatTD1,
+		DocumentType:     trimPlaceholder(line1[:2]),    // 2 bytes
+		IssuingCountry:   trimPlaceholder(line1[2:5]),   // 3 bytes
+		DocumentNumber:   documentNumber,                // 9 bytes
+		CheckDigitNumber: checkDigitNumber,              // 1 byte
+		PersonalNumber:   optionalData,                  // 15 bytes
+		DateOfBirth:      line2[:6],                     // 6 bytes
+		CheckDigitDOB:    trimPlaceholder(line2[6:7]),   // 1 byte
+		Sex:              parseSex(line2[7:8]),          // 1 byte
+		DateOfExpiry:     line2[8:14],                   // 6 bytes
+		CheckDigitExpiry: trimPlaceholder(line2[14:15]), // 1 byte
+		Nationality:      trimPlaceholder(line2[15:18]), // 3 bytes
+		OptionalData:     trimPlaceholder(line2[18:29]), // 11 bytes
+		CheckDigitFinal:  trimPlaceholder(line2[29:30]), // 1 byte
+		HolderName:       parseHolderName(line3),        // 30 bytes
+	}, nil
+}
+
+// TD2 page 21
+// https://www.icao.int/publications/Documents/9303_p6_cons_en.pdf
+func parseTD2(mrz string) (*Passport, error) {
+	line1 := mrz[:36]
+	line2 := mrz[36:72]
+
+	if !isIDCardCode(line1[0]) {
+		return nil, errors.New(
+			"invalid TD2 format: first character should be 'I', 'A' or 'C' for ID card",
+		)
+	}
+
+	documentNumber, checkDigitNumber, optionalData := splitDocumentNumber(
+		line2[:9], line2[9:10], line2[28:35],
+	)
+
+	return &Passport{
+		Format:           FormatTD2,
+		DocumentType:     trimPlaceholder(line1[:2]),    // 2 bytes
+		IssuingCountry:   trimPlaceholder(line1[2:5]),   // 3 bytes
+		HolderName:       parseHolderName(line1[5:36]),  // 31 bytes
+		DocumentNumber:   documentNumber,                // 9 bytes
+		CheckDigitNumber: checkDigitNumber,              // 1 byte
+		Nationality:      trimPlaceholder(line2[10:13]), // 3 bytes
+		DateOfBirth:      line2[13:19],                  // 6 bytes
+		CheckDigitDOB:    trimPlaceholder(line2[19:20]), // 1 byte
+		Sex:              parseSex(line2[20:21]),        // 1 byte
+		DateOfExpiry:     line2[21:27],                  // 6 bytes
+		CheckDigitExpiry: trimPlaceholder(line2[27:28]), // 1 byte
+		PersonalNumber:   optionalData,                  // 7 bytes
+		CheckDigitFinal:  trimPlaceholder(line2[35:36]), // 1 byte
+	}, nil
+}
 
-	line1 := dg1[:44]
-	line2 := dg1[44:88]
+// TD3 page 53
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf
+func parseTD3(mrz string) (*Passport, error) {
+	line1 := mrz[:44]
+	line2 := mrz[44:88]
 
 	// Basic validation
 	if !strings.HasPrefix(line1, "P") {
 		return nil, errors.New("invalid TD3 format: first character should be 'P' for passport")
 	}
 
-	// Determine the sex value
-	var sexValue Sex
-	switch line2[20:21] {
-	case "M":
-		sexValue = Male
-	case "F":
-		sexValue = Female
-	case "X":
-		sexValue = Other
-	default:
-		sexValue = Other
-	}
-
-	// TD3 page 53
-	// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf
-	passport := &Passport{
+	return &Passport{
+		Format:             FormatTD3,
 		DocumentType:       trimPlaceholder(line1[:2]),      // 2 bytes
 		IssuingCountry:     trimPlaceholder(line1[2:5]),     // 3 bytes
 		HolderName:         parseHolderName(line1[5:44]),    // 39 bytes
 		DocumentNumber:     trimPlaceholder(line2[:9]),      // 9 bytes
 		CheckDigitNumber:   trimPlaceholder(line2[9:10]),    // 1 byte
 		Nationality:        trimPlaceholder(line2[10:13]),   // 3 bytes
-		DateOfBirth:        trimPlaceholder(line2[13:19]),   // 6 bytes
+		DateOfBirth:        line2[13:19],                    // 6 bytes
 		CheckDigitDOB:      trimPlaceholder(line2[19:20]),   // 1 byte
-		Sex:                sexValue,                        // 1 byte
-		DateOfExpiry:       trimPlaceholder(line2[21:27]),   // 6 bytes
+		Sex:                parseSex(line2[20:21]),          // 1 byte
+		DateOfExpiry:       line2[21:27],                    // 6 bytes
 		CheckDigitExpiry:   trimPlaceholder(line2[27:28]),   // 1 byte
 		PersonalNumber:     strings.TrimSpace(line2[28:42]), // 14 bytes
 		CheckDigitPersonal: trimPlaceholder(line2[42:43]),   // 1 byte
 		CheckDigitFinal:    trimPlaceholder(line2[43:44]),   // 1 byte
-		Raw:                dg1Raw,
+	}, nil
+}
+
+// MRV-A and MRV-B page 9 and 13
+// https://www.icao.int/publications/Documents/9303_p7_cons_en.pdf
+// Visas have the same layout as TD3 and TD2 documents up to the date of expiry,
+// followed by optional data without a check digit. There is no composite
+// check digit.
+func parseMRV(mrz string, format DocumentFormat) (*Passport, error) {
+	lineLength := len(mrz) / 2
+	line1 := mrz[:lineLength]
+	line2 := mrz[lineLength:]
+
+	return &Passport{
+		Format:           format,
+		DocumentType:     trimPlaceholder(line1[:2]),    // 2 bytes
+		IssuingCountry:   trimPlaceholder(line1[2:5]),   // 3 bytes
+		HolderName:       parseHolderName(line1[5:]),    // 39 or 31 bytes
+		DocumentNumber:   trimPlaceholder(line2[:9]),    // 9 bytes
+		CheckDigitNumber: trimPlaceholder(line2[9:10]),  // 1 byte
+		Nationality:      trimPlaceholder(line2[10:13]), // 3 bytes
+		DateOfBirth:      line2[13:19],                  // 6 bytes
+		CheckDigitDOB:    trimPlaceholder(line2[19:20]), // 1 byte
+		Sex:              parseSex(line2[20:21]),        // 1 byte
+		DateOfExpiry:     line2[21:27],                  // 6 bytes
+		CheckDigitExpiry: trimPlaceholder(line2[27:28]), // 1 byte
+		OptionalData:     trimPlaceholder(line2[28:]),   // 16 or 8 bytes
+	}, nil
+}
+
+// IsVisa reports whether the document is a machine readable visa. The date of
+// expiry of a visa is the end of its validity period. Visas are parsed only,
+// credentials can not be issued for them, see ErrUnsupportedFormat.
+func (p *Passport) IsVisa() bool {
+	return p.Format == FormatMRVA || p.Format == FormatMRVB
+}
+
+// isVisaCode reports whether c is the first character of a visa document code.
+func isVisaCode(c byte) bool {
+	return c == 'V'
+}
+
+// isIDCardCode reports whether c is a valid first character of
+// a TD1/TD2 document code.
+func isIDCardCode(c byte) bool {
+	return c == 'I' || c == 'A' || c == 'C'
+}
+
+// splitDocumentNumber handles document numbers longer than 9 characters.
+// In TD1 and TD2 documents the check digit position is filled with '<' and
+// the rest of the number followed by its check digit is stored at the start
+// of the optional data field.
+func splitDocumentNumber(number, checkDigit, optionalData string) (
+	documentNumber, documentCheckDigit, rest string,
+) {
+	if checkDigit != "<" {
+		return trimPlaceholder(number), checkDigit, trimPlaceholder(optionalData)
 	}
+	end := strings.IndexByte(optionalData, '<')
+	if end < 1 {
+		return trimPlaceholder(number), "", trimPlaceholder(optionalData)
+	}
+	documentNumber = number + optionalData[:end-1]
+	documentCheckDigit = optionalData[end-1 : end]
+	rest = trimPlaceholder(strings.TrimLeft(optionalData[end:], "<"))
+	return documentNumber, documentCheckDigit, rest
+}
 
-	return passport, nil
+func parseSex(value string) Sex {
+	switch value {
+	case "M":
+		return Male
+	case "F":
+		return Female
+	default:
+		return Other
+	}
 }
 
+// parseHolderName joins the components of an MRZ name field with single
+// spaces, e.g. "KUZNETSOV<<VALERIY" becomes "KUZNETSOV VALERIY".
 func parseHolderName(holder string) string {
-	return strings.TrimSpace(
-		strings.ReplaceAll(holder, "<", " "),
-	)
+	return strings.Join(splitFields(holder), " ")
+}
+
+// parseNameIdentifiers splits the MRZ name field into the primary identifier
+// (surname) and the secondary identifier (given names) separated by "<<".
+// Name components are separated by a single space.
+func parseNameIdentifiers(name string) (primary, secondary string) {
+	name = trimPlaceholder(name)
+	primary, secondary, _ = strings.Cut(name, "<<")
+	return joinNameComponents(primary), joinNameComponents(secondary)
+}
+
+func joinNameComponents(name string) string {
+	return strings.Join(splitFields(name), " ")
 }
 
 func trimPlaceholder(value string) string {
@@ -9,10 +9,7 @@ import (
 
 // DG1 is the same as the MRZ data, but with a group tag at the beginning.
 func mrzToDg1(mrz string) string {
-	tag := []byte{97, 91, 95, 31, 88}
-	mrzBytes := []byte(mrz)
-	b := append(tag, mrzBytes...)
-	return hex.EncodeToString(b)
+	return hex.EncodeToString(encodeDG1(mrz))
 }
 
 func TestParseDG1(t *testing.T) {
@@ -26,42 +23,144 @@ func TestParseDG1(t *testing.T) {
 			name:  "Valid TD3 passport with hex data and group tag",
 			input: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02",
 			expected: &Passport{
-				DocumentType:   "P",
-				IssuingCountry: "UKR",
-				DocumentNumber: "AC1234567",
-				HolderName:     "KUZNETSOV  VALERIY",
-				Nationality:    "UKR",
-				DateOfBirth:    "960309",
-				Sex:            Male,
-				DateOfExpiry:   "350803",
+				Format:              FormatTD3,
+				DocumentType:        "P",
+				IssuingCountry:      "UKR",
+				DocumentNumber:      "AC1234567",
+				HolderName:          "KUZNETSOV VALERIY",
+				PrimaryIdentifier:   "KUZNETSOV",
+				SecondaryIdentifier: "VALERIY",
+				Nationality:         "UKR",
+				DateOfBirth:         "960309",
+				Sex:                 Male,
+				DateOfExpiry:        "350803",
 			},
 		},
 		{
 			name:  "Valid TD3 passport with hex data and group tag. Double fullname",
 			input: "P<UKRKUZNETSOV<MELENDEZ<<VALERIY<ALEX<<<<<<<AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02",
 			expected: &Passport{
-				DocumentType:   "P",
-				IssuingCountry: "UKR",
-				DocumentNumber: "AC1234567",
-				HolderName:     "KUZNETSOV MELENDEZ  VALERIY ALEX",
-				Nationality:    "UKR",
-				DateOfBirth:    "960309",
-				Sex:            Male,
-				DateOfExpiry:   "350803",
+				Format:              FormatTD3,
+				DocumentType:        "P",
+				IssuingCountry:      "UKR",
+				DocumentNumber:      "AC1234567",
+				HolderName:          "KUZNETSOV MELENDEZ VALERIY ALEX",
+				PrimaryIdentifier:   "KUZNETSOV MELENDEZ",
+				SecondaryIdentifier: "VALERIY ALEX",
+				Nationality:         "UKR",
+				DateOfBirth:         "960309",
+				Sex:                 Male,
+				DateOfExpiry:        "350803",
 			},
 		},
 		{
 			name:  "Valid TD3 passport with hex data and group tag",
 			input: "PMUKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02",
 			expected: &Passport{
-				DocumentType:   "PM",
-				IssuingCountry: "UKR",
-				DocumentNumber: "AC1234567",
-				HolderName:     "KUZNETSOV  VALERIY",
-				Nationality:    "UKR",
-				DateOfBirth:    "960309",
-				Sex:            Male,
-				DateOfExpiry:   "350803",
+				Format:              FormatTD3,
+				DocumentType:        "PM",
+				IssuingCountry:      "UKR",
+				DocumentNumber:      "AC1234567",
+				HolderName:          "KUZNETSOV VALERIY",
+				PrimaryIdentifier:   "KUZNETSOV",
+				SecondaryIdentifier: "VALERIY",
+				Nationality:         "UKR",
+				DateOfBirth:         "960309",
+				Sex:                 Male,
+				DateOfExpiry:        "350803",
+			},
+		},
+		{
+			name: "Valid TD1 identity card",
+			input: "I<UTOD231458907<<<<<<<<<<<<<<<" +
+				"7408122F1204159UTO<<<<<<<<<<<6" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+			expected: &Passport{
+				Format:              FormatTD1,
+				DocumentType:        "I",
+				IssuingCountry:      "UTO",
+				DocumentNumber:      "D23145890",
+				HolderName:          "ERIKSSON ANNA MARIA",
+				PrimaryIdentifier:   "ERIKSSON",
+				SecondaryIdentifier: "ANNA MARIA",
+				Nationality:         "UTO",
+				DateOfBirth:         "740812",
+				Sex:                 Female,
+				DateOfExpiry:        "120415",
+			},
+		},
+		{
+			name: "Valid TD1 identity card with long document number",
+			input: "I<UTOD23145890<AB112234<<<<<<<" +
+				"7408122F1204159UTO<<<<<<<<<<<6" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+			expected: &Passport{
+				Format:              FormatTD1,
+				DocumentType:        "I",
+				IssuingCountry:      "UTO",
+				DocumentNumber:      "D23145890AB11223",
+				HolderName:          "ERIKSSON ANNA MARIA",
+				PrimaryIdentifier:   "ERIKSSON",
+				SecondaryIdentifier: "ANNA MARIA",
+				Nationality:         "UTO",
+				DateOfBirth:         "740812",
+				Sex:                 Female,
+				DateOfExpiry:        "120415",
+			},
+		},
+		{
+			name: "Valid TD2 identity card",
+			input: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F1204159<<<<<<<6",
+			expected: &Passport{
+				Format:              FormatTD2,
+				DocumentType:        "I",
+				IssuingCountry:      "UTO",
+				DocumentNumber:      "D23145890",
+				HolderName:          "ERIKSSON ANNA MARIA",
+				PrimaryIdentifier:   "ERIKSSON",
+				SecondaryIdentifier: "ANNA MARIA",
+				Nationality:         "UTO",
+				DateOfBirth:         "740812",
+				Sex:                 Female,
+				DateOfExpiry:        "120415",
+			},
+		},
+		{
+			name: "Valid MRV-A visa",
+			input: "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<" +
+				"L8988901C4XXX4009078F9612109ZE184226B<<<<<<<",
+			expected: &Passport{
+				Format:              FormatMRVA,
+				DocumentType:        "V",
+				IssuingCountry:      "UTO",
+				DocumentNumber:      "L8988901C",
+				HolderName:          "ERIKSSON ANNA MARIA",
+				PrimaryIdentifier:   "ERIKSSON",
+				SecondaryIdentifier: "ANNA MARIA",
+				Nationality:         "XXX",
+				DateOfBirth:         "400907",
+				Sex:                 Female,
+				DateOfExpiry:        "961210",
+				OptionalData:        "ZE184226B",
+			},
+		},
+		{
+			name: "Valid MRV-B visa",
+			input: "VCUTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F3004157<<<<<<<<",
+			expected: &Passport{
+				Format:              FormatMRVB,
+				DocumentType:        "VC",
+				IssuingCountry:      "UTO",
+				DocumentNumber:      "D23145890",
+				HolderName:          "ERIKSSON ANNA MARIA",
+				PrimaryIdentifier:   "ERIKSSON",
+				SecondaryIdentifier: "ANNA MARIA",
+				Nationality:         "UTO",
+				DateOfBirth:         "740812",
+				Sex:                 Female,
+				DateOfExpiry:        "300415",
 			},
 		},
 	}
@@ -72,6 +171,7 @@ func TestParseDG1(t *testing.T) {
 			require.NoError(t, err)
 
 			// Check each field individually for better error messages
+			require.Equal(t, tt.expected.Format, result.Format, "Format mismatch")
 			require.Equal(t, tt.expected.DocumentType, result.DocumentType, "DocumentType mismatch")
 			require.Equal(
 				t,
@@ -86,10 +186,151 @@ func TestParseDG1(t *testing.T) {
 				"DocumentNumber mismatch",
 			)
 			require.Equal(t, tt.expected.HolderName, result.HolderName, "HolderName mismatch")
+			require.Equal(t, tt.expected.PrimaryIdentifier, result.PrimaryIdentifier,
+				"PrimaryIdentifier mismatch")
+			require.Equal(t, tt.expected.SecondaryIdentifier, result.SecondaryIdentifier,
+				"SecondaryIdentifier mismatch")
 			require.Equal(t, tt.expected.Nationality, result.Nationality, "Nationality mismatch")
 			require.Equal(t, tt.expected.DateOfBirth, result.DateOfBirth, "DateOfBirth mismatch")
 			require.Equal(t, tt.expected.Sex, result.Sex, "Sex mismatch")
 			require.Equal(t, tt.expected.DateOfExpiry, result.DateOfExpiry, "DateOfExpiry mismatch")
+			require.Equal(t, tt.expected.OptionalData, result.OptionalData, "OptionalData mismatch")
+			require.Equal(t, tt.expected.IsVisa(), result.IsVisa(), "IsVisa mismatch")
+		})
+	}
+}
+
+func TestParseDG1_InvalidFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Unsupported length",
+			input: "P<UKRKUZNETSOV<<VALERIY",
+		},
+		{
+			name: "TD3 without passport document code",
+			input: "I<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<" +
+				"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02",
+		},
+		{
+			name: "TD1 with passport document code",
+			input: "P<UTOD231458907<<<<<<<<<<<<<<<" +
+				"7408122F1204159UTO<<<<<<<<<<<6" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+		},
+		{
+			name: "TD2 with passport document code",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F1204159<<<<<<<6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG1(mrzToDg1(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseNameIdentifiers(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   string
+		secondary string
+	}{
+		{name: "KUZNETSOV<<VALERIY<<<<<<", primary: "KUZNETSOV", secondary: "VALERIY"},
+		{name: "DE<LA<CRUZ<<MARIA<JOSE<<<", primary: "DE LA CRUZ", secondary: "MARIA JOSE"},
+		{name: "ERIKSSON<<<<<<<<", primary: "ERIKSSON"},
+		{name: "KUZNETSOV<<VALERIY<ALEXANDR", primary: "KUZNETSOV", secondary: "VALERIY ALEXANDR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, secondary := parseNameIdentifiers(tt.name)
+			require.Equal(t, tt.primary, primary)
+			require.Equal(t, tt.secondary, secondary)
+		})
+	}
+}
+
+func TestParseMRZ(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		mrz   string
+	}{
+		{
+			name: "TD3 passport",
+			input: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<\n" +
+				"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<00\n",
+			mrz: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<AC12345674UKR9603091M3508035<<<<<<<<<<<<<<00",
+		},
+		{
+			name: "TD1 identity card with CRLF and spaces",
+			input: "  I<UTOD231458907<<<<<<<<<<<<<<<\r\n" +
+				"7408122F1204159UTO<<<<<<<<<<<6 \r\n" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+			mrz: "I<UTOD231458907<<<<<<<<<<<<<<<7408122F1204159UTO<<<<<<<<<<<6ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+		},
+		{
+			name: "TD2 identity card on a single line",
+			input: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<" +
+				"D231458907UTO7408122F1204159<<<<<<<6",
+			mrz: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<D231458907UTO7408122F1204159<<<<<<<6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseMRZ(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, SourceMRZ, result.Source)
+
+			expected, err := ParseDG1(mrzToDg1(tt.mrz))
+			require.NoError(t, err)
+			require.Equal(t, SourceChip, expected.Source)
+			expected.Source = SourceMRZ
+			require.Equal(t, expected, result)
+		})
+	}
+}
+
+func TestParseMRZ_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "Line lengths differ",
+			input: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<\n" +
+				"AC12345674UKR9603091M3508035<<<<<<<<<<<<<00",
+		},
+		{
+			name: "Wrong number of lines",
+			input: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+				"D231458907UTO7408122F1204159<<<<<<<6\n" +
+				"D231458907UTO7408122F1204159<<<<<<<6",
+		},
+		{
+			name: "Lowercase characters",
+			input: "P<UKRKuznetsov<<VALERIY<<<<<<<<<<<<<<<<<<<<<\n" +
+				"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<00",
+		},
+		{
+			name: "Check digit mismatch",
+			input: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<\n" +
+				"AC12345675UKR9603091M3508035<<<<<<<<<<<<<<00",
+		},
+		{name: "Unsupported length", input: "P<UKRKUZNETSOV<<VALERIY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMRZ(tt.input)
+			require.Error(t, err)
 		})
 	}
 }
@@ -0,0 +1,246 @@
+package passport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// DG2 data object tags.
+const (
+	dg2Tag                      = 0x75   // DG2 application template
+	biometricGroupTemplateTag   = 0x7F61 // Biometric information group template
+	biometricInfoTemplateTag    = 0x7F60 // Biometric information template
+	biometricHeaderTemplateTag  = 0xA1   // Biometric header template
+	biometricDataBlockTag       = 0x5F2E // Biometric data block
+	biometricDataBlockEnciphTag = 0x7F2E // Enciphered biometric data block
+	biometricCountTag           = 0x02   // Number of instances
+
+	headerVersionTag    = 0x80 // ICAO header version
+	biometricTypeTag    = 0x81 // Biometric type
+	biometricSubtypeTag = 0x82 // Biometric subtype
+	creationDateTimeTag = 0x83 // Creation date and time
+	validityPeriodTag   = 0x85 // Validity period
+	creatorTag          = 0x86 // Creator of the biometric reference data
+	formatOwnerTag      = 0x87 // Format owner
+	formatTypeTag       = 0x88 // Format type
+)
+
+// ISO/IEC 19794-5 facial record sizes.
+const (
+	facialRecordHeaderSize = 14
+	facialInfoSize         = 20
+	featurePointSize       = 8
+	imageInfoSize          = 12
+)
+
+var facialRecordIdentifier = []byte("FAC\x00")
+
+// ImageFormat is the encoding of a face image.
+type ImageFormat string
+
+const (
+	ImageFormatJPEG     ImageFormat = "JPEG"
+	ImageFormatJPEG2000 ImageFormat = "JPEG2000"
+)
+
+// BiometricHeader is the biometric header template of a DG2 face record.
+type BiometricHeader struct {
+	HeaderVersion    []byte // ICAO header version
+	BiometricType    []byte // Biometric type, 0x02 for facial features
+	BiometricSubtype []byte // Biometric subtype
+	CreationDateTime []byte // Creation date and time
+	ValidityPeriod   []byte // Validity period
+	Creator          []byte // Creator of the biometric reference data
+	FormatOwner      []byte // Format owner, 0x0101 for ISO/IEC JTC 1/SC 37
+	FormatType       []byte // Format type, 0x0008 for ISO/IEC 19794-5
+}
+
+// FaceImage is a facial record of DG2 encoded with ISO/IEC 19794-5.
+type FaceImage struct {
+	Header          BiometricHeader // Biometric header template
+	Gender          byte            // Gender of the holder
+	EyeColor        byte            // Eye color
+	HairColor       byte            // Hair color
+	FeatureMask     uint32          // Property mask (24 bits)
+	Expression      uint16          // Facial expression
+	PoseAngle       [3]byte         // Yaw, pitch and roll
+	FeaturePoints   int             // Number of feature points
+	FaceImageType   byte            // Face image type, e.g. full frontal
+	Format          ImageFormat     // Encoding of the image
+	Width           int             // Image width in pixels
+	Height          int             // Image height in pixels
+	ColorSpace      byte            // Image color space
+	SourceType      byte            // Image source type
+	DeviceType      uint16          // Image capture device type
+	Quality         uint16          // Image quality
+	Image           []byte          // JPEG or JPEG2000 image
+	BiometricRecord []byte          // Raw ISO/IEC 19794-5 record
+}
+
+// DG2 represents the encoded face of the holder.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 35
+type DG2 struct {
+	Faces []FaceImage // Face records, usually one
+	Raw   []byte      // Raw data including group tag
+}
+
+// ParseDG2 parses the provided hex encoded DG2 data.
+func ParseDG2(data string) (*DG2, error) {
+	dg2Raw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG2 format: data should be a hexadecimal string: %w", err)
+	}
+	objs, err := decodeDataGroup(dg2Raw, "DG2", dg2Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG2 format: %w", err)
+	}
+	group, ok := findTLV(objs, biometricGroupTemplateTag)
+	if !ok {
+		return nil, fmt.Errorf("invalid DG2 format: %w: biometric group template 0x%X not found",
+			ErrMalformedTLV, biometricGroupTemplateTag)
+	}
+	templates, err := group.children()
+	if err != nil {
+		return nil, fmt.Errorf("invalid DG2 format: %w", err)
+	}
+
+	dg2 := &DG2{Raw: dg2Raw}
+	for _, template := range templates {
+		if template.tag != biometricInfoTemplateTag {
+			continue
+		}
+		face, err := parseBiometricInfoTemplate(template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DG2 face %d: %w", len(dg2.Faces)+1, err)
+		}
+		dg2.Faces = append(dg2.Faces, *face)
+	}
+	if len(dg2.Faces) == 0 {
+		return nil, errors.New("invalid DG2 format: no face records found")
+	}
+	if count, ok := findTLV(templates, biometricCountTag); ok &&
+		len(count.value) == 1 && int(count.value[0]) != len(dg2.Faces) {
+		return nil, fmt.Errorf("invalid DG2 format: expected %d face records, found %d",
+			count.value[0], len(dg2.Faces))
+	}
+
+	return dg2, nil
+}
+
+func parseBiometricInfoTemplate(template tlv) (*FaceImage, error) {
+	objs, err := template.children()
+	if err != nil {
+		return nil, err
+	}
+
+	var header BiometricHeader
+	if bht, ok := findTLV(objs, biometricHeaderTemplateTag); ok {
+		if header, err = parseBiometricHeader(bht); err != nil {
+			return nil, err
+		}
+	}
+
+	block, ok := findTLV(objs, biometricDataBlockTag)
+	if !ok {
+		if _, enciphered := findTLV(objs, biometricDataBlockEnciphTag); enciphered {
+			return nil, errors.New("enciphered biometric data block is not supported")
+		}
+		return nil, fmt.Errorf("%w: biometric data block 0x%X not found",
+			ErrMalformedTLV, biometricDataBlockTag)
+	}
+
+	face, err := parseFacialRecord(block.value)
+	if err != nil {
+		return nil, err
+	}
+	face.Header = header
+	return face, nil
+}
+
+func parseBiometricHeader(bht tlv) (BiometricHeader, error) {
+	objs, err := bht.children()
+	if err != nil {
+		return BiometricHeader{}, err
+	}
+	var header BiometricHeader
+	fields := map[uint32]*[]byte{
+		headerVersionTag:    &header.HeaderVersion,
+		biometricTypeTag:    &header.BiometricType,
+		biometricSubtypeTag: &header.BiometricSubtype,
+		creationDateTimeTag: &header.CreationDateTime,
+		validityPeriodTag:   &header.ValidityPeriod,
+		creatorTag:          &header.Creator,
+		formatOwnerTag:      &header.FormatOwner,
+		formatTypeTag:       &header.FormatType,
+	}
+	for _, obj := range objs {
+		if field, ok := fields[obj.tag]; ok {
+			*field = obj.value
+		}
+	}
+	return header, nil
+}
+
+// parseFacialRecord parses the first facial image of an ISO/IEC 19794-5
+// record: general header, facial information, feature points,
+// image information and image data.
+func parseFacialRecord(record []byte) (*FaceImage, error) {
+	if len(record) < facialRecordHeaderSize+facialInfoSize {
+		return nil, fmt.Errorf("facial record is too short: %d bytes", len(record))
+	}
+	if !bytes.Equal(record[:4], facialRecordIdentifier) {
+		return nil, fmt.Errorf("unexpected facial record identifier %x", record[:4])
+	}
+	if recordLength := binary.BigEndian.Uint32(record[8:12]); int(recordLength) != len(record) {
+		return nil, fmt.Errorf(
+			"facial record length %d does not match data length %d", recordLength, len(record))
+	}
+	if binary.BigEndian.Uint16(record[12:14]) == 0 {
+		return nil, errors.New("facial record has no images")
+	}
+
+	info := record[facialRecordHeaderSize:]
+	blockLength := int(binary.BigEndian.Uint32(info[:4]))
+	featurePoints := int(binary.BigEndian.Uint16(info[4:6]))
+	imageInfoOffset := facialInfoSize + featurePoints*featurePointSize
+	if blockLength > len(info) || blockLength < imageInfoOffset+imageInfoSize {
+		return nil, fmt.Errorf("invalid facial record data length %d", blockLength)
+	}
+
+	face := &FaceImage{
+		Gender:          info[6],
+		EyeColor:        info[7],
+		HairColor:       info[8],
+		FeatureMask:     uint32(info[9])<<16 | uint32(info[10])<<8 | uint32(info[11]),
+		Expression:      binary.BigEndian.Uint16(info[12:14]),
+		PoseAngle:       [3]byte{info[14], info[15], info[16]},
+		FeaturePoints:   featurePoints,
+		BiometricRecord: record,
+	}
+
+	imageInfo := info[imageInfoOffset : imageInfoOffset+imageInfoSize]
+	face.FaceImageType = imageInfo[0]
+	switch imageInfo[1] {
+	case 0:
+		face.Format = ImageFormatJPEG
+	case 1:
+		face.Format = ImageFormatJPEG2000
+	default:
+		return nil, fmt.Errorf("unsupported image data type %d", imageInfo[1])
+	}
+	face.Width = int(binary.BigEndian.Uint16(imageInfo[2:4]))
+	face.Height = int(binary.BigEndian.Uint16(imageInfo[4:6]))
+	face.ColorSpace = imageInfo[6]
+	face.SourceType = imageInfo[7]
+	face.DeviceType = binary.BigEndian.Uint16(imageInfo[8:10])
+	face.Quality = binary.BigEndian.Uint16(imageInfo[10:12])
+	face.Image = info[imageInfoOffset+imageInfoSize : blockLength]
+	if len(face.Image) == 0 {
+		return nil, errors.New("facial record has no image data")
+	}
+
+	return face, nil
+}
@@ -0,0 +1,193 @@
+package passport
+
+import (
+	"crypto"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testJPEG     = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 0x4A, 0x46, 0x49, 0x46, 0xFF, 0xD9}
+	testJPEG2000 = []byte{0x00, 0x00, 0x00, 0x0C, 0x6A, 0x50, 0x20, 0x20, 0x0D, 0x0A, 0x87, 0x0A}
+)
+
+// testFacialRecord builds an ISO/IEC 19794-5 record with a single image.
+func testFacialRecord(image []byte, dataType byte, width, height uint16, featurePoints int) []byte {
+	blockLength := facialInfoSize + featurePoints*featurePointSize + imageInfoSize + len(image)
+	record := []byte("FAC\x00010\x00")
+	record = binary.BigEndian.AppendUint32(record, uint32(facialRecordHeaderSize+blockLength))
+	record = binary.BigEndian.AppendUint16(record, 1)
+
+	record = binary.BigEndian.AppendUint32(record, uint32(blockLength))
+	record = binary.BigEndian.AppendUint16(record, uint16(featurePoints))
+	record = append(record,
+		0x01,             // gender
+		0x03,             // eye color
+		0x02,             // hair color
+		0x00, 0x00, 0x01, // feature mask
+		0x00, 0x01, // expression
+		0x00, 0x00, 0x00, // pose angle
+		0x00, 0x00, 0x00, // pose angle uncertainty
+	)
+	for i := 0; i < featurePoints; i++ {
+		record = append(record, 0x01, byte(i), 0x00, 0x10, 0x00, 0x20, 0x00, 0x00)
+	}
+	record = append(record, 0x01, dataType)
+	record = binary.BigEndian.AppendUint16(record, width)
+	record = binary.BigEndian.AppendUint16(record, height)
+	record = append(record, 0x01, 0x02, 0x00, 0x00, 0x00, 0x00)
+	return append(record, image...)
+}
+
+func testBiometricInfoTemplate(record []byte) []byte {
+	bht := encodeTLV(biometricHeaderTemplateTag, concatBytes(
+		encodeTLV(headerVersionTag, []byte{0x01, 0x01}),
+		encodeTLV(biometricTypeTag, []byte{0x02}),
+		encodeTLV(biometricSubtypeTag, []byte{0x00}),
+		encodeTLV(formatOwnerTag, []byte{0x01, 0x01}),
+		encodeTLV(formatTypeTag, []byte{0x00, 0x08}),
+	))
+	return encodeTLV(biometricInfoTemplateTag, concatBytes(bht, encodeTLV(biometricDataBlockTag, record)))
+}
+
+func testDG2(templates ...[]byte) string {
+	group := encodeTLV(biometricCountTag, []byte{byte(len(templates))})
+	group = append(group, concatBytes(templates...)...)
+	return testDataGroup(dg2Tag, encodeTLV(biometricGroupTemplateTag, group))
+}
+
+func TestParseDG2(t *testing.T) {
+	largeImage := append(append([]byte{}, testJPEG[:10]...), make([]byte, 70000)...)
+
+	tests := []struct {
+		name          string
+		input         string
+		faces         int
+		format        ImageFormat
+		image         []byte
+		width         int
+		height        int
+		featurePoints int
+	}{
+		{
+			name:  "JPEG",
+			input: testDG2(testBiometricInfoTemplate(testFacialRecord(testJPEG, 0, 480, 640, 0))),
+			faces: 1, format: ImageFormatJPEG, image: testJPEG, width: 480, height: 640,
+		},
+		{
+			name: "JPEG2000 with feature points",
+			input: testDG2(testBiometricInfoTemplate(
+				testFacialRecord(testJPEG2000, 1, 240, 320, 2))),
+			faces: 1, format: ImageFormatJPEG2000, image: testJPEG2000, width: 240, height: 320,
+			featurePoints: 2,
+		},
+		{
+			name: "Two faces",
+			input: testDG2(
+				testBiometricInfoTemplate(testFacialRecord(testJPEG, 0, 480, 640, 0)),
+				testBiometricInfoTemplate(testFacialRecord(testJPEG2000, 1, 240, 320, 0)),
+			),
+			faces: 2, format: ImageFormatJPEG, image: testJPEG, width: 480, height: 640,
+		},
+		{
+			name:  "Large image",
+			input: testDG2(testBiometricInfoTemplate(testFacialRecord(largeImage, 0, 480, 640, 0))),
+			faces: 1, format: ImageFormatJPEG, image: largeImage, width: 480, height: 640,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dg2, err := ParseDG2(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.input, hex.EncodeToString(dg2.Raw))
+			require.Len(t, dg2.Faces, tt.faces)
+
+			face := dg2.Faces[0]
+			require.Equal(t, tt.format, face.Format)
+			require.Equal(t, tt.image, face.Image)
+			require.Equal(t, tt.width, face.Width)
+			require.Equal(t, tt.height, face.Height)
+			require.Equal(t, tt.featurePoints, face.FeaturePoints)
+			require.Equal(t, byte(0x01), face.Gender)
+			require.Equal(t, uint32(1), face.FeatureMask)
+			require.Equal(t, byte(0x01), face.FaceImageType)
+			require.Equal(t, []byte{0x02}, face.Header.BiometricType)
+			require.Equal(t, []byte{0x00, 0x08}, face.Header.FormatType)
+		})
+	}
+}
+
+func TestParseDG2_Invalid(t *testing.T) {
+	record := testFacialRecord(testJPEG, 0, 480, 640, 0)
+	badIdentifier := append([]byte("FIR\x00"), record[4:]...)
+	badLength := append(append([]byte{}, record...), 0x00)
+	badDataType := testFacialRecord(testJPEG, 5, 480, 640, 0)
+	noImage := testFacialRecord(nil, 0, 480, 640, 0)
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not hex", input: "zz"},
+		{name: "Wrong tag", input: testDataGroup(dg11Tag)},
+		{name: "No group template", input: testDataGroup(dg2Tag)},
+		{
+			name:  "No face records",
+			input: testDataGroup(dg2Tag, encodeTLV(biometricGroupTemplateTag, nil)),
+		},
+		{name: "Bad identifier", input: testDG2(testBiometricInfoTemplate(badIdentifier))},
+		{name: "Bad record length", input: testDG2(testBiometricInfoTemplate(badLength))},
+		{name: "Bad data type", input: testDG2(testBiometricInfoTemplate(badDataType))},
+		{name: "No image data", input: testDG2(testBiometricInfoTemplate(noImage))},
+		{name: "Truncated record", input: testDG2(testBiometricInfoTemplate(record[:20]))},
+		{
+			name: "Enciphered",
+			input: testDG2(encodeTLV(biometricInfoTemplateTag,
+				encodeTLV(biometricDataBlockEnciphTag, encodeTLV(0x80, []byte{0x01})))),
+		},
+		{
+			name: "Count mismatch",
+			input: testDataGroup(dg2Tag, encodeTLV(biometricGroupTemplateTag, concatBytes(
+				encodeTLV(biometricCountTag, []byte{0x02}),
+				testBiometricInfoTemplate(record),
+			))),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG2(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestPassportV1Inputs_Portrait(t *testing.T) {
+	ds := newTestDocumentSigner(t)
+	dg2Hex := testDG2(testBiometricInfoTemplate(testFacialRecord(testJPEG, 0, 480, 640, 0)))
+	dg2, err := hex.DecodeString(dg2Hex)
+	require.NoError(t, err)
+
+	inputs := PassportV1Inputs{
+		PassportData: mrzToDg1(testMRZ),
+		DG2:          dg2Hex,
+		IssuanceDate: time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		SOD:          hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256, map[int][]byte{2: dg2})),
+	}
+	face, err := inputs.Portrait()
+	require.NoError(t, err)
+	require.Equal(t, testJPEG, face.Image)
+
+	inputs.SOD = hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256, map[int][]byte{2: testJPEG}))
+	_, err = inputs.Portrait()
+	require.ErrorIs(t, err, ErrDataGroupHashMismatch)
+
+	inputs.DG2 = ""
+	_, err = inputs.Portrait()
+	require.Error(t, err)
+}
@@ -0,0 +1,357 @@
+package passport
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ecCurve is a short Weierstrass curve y^2 = x^3 + ax + b over GF(p).
+// crypto/elliptic assumes a = -3, which does not hold for the brainpool
+// curves, and does not expose the point addition needed by the PACE generic
+// mapping.
+//
+// Scalar multiplication, which takes the secret keys of PACE and Chip
+// Authentication, uses the constant time implementation of crypto/elliptic
+// for the NIST curves. For the other curves it is a Montgomery ladder with a
+// fixed number of steps, but math/big is not constant time, so the timing of
+// the field arithmetic still depends on the values.
+type ecCurve struct {
+	name       string
+	p, a, b, n *big.Int
+	g          ecPoint
+	nist       elliptic.Curve // crypto/elliptic curve of NIST curves, nil otherwise
+}
+
+// ecPoint is an affine point. The point at infinity has nil coordinates.
+type ecPoint struct {
+	x, y *big.Int
+}
+
+func (pt ecPoint) infinity() bool {
+	return pt.x == nil
+}
+
+// Standardized domain parameters, BSI TR-03110 part 3 table 4.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 43
+var standardCurves = map[int]*ecCurve{
+	9: newBrainpoolCurve("brainpoolP192r1",
+		"C302F41D932A36CDA7A3463093D18DB78FCE476DE1A86297",
+		"6A91174076B1E0E19C39C031FE8685C1CAE040E5C69A28EF",
+		"469A28EF7C28CCA3DC721D044F4496BCCA7EF4146FBF25C9",
+		"C0A0647EAAB6A48753B033C56CB0F0900A2F5C4853375FD6",
+		"14B690866ABD5BB88B5F4828C1490002E6773FA2FA299B8F",
+		"C302F41D932A36CDA7A3462F9E9E916B5BE8F1029AC4ACC1",
+	),
+	10: newNISTCurve(elliptic.P224()),
+	11: newBrainpoolCurve("brainpoolP224r1",
+		"D7C134AA264366862A18302575D1D787B09F075797DA89F57EC8C0FF",
+		"68A5E62CA9CE6C1C299803A6C1530B514E182AD8B0042A59CAD29F43",
+		"2580F63CCFE44138870713B1A92369E33E2135D266DBB372386C400B",
+		"0D9029AD2C7E5CF4340823B2A87DC68C9E4CE3174C1E6EFDEE12C07D",
+		"58AA56F772C0726F24C6B89E4ECDAC24354B9E99CAA3F6D3761402CD",
+		"D7C134AA264366862A18302575D0FB98D116BC4B6DDEBCA3A5A7939F",
+	),
+	12: newNISTCurve(elliptic.P256()),
+	13: newBrainpoolCurve("brainpoolP256r1",
+		"A9FB57DBA1EEA9BC3E660A909D838D726E3BF623D52620282013481D1F6E5377",
+		"7D5A0975FC2C3057EEF67530417AFFE7FB8055C126DC5C6CE94A4B44F330B5D9",
+		"26DC5C6CE94A4B44F330B5D9BBD77CBF958416295CF7E1CE6BCCDC18FF8C07B6",
+		"8BD2AEB9CB7E57CB2C4B482FFC81B7AFB9DE27E1E3BD23C23A4453BD9ACE3262",
+		"547EF835C3DAC4FD97F8461A14611DC9C27745132DED8E545C1D54C72F046997",
+		"A9FB57DBA1EEA9BC3E660A909D838D718C397AA3B561A6F7901E0E82974856A7",
+	),
+	14: newBrainpoolCurve("brainpoolP320r1",
+		"D35E472036BC4FB7E13C785ED201E065F98FCFA6F6F40DEF4F92B9EC7893EC28FCD412B1F1B32E27",
+		"3EE30B568FBAB0F883CCEBD46D3F3BB8A2A73513F5EB79DA66190EB085FFA9F492F375A97D860EB4",
+		"520883949DFDBC42D3AD198640688A6FE13F41349554B49ACC31DCCD884539816F5EB4AC8FB1F1A6",
+		"43BD7E9AFB53D8B85289BCC48EE5BFE6F20137D10A087EB6E7871E2A10A599C710AF8D0D39E20611",
+		"14FDD05545EC1CC8AB4093247F77275E0743FFED117182EAA9C77877AAAC6AC7D35245D1692E8EE1",
+		"D35E472036BC4FB7E13C785ED201E065F98FCFA5B68F12A32D482EC7EE8658E98691555B44C59311",
+	),
+	15: newNISTCurve(elliptic.P384()),
+	16: newBrainpoolCurve("brainpoolP384r1",
+		"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B412B1DA197FB71123"+
+			"ACD3A729901D1A71874700133107EC53",
+		"7BC382C63D8C150C3C72080ACE05AFA0C2BEA28E4FB22787139165EFBA91F90F"+
+			"8AA5814A503AD4EB04A8C7DD22CE2826",
+		"04A8C7DD22CE28268B39B55416F0447C2FB77DE107DCD2A62E880EA53EEB62D5"+
+			"7CB4390295DBC9943AB78696FA504C11",
+		"1D1C64F068CF45FFA2A63A81B7C13F6B8847A3E77EF14FE3DB7FCAFE0CBD10E8"+
+			"E826E03436D646AAEF87B2E247D4AF1E",
+		"8ABE1D7520F9C2A45CB1EB8E95CFD55262B70B29FEEC5864E19C054FF9912928"+
+			"0E4646217791811142820341263C5315",
+		"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B31F166E6CAC0425A7"+
+			"CF3AB6AF6B7FC3103B883202E9046565",
+	),
+	17: newBrainpoolCurve("brainpoolP512r1",
+		"AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA70330871"+
+			"7D4D9B009BC66842AECDA12AE6A380E62881FF2F2D82C68528AA6056583A48F3",
+		"7830A3318B603B89E2327145AC234CC594CBDD8D3DF91610A83441CAEA9863BC"+
+			"2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A72BF2C7B9E7C1AC4D77FC94CA",
+		"3DF91610A83441CAEA9863BC2DED5D5AA8253AA10A2EF1C98B9AC8B57F1117A7"+
+			"2BF2C7B9E7C1AC4D77FC94CADC083E67984050B75EBAE5DD2809BD638016F723",
+		"81AEE4BDD82ED9645A21322E9C4C6A9385ED9F70B5D916C1B43B62EEF4D0098E"+
+			"FF3B1F78E2D0D48D50D1687B93B97D5F7C6D5047406A5E688B352209BCB9F822",
+		"7DDE385D566332ECC0EABFA9CF7822FDF209F70024A57B1AA000C55B881F8111"+
+			"B2DCDE494A5F485E5BCA4BD88A2763AED1CA2B2FA8F0540678CD1E0F3AD80892",
+		"AADD9DB8DBE9C48B3FD4E6AE33C9FC07CB308DB3B3C9D20ED6639CCA70330870"+
+			"553E5C414CA92619418661197FAC10471DB1D381085DDADDB58796829CA90069",
+	),
+	18: newNISTCurve(elliptic.P521()),
+}
+
+func newBrainpoolCurve(name, p, a, b, x, y, n string) *ecCurve {
+	return &ecCurve{
+		name: name,
+		p:    mustHexInt(p),
+		a:    mustHexInt(a),
+		b:    mustHexInt(b),
+		n:    mustHexInt(n),
+		g:    ecPoint{mustHexInt(x), mustHexInt(y)},
+	}
+}
+
+func mustHexInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid curve parameter " + s)
+	}
+	return v
+}
+
+func newNISTCurve(curve elliptic.Curve) *ecCurve {
+	params := curve.Params()
+	return &ecCurve{
+		name: params.Name,
+		p:    params.P,
+		a:    new(big.Int).Sub(params.P, big.NewInt(3)),
+		b:    params.B,
+		n:    params.N,
+		g:    ecPoint{params.Gx, params.Gy},
+		nist: curve,
+	}
+}
+
+// maxFieldBits bounds the size of explicit domain parameters, the largest
+// standardized curves have 521 bits.
+const maxFieldBits = 521
+
+// validate checks explicit domain parameters, BSI TR-03111 section 2.3.1:
+// p is an odd prime, a and b are field elements of a non-singular curve and
+// n is a prime of at most the size of p plus one bit. The base point is
+// checked by validateBase.
+func (c *ecCurve) validate() error {
+	switch {
+	case c.p == nil || c.p.BitLen() > maxFieldBits || c.p.Cmp(big.NewInt(3)) <= 0 ||
+		!c.p.ProbablyPrime(20):
+		return errors.New("field modulus is not an odd prime")
+	case c.a.Cmp(c.p) >= 0 || c.b.Cmp(c.p) >= 0:
+		return errors.New("curve coefficients are not field elements")
+	case c.n == nil || c.n.BitLen() > c.p.BitLen()+1 || c.n.Cmp(big.NewInt(1)) <= 0 ||
+		!c.n.ProbablyPrime(20):
+		return errors.New("order is not a prime")
+	}
+	// 4a^3 + 27b^2 != 0 mod p
+	discriminant := new(big.Int).Exp(c.a, big.NewInt(3), c.p)
+	discriminant.Lsh(discriminant, 2)
+	b2 := new(big.Int).Mul(c.b, c.b)
+	discriminant.Add(discriminant, b2.Mul(b2, big.NewInt(27))).Mod(discriminant, c.p)
+	if discriminant.Sign() == 0 {
+		return errors.New("curve is singular")
+	}
+	return nil
+}
+
+// validateBase checks that the base point is a point of order n.
+func (c *ecCurve) validateBase() error {
+	if !c.onCurve(c.g) || !c.ladder(c.g, c.n).infinity() {
+		return errors.New("base point is not of the given order")
+	}
+	return nil
+}
+
+// byteSize is the size of an encoded field element.
+func (c *ecCurve) byteSize() int {
+	return (c.p.BitLen() + 7) / 8
+}
+
+func (c *ecCurve) onCurve(pt ecPoint) bool {
+	if pt.infinity() || pt.x.Sign() < 0 || pt.x.Cmp(c.p) >= 0 ||
+		pt.y.Sign() < 0 || pt.y.Cmp(c.p) >= 0 {
+		return false
+	}
+	lhs := new(big.Int).Mul(pt.y, pt.y)
+	rhs := new(big.Int).Mul(pt.x, pt.x)
+	rhs.Add(rhs, c.a).Mul(rhs, pt.x).Add(rhs, c.b)
+	return lhs.Sub(lhs, rhs).Mod(lhs, c.p).Sign() == 0
+}
+
+func (c *ecCurve) add(p1, p2 ecPoint) ecPoint {
+	switch {
+	case p1.infinity():
+		return p2
+	case p2.infinity():
+		return p1
+	case p1.x.Cmp(p2.x) == 0:
+		sum := new(big.Int).Add(p1.y, p2.y)
+		if sum.Mod(sum, c.p).Sign() == 0 {
+			return ecPoint{}
+		}
+		return c.double(p1)
+	}
+	// lambda = (y2 - y1) / (x2 - x1)
+	num := new(big.Int).Sub(p2.y, p1.y)
+	den := new(big.Int).Sub(p2.x, p1.x)
+	return c.addWithSlope(p1, p2, num, den)
+}
+
+func (c *ecCurve) double(pt ecPoint) ecPoint {
+	if pt.infinity() || pt.y.Sign() == 0 {
+		return ecPoint{}
+	}
+	// lambda = (3x^2 + a) / 2y
+	num := new(big.Int).Mul(pt.x, pt.x)
+	num.Mul(num, big.NewInt(3)).Add(num, c.a)
+	den := new(big.Int).Lsh(pt.y, 1)
+	return c.addWithSlope(pt, pt, num, den)
+}
+
+func (c *ecCurve) addWithSlope(p1, p2 ecPoint, num, den *big.Int) ecPoint {
+	den.Mod(den, c.p).ModInverse(den, c.p)
+	lambda := num.Mul(num, den).Mod(num, c.p)
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, p1.x).Sub(x, p2.x).Mod(x, c.p)
+	y := new(big.Int).Sub(p1.x, x)
+	y.Mul(y, lambda).Sub(y, p1.y).Mod(y, c.p)
+	return ecPoint{x, y}
+}
+
+func (c *ecCurve) scalarMult(pt ecPoint, k *big.Int) ecPoint {
+	if pt.infinity() {
+		return ecPoint{}
+	}
+	if c.nist != nil {
+		// The points are checked by unmarshal, so ScalarMult does not panic.
+		//nolint:staticcheck // the PACE generic mapping needs points other than ECDH keys
+		x, y := c.nist.ScalarMult(pt.x, pt.y, k.Bytes())
+		if x.Sign() == 0 && y.Sign() == 0 {
+			return ecPoint{}
+		}
+		return ecPoint{x, y}
+	}
+	return c.ladder(pt, k)
+}
+
+// ladder is the Montgomery ladder: every step performs one addition and one
+// doubling, and the number of steps only depends on the order of the curve,
+// not on the bits of the scalar.
+func (c *ecCurve) ladder(pt ecPoint, k *big.Int) ecPoint {
+	bits := c.n.BitLen()
+	if k.BitLen() > bits {
+		bits = k.BitLen()
+	}
+	r := [2]ecPoint{{}, pt}
+	for i := bits - 1; i >= 0; i-- {
+		bit := k.Bit(i)
+		r[1-bit] = c.add(r[0], r[1])
+		r[bit] = c.double(r[bit])
+	}
+	return r[0]
+}
+
+// marshal encodes the point in uncompressed form.
+func (c *ecCurve) marshal(pt ecPoint) []byte {
+	size := c.byteSize()
+	out := make([]byte, 1+2*size)
+	out[0] = 0x04
+	pt.x.FillBytes(out[1 : 1+size])
+	pt.y.FillBytes(out[1+size:])
+	return out
+}
+
+// unmarshal decodes an uncompressed point and checks that it is on the curve.
+func (c *ecCurve) unmarshal(data []byte) (ecPoint, error) {
+	size := c.byteSize()
+	if len(data) != 1+2*size || data[0] != 0x04 {
+		return ecPoint{}, errors.New("invalid uncompressed point encoding")
+	}
+	pt := ecPoint{
+		new(big.Int).SetBytes(data[1 : 1+size]),
+		new(big.Int).SetBytes(data[1+size:]),
+	}
+	if !c.onCurve(pt) {
+		return ecPoint{}, fmt.Errorf("point is not on curve %s", c.name)
+	}
+	return pt, nil
+}
+
+// generateKey returns a private key in [1, n-1] and the public key on the
+// generator.
+func (c *ecCurve) generateKey(random io.Reader, g ecPoint) (*big.Int, ecPoint, error) {
+	buf := make([]byte, (c.n.BitLen()+7)/8)
+	mask := byte(0xFF >> (8*len(buf) - c.n.BitLen()))
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, ecPoint{}, fmt.Errorf("failed to generate private key: %w", err)
+		}
+		buf[0] &= mask
+		d := new(big.Int).SetBytes(buf)
+		if d.Sign() > 0 && d.Cmp(c.n) < 0 {
+			return d, c.scalarMult(g, d), nil
+		}
+	}
+}
+
+// verify checks a plain ECDSA signature r || s of the hash with the public
+// key, BSI TR-03111 section 4.2.1.
+func (c *ecCurve) verify(publicKey ecPoint, hashed, signature []byte) bool {
+	size := (c.n.BitLen() + 7) / 8
+	if len(signature) != 2*size {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	return c.verifyRS(publicKey, hashed, r, s)
+}
+
+// verifyASN1 checks an X9.62 ECDSA signature, a DER encoded SEQUENCE of r
+// and s, as used by CMS and X.509.
+func (c *ecCurve) verifyASN1(publicKey ecPoint, hashed, signature []byte) bool {
+	var sig struct{ R, S *big.Int }
+	if unmarshalDER(signature, &sig) != nil {
+		return false
+	}
+	return c.verifyRS(publicKey, hashed, sig.R, sig.S)
+}
+
+func (c *ecCurve) verifyRS(publicKey ecPoint, hashed []byte, r, s *big.Int) bool {
+	if r.Sign() <= 0 || r.Cmp(c.n) >= 0 || s.Sign() <= 0 || s.Cmp(c.n) >= 0 {
+		return false
+	}
+	w := new(big.Int).ModInverse(s, c.n)
+	u1 := c.hashToInt(hashed)
+	u1.Mul(u1, w).Mod(u1, c.n)
+	u2 := w.Mul(r, w).Mod(w, c.n)
+	pt := c.add(c.scalarMult(c.g, u1), c.scalarMult(publicKey, u2))
+	if pt.infinity() {
+		return false
+	}
+	return new(big.Int).Mod(pt.x, c.n).Cmp(r) == 0
+}
+
+// hashToInt converts the leftmost bits of the hash to an integer with the
+// bit length of the order.
+func (c *ecCurve) hashToInt(hashed []byte) *big.Int {
+	orderBits := c.n.BitLen()
+	if size := (orderBits + 7) / 8; len(hashed) > size {
+		hashed = hashed[:size]
+	}
+	e := new(big.Int).SetBytes(hashed)
+	if excess := len(hashed)*8 - orderBits; excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+	return e
+}
@@ -0,0 +1,105 @@
+package passport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardCurves(t *testing.T) {
+	for id, curve := range standardCurves {
+		t.Run(curve.name, func(t *testing.T) {
+			require.True(t, curve.onCurve(curve.g), "parameter ID %d", id)
+			require.True(t, curve.scalarMult(curve.g, curve.n).infinity())
+
+			k := big.NewInt(0x1234567)
+			pt := curve.scalarMult(curve.g, k)
+			require.True(t, curve.onCurve(pt))
+			decoded, err := curve.unmarshal(curve.marshal(pt))
+			require.NoError(t, err)
+			require.Equal(t, pt, decoded)
+
+			sum := curve.add(pt, curve.g)
+			require.Equal(t, curve.scalarMult(curve.g, new(big.Int).Add(k, big.NewInt(1))), sum)
+
+			// crypto/elliptic and the ladder agree for the NIST curves
+			k, err = rand.Int(rand.Reader, curve.n)
+			require.NoError(t, err)
+			require.Equal(t, curve.ladder(curve.g, k), curve.scalarMult(curve.g, k))
+			require.NoError(t, curve.validate())
+			require.NoError(t, curve.validateBase())
+		})
+	}
+}
+
+func TestECCurve_Unmarshal_Invalid(t *testing.T) {
+	curve := standardCurves[13]
+	encoded := curve.marshal(curve.g)
+
+	_, err := curve.unmarshal(encoded[1:])
+	require.Error(t, err)
+
+	compressed := append([]byte{0x02}, encoded[1:]...)
+	_, err = curve.unmarshal(compressed)
+	require.Error(t, err)
+
+	offCurve := append([]byte{}, encoded...)
+	offCurve[len(offCurve)-1] ^= 0x01
+	_, err = curve.unmarshal(offCurve)
+	require.Error(t, err)
+}
+
+// signPlainECDSA signs the hash and returns the plain signature r || s.
+func signPlainECDSA(t *testing.T, curve *ecCurve, privateKey *big.Int, hashed []byte) []byte {
+	t.Helper()
+	size := (curve.n.BitLen() + 7) / 8
+	for {
+		k, pt, err := curve.generateKey(rand.Reader, curve.g)
+		require.NoError(t, err)
+		r := new(big.Int).Mod(pt.x, curve.n)
+		s := new(big.Int).Mul(r, privateKey)
+		s.Add(s, curve.hashToInt(hashed)).Mul(s, k.ModInverse(k, curve.n)).Mod(s, curve.n)
+		if r.Sign() != 0 && s.Sign() != 0 {
+			signature := make([]byte, 2*size)
+			r.FillBytes(signature[:size])
+			s.FillBytes(signature[size:])
+			return signature
+		}
+	}
+}
+
+func TestECCurve_Verify(t *testing.T) {
+	hashed := sha256.Sum256([]byte("challenge"))
+
+	// Signature of crypto/ecdsa on P-256.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hashed[:])
+	require.NoError(t, err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	p256 := standardCurves[12]
+	require.True(t, p256.verify(ecPoint{key.X, key.Y}, hashed[:], signature))
+
+	// brainpoolP384r1 with a hash shorter than the order and brainpoolP192r1
+	// with a truncated hash.
+	for _, id := range []int{16, 9} {
+		curve := standardCurves[id]
+		d, pub, err := curve.generateKey(rand.Reader, curve.g)
+		require.NoError(t, err)
+		signature = signPlainECDSA(t, curve, d, hashed[:])
+		require.True(t, curve.verify(pub, hashed[:], signature), curve.name)
+
+		tampered := append([]byte{}, signature...)
+		tampered[len(tampered)-1] ^= 0x01
+		require.False(t, curve.verify(pub, hashed[:], tampered), curve.name)
+		require.False(t, curve.verify(pub, hashed[:], signature[1:]), curve.name)
+		require.False(t, curve.verify(curve.g, hashed[:], signature), curve.name)
+	}
+}
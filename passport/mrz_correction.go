@@ -0,0 +1,219 @@
+package passport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAmbiguousCorrection is returned when more than one correction
+// of the MRZ satisfies the check digits.
+var ErrAmbiguousCorrection = errors.New("ambiguous MRZ correction")
+
+// maxCorrectionCandidates limits the number of alphanumeric characters
+// that are tried in both readings.
+const maxCorrectionCandidates = 16
+
+// charClass is the set of characters allowed in an MRZ field.
+type charClass int
+
+const (
+	classAlpha        charClass = iota // letters and filler
+	classNumeric                       // digits and filler
+	classAlphanumeric                  // letters, digits and filler
+	classUnchecked                     // not covered by check digits, kept as read
+)
+
+// classSpan assigns a character class to a range of the MRZ.
+type classSpan struct {
+	span
+	class charClass
+}
+
+// mrzClasses describe the character classes of every MRZ position.
+// ICAO 9303 part 5 (TD1), part 6 (TD2) and part 4 (TD3).
+var mrzClasses = map[DocumentFormat][]classSpan{
+	FormatTD1: {
+		{span{0, 5}, classAlpha},          // document code, issuing state
+		{span{5, 30}, classAlphanumeric},  // document number, check digit, optional data
+		{span{30, 37}, classNumeric},      // date of birth, check digit
+		{span{37, 38}, classAlpha},        // sex
+		{span{38, 45}, classNumeric},      // date of expiry, check digit
+		{span{45, 48}, classAlpha},        // nationality
+		{span{48, 59}, classAlphanumeric}, // optional data
+		{span{59, 60}, classNumeric},      // composite check digit
+		{span{60, 90}, classAlpha},        // name
+	},
+	FormatTD2: {
+		{span{0, 36}, classAlpha},         // document code, issuing state, name
+		{span{36, 45}, classAlphanumeric}, // document number
+		{span{45, 46}, classNumeric},      // check digit
+		{span{46, 49}, classAlpha},        // nationality
+		{span{49, 56}, classNumeric},      // date of birth, check digit
+		{span{56, 57}, classAlpha},        // sex
+		{span{57, 64}, classNumeric},      // date of expiry, check digit
+		{span{64, 71}, classAlphanumeric}, // optional data
+		{span{71, 72}, classNumeric},      // composite check digit
+	},
+	FormatTD3: {
+		{span{0, 44}, classAlpha},         // document code, issuing state, name
+		{span{44, 53}, classAlphanumeric}, // document number
+		{span{53, 54}, classNumeric},      // check digit
+		{span{54, 57}, classAlpha},        // nationality
+		{span{57, 64}, classNumeric},      // date of birth, check digit
+		{span{64, 65}, classAlpha},        // sex
+		{span{65, 72}, classNumeric},      // date of expiry, check digit
+		{span{72, 86}, classAlphanumeric}, // personal number
+		{span{86, 88}, classNumeric},      // check digits
+	},
+	FormatMRVA: {
+		{span{0, 44}, classAlpha},         // document code, issuing state, name
+		{span{44, 53}, classAlphanumeric}, // visa number
+		{span{53, 54}, classNumeric},      // check digit
+		{span{54, 57}, classAlpha},        // nationality
+		{span{57, 64}, classNumeric},      // date of birth, check digit
+		{span{64, 65}, classAlpha},        // sex
+		{span{65, 72}, classNumeric},      // valid until, check digit
+		{span{72, 88}, classUnchecked},    // optional data
+	},
+	FormatMRVB: {
+		{span{0, 36}, classAlpha},         // document code, issuing state, name
+		{span{36, 45}, classAlphanumeric}, // visa number
+		{span{45, 46}, classNumeric},      // check digit
+		{span{46, 49}, classAlpha},        // nationality
+		{span{49, 56}, classNumeric},      // date of birth, check digit
+		{span{56, 57}, classAlpha},        // sex
+		{span{57, 64}, classNumeric},      // valid until, check digit
+		{span{64, 72}, classUnchecked},    // optional data
+	},
+}
+
+// Characters commonly swapped by OCR.
+var (
+	letterToDigit = map[byte]byte{'O': '0', 'I': '1', 'B': '8', 'S': '5'}
+	digitToLetter = map[byte]byte{'0': 'O', '1': 'I', '8': 'B', '5': 'S'}
+)
+
+// MRZCorrection is a character replaced by CorrectMRZ.
+type MRZCorrection struct {
+	Position int    // Position in the MRZ without line separators
+	From     string // Character read by OCR
+	To       string // Corrected character
+}
+
+// CorrectMRZ fixes OCR confusions of O/0, I/1, B/8 and S/5 in the MRZ text.
+// Letters in numeric fields and digits in alphabetic fields are replaced
+// directly. Confusable characters in alphanumeric fields, such as the
+// document number, are tried in both readings until the check digits
+// validate. When more than one reading validates, ErrAmbiguousCorrection is
+// returned, whatever the number of substitutions of each reading.
+//
+// The corrected MRZ is returned with lines separated by "\n", together with
+// the applied corrections.
+func CorrectMRZ(text string) (string, []MRZCorrection, error) {
+	normalized, err := normalizeMRZ(text)
+	if err != nil {
+		return "", nil, err
+	}
+	format, ok := mrzFormat(normalized)
+	if !ok {
+		return "", nil, fmt.Errorf(
+			"invalid MRZ format: data should be %d (TD1), %d (TD2) or %d (TD3) characters long: %d",
+			td1Length, td2Length, td3Length, len(normalized),
+		)
+	}
+
+	mrz := []byte(normalized)
+	var candidates []int
+	for _, field := range mrzClasses[format] {
+		for i := field.start; i < field.end; i++ {
+			switch field.class {
+			case classAlpha:
+				if c, ok := digitToLetter[mrz[i]]; ok {
+					mrz[i] = c
+				}
+			case classNumeric:
+				if c, ok := letterToDigit[mrz[i]]; ok {
+					mrz[i] = c
+				}
+			case classAlphanumeric:
+				if _, ok := swapConfusable(mrz[i]); ok {
+					candidates = append(candidates, i)
+				}
+			case classUnchecked:
+			}
+		}
+	}
+	if len(candidates) > maxCorrectionCandidates {
+		return "", nil, fmt.Errorf(
+			"failed to correct MRZ: too many ambiguous characters: %d", len(candidates))
+	}
+
+	var (
+		valid   [][]byte
+		lastErr error
+	)
+	for mask := 0; mask < 1<<len(candidates); mask++ {
+		variant := append([]byte(nil), mrz...)
+		for bit, i := range candidates {
+			if mask&(1<<bit) != 0 {
+				variant[i], _ = swapConfusable(variant[i])
+			}
+		}
+		if lastErr = validateMRZ(string(variant)); lastErr != nil {
+			continue
+		}
+		valid = append(valid, variant)
+	}
+	switch len(valid) {
+	case 0:
+		return "", nil, fmt.Errorf("failed to correct MRZ: %w", lastErr)
+	case 1:
+	default:
+		return "", nil, fmt.Errorf("%w: %d readings match the check digits",
+			ErrAmbiguousCorrection, len(valid))
+	}
+
+	var corrections []MRZCorrection
+	for i := range valid[0] {
+		if valid[0][i] != normalized[i] {
+			corrections = append(corrections, MRZCorrection{
+				Position: i,
+				From:     normalized[i : i+1],
+				To:       string(valid[0][i]),
+			})
+		}
+	}
+	return formatMRZLines(string(valid[0]), format), corrections, nil
+}
+
+// swapConfusable returns the other reading of a confusable character.
+func swapConfusable(c byte) (byte, bool) {
+	if d, ok := letterToDigit[c]; ok {
+		return d, true
+	}
+	d, ok := digitToLetter[c]
+	return d, ok
+}
+
+func validateMRZ(mrz string) error {
+	passport, err := parseMRZ(mrz)
+	if err != nil {
+		return err
+	}
+	return passport.ValidateCheckDigits()
+}
+
+// formatMRZLines splits the MRZ of the format into lines.
+func formatMRZLines(mrz string, format DocumentFormat) string {
+	lineCount := 2
+	if format == FormatTD1 {
+		lineCount = 3
+	}
+	lineLength := len(mrz) / lineCount
+	lines := make([]string, 0, lineCount)
+	for i := 0; i < len(mrz); i += lineLength {
+		lines = append(lines, mrz[i:i+lineLength])
+	}
+	return strings.Join(lines, "\n")
+}
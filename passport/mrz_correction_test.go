@@ -0,0 +1,138 @@
+package passport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrectMRZ(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		corrections []MRZCorrection
+	}{
+		{
+			name: "Valid TD3 passport",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B902C37UTO7408122F1204159ZE184226B<<<<<18",
+			expected: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B902C37UTO7408122F1204159ZE184226B<<<<<18",
+		},
+		{
+			name: "Letters in dates and digits in names",
+			input: "P<UT0ERIK5SON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B902C37UTO74O8I22F12O4I59ZE184226B<<<<<I8",
+			expected: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B902C37UTO7408122F1204159ZE184226B<<<<<18",
+			corrections: []MRZCorrection{
+				{Position: 4, From: "0", To: "O"},
+				{Position: 9, From: "5", To: "S"},
+				{Position: 59, From: "O", To: "0"},
+				{Position: 61, From: "I", To: "1"},
+				{Position: 67, From: "O", To: "0"},
+				{Position: 69, From: "I", To: "1"},
+				{Position: 86, From: "I", To: "1"},
+			},
+		},
+		{
+			name: "Document number",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B9O2C37UTO7408122F1204159ZE184226B<<<<<18",
+			expected: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B902C37UTO7408122F1204159ZE184226B<<<<<18",
+			corrections: []MRZCorrection{
+				{Position: 49, From: "O", To: "0"},
+			},
+		},
+		{
+			name: "TD1 identity card optional data",
+			input: "I<UTOD231457900<<<<<<<<<<<<<<<\n" +
+				"7408122F1204159UTO<<<<<<<<<<<0\n" +
+				"ERIKSSON<<ANNA<MAR1A<<<<<<<<<<",
+			expected: "I<UTOD231457900<<<<<<<<<<<<<<<\n" +
+				"7408122F1204159UTO<<<<<<<<<<<0\n" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+			corrections: []MRZCorrection{
+				{Position: 78, From: "1", To: "I"},
+			},
+		},
+		{
+			name: "TD2 identity card document number",
+			input: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+				"D2314S7900UTO7408122F1204159<<<<<<<0",
+			expected: "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+				"D231457900UTO7408122F1204159<<<<<<<0",
+			corrections: []MRZCorrection{
+				{Position: 41, From: "S", To: "5"},
+			},
+		},
+		{
+			name: "MRV-A visa optional data is kept",
+			input: "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B901C41XXX4OO9078F9612109ZE184226B<<<<<<<",
+			expected: "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L89B901C41XXX4009078F9612109ZE184226B<<<<<<<",
+			corrections: []MRZCorrection{
+				{Position: 58, From: "O", To: "0"},
+				{Position: 59, From: "O", To: "0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrz, corrections, err := CorrectMRZ(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, mrz)
+			require.Equal(t, tt.corrections, corrections)
+
+			_, err = ParseMRZ(mrz)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCorrectMRZ_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr error
+	}{
+		{
+			name: "Two readings of the document number",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"O076543272UTO7408122F1204159<<<<<<<<<<<<<<<6",
+			expectedErr: ErrAmbiguousCorrection,
+		},
+		{
+			name: "One and two substitutions both validate",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"X40702OC35UTO7408122F1204159<<<<<<<<<<<<<<00",
+			expectedErr: ErrAmbiguousCorrection,
+		},
+		{
+			name: "ICAO specimen document number reads as LB9B902C3",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L898902C36UTO7408122F1204159ZE184226B<<<<<10",
+			expectedErr: ErrAmbiguousCorrection,
+		},
+		{
+			name: "Digit misread as another digit",
+			input: "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L898902C36UTO7408125F1204159ZE184226B<<<<<10",
+		},
+		{name: "Unsupported length", input: "P<UTOERIKSSON<<ANNA<MARIA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := CorrectMRZ(tt.input)
+			require.Error(t, err)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
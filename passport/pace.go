@@ -0,0 +1,342 @@
+package passport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // SHA-1 is required by ICAO 9303 PACE
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// PACE object identifiers.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 41
+var (
+	oidPACE               = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 4}
+	oidPACEECDHGMAES128   = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 4, 2, 2}
+	oidPACEECDHGMAES192   = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 4, 2, 3}
+	oidPACEECDHGMAES256   = asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 4, 2, 4}
+	paceInfoProtocolDepth = len(oidPACEECDHGMAES128)
+)
+
+// paceKeySizes are the AES key sizes of the supported PACE protocols.
+var paceKeySizes = map[string]int{
+	oidPACEECDHGMAES128.String(): 16,
+	oidPACEECDHGMAES192.String(): 24,
+	oidPACEECDHGMAES256.String(): 32,
+}
+
+// General Authenticate data objects.
+const (
+	dynamicAuthDataTag  = 0x7C // Dynamic authentication data
+	encryptedNonceTag   = 0x80 // Encrypted nonce
+	mappingDataPCDTag   = 0x81 // Mapping data of the terminal
+	mappingDataPICCTag  = 0x82 // Mapping data of the chip
+	ephemeralKeyPCDTag  = 0x83 // Ephemeral public key of the terminal
+	ephemeralKeyPICCTag = 0x84 // Ephemeral public key of the chip
+	authTokenPCDTag     = 0x85 // Authentication token of the terminal
+	authTokenPICCTag    = 0x86 // Authentication token of the chip
+
+	publicKeyTag       = 0x7F49 // Public key data object
+	ecPublicPointTag   = 0x86   // Public point of an EC public key
+	cryptoMechanismTag = 0x80   // Cryptographic mechanism reference
+	passwordRefTag     = 0x83   // Reference of a public key or secret key
+)
+
+// PasswordType is the kind of PACE password.
+type PasswordType byte
+
+const (
+	PasswordMRZ PasswordType = 1 // Machine readable zone
+	PasswordCAN PasswordType = 2 // Card access number
+)
+
+// PACEPassword is the shared secret PACE keys are derived from.
+type PACEPassword struct {
+	Type PasswordType
+	key  []byte
+}
+
+// NewMRZPassword returns the PACE password derived from the MRZ as for BAC.
+func NewMRZPassword(key BACKey) (PACEPassword, error) {
+	info, err := key.mrzInformation()
+	if err != nil {
+		return PACEPassword{}, err
+	}
+	//nolint:gosec // SHA-1 is required by ICAO 9303
+	digest := sha1.Sum([]byte(info))
+	return PACEPassword{Type: PasswordMRZ, key: digest[:]}, nil
+}
+
+// NewCANPassword returns the PACE password of the card access number printed
+// on the document.
+func NewCANPassword(can string) (PACEPassword, error) {
+	if can == "" {
+		return PACEPassword{}, errors.New("invalid CAN: empty")
+	}
+	for i := 0; i < len(can); i++ {
+		if can[i] < '0' || can[i] > '9' {
+			return PACEPassword{}, fmt.Errorf("invalid CAN: unexpected character '%c'", can[i])
+		}
+	}
+	return PACEPassword{Type: PasswordCAN, key: []byte(can)}, nil
+}
+
+// PACEInfo is a PACE protocol supported by the chip.
+type PACEInfo struct {
+	Protocol    asn1.ObjectIdentifier // PACE protocol, e.g. id-PACE-ECDH-GM-AES-CBC-CMAC-128
+	Version     int                   // Protocol version, 2
+	ParameterID int                   // Standardized domain parameters, e.g. 13 for brainpoolP256r1
+}
+
+type securityInfo struct {
+	Protocol     asn1.ObjectIdentifier
+	RequiredData asn1.RawValue
+	OptionalData asn1.RawValue `asn1:"optional"`
+}
+
+// ParseCardAccess parses EF.CardAccess and returns the PACE protocols
+// supported by the chip. Other security infos are skipped.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 91
+func ParseCardAccess(data []byte) ([]PACEInfo, error) {
+	var infos []securityInfo
+	rest, err := asn1.UnmarshalWithParams(data, &infos, "set")
+	if err != nil {
+		return nil, fmt.Errorf("invalid EF.CardAccess: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("invalid EF.CardAccess: %d trailing bytes", len(rest))
+	}
+
+	var pace []PACEInfo
+	for _, info := range infos {
+		if len(info.Protocol) != paceInfoProtocolDepth ||
+			!info.Protocol[:len(oidPACE)].Equal(oidPACE) {
+			continue
+		}
+		paceInfo := PACEInfo{Protocol: info.Protocol}
+		if _, err = asn1.Unmarshal(info.RequiredData.FullBytes, &paceInfo.Version); err != nil {
+			return nil, fmt.Errorf("invalid PACEInfo version: %w", err)
+		}
+		if len(info.OptionalData.FullBytes) != 0 {
+			_, err = asn1.Unmarshal(info.OptionalData.FullBytes, &paceInfo.ParameterID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PACEInfo parameter ID: %w", err)
+			}
+		}
+		pace = append(pace, paceInfo)
+	}
+	if len(pace) == 0 {
+		return nil, errors.New("invalid EF.CardAccess: no PACEInfo found")
+	}
+	return pace, nil
+}
+
+// PerformPACE runs PACE with generic mapping over ECDH with the chip and
+// returns the AES secure messaging session. The protocol and the domain
+// parameters are taken from EF.CardAccess, see ParseCardAccess. The eMRTD
+// application should be selected after PACE, see SelectApplication.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 16
+func PerformPACE(t Transport, info PACEInfo, password PACEPassword) (*SecureMessaging, error) {
+	return performPACE(t, info, password, rand.Reader)
+}
+
+func performPACE(
+	t Transport, info PACEInfo, password PACEPassword, random io.Reader,
+) (*SecureMessaging, error) {
+	keySize, ok := paceKeySizes[info.Protocol.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported PACE protocol %s", info.Protocol)
+	}
+	curve, ok := standardCurves[info.ParameterID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported PACE domain parameters %d", info.ParameterID)
+	}
+
+	if err := setAuthenticationTemplate(t, info.Protocol, password.Type); err != nil {
+		return nil, err
+	}
+
+	// Decrypt the nonce with the key derived from the password.
+	z, err := generalAuthenticate(t, 0, nil, encryptedNonceTag, false)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decryptNonce(deriveAESKey(password.key, kdfCounterPACE, keySize), z)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the nonce to an ephemeral generator.
+	generator, err := mapNonce(t, curve, s, random)
+	if err != nil {
+		return nil, err
+	}
+
+	// Key agreement with the ephemeral generator.
+	privateKey, publicKey, err := curve.generateKey(random, generator)
+	if err != nil {
+		return nil, err
+	}
+	chipKey, err := exchangePoint(
+		t, curve, publicKey, ephemeralKeyPCDTag, ephemeralKeyPICCTag)
+	if err != nil {
+		return nil, err
+	}
+	if chipKey.x.Cmp(publicKey.x) == 0 && chipKey.y.Cmp(publicKey.y) == 0 {
+		return nil, fmt.Errorf("%w: chip returned the terminal public key", ErrMutualAuthentication)
+	}
+	shared := curve.scalarMult(chipKey, privateKey)
+	if shared.infinity() {
+		return nil, fmt.Errorf("%w: invalid shared secret", ErrMutualAuthentication)
+	}
+	secret := make([]byte, curve.byteSize())
+	shared.x.FillBytes(secret)
+	ksEnc := deriveAESKey(secret, kdfCounterEnc, keySize)
+	ksMac := deriveAESKey(secret, kdfCounterMAC, keySize)
+
+	// Mutual authentication.
+	session, err := newAESCipher(ksEnc, ksMac)
+	if err != nil {
+		return nil, err
+	}
+	tokenPCD := session.mac(authenticationToken(info.Protocol, curve, chipKey))
+	tokenPICC, err := generalAuthenticate(t, authTokenPCDTag, tokenPCD, authTokenPICCTag, true)
+	if err != nil {
+		return nil, err
+	}
+	expected := session.mac(authenticationToken(info.Protocol, curve, publicKey))
+	if subtle.ConstantTimeCompare(tokenPICC, expected) != 1 {
+		return nil, fmt.Errorf("%w: authentication token does not match", ErrMutualAuthentication)
+	}
+
+	return newSecureMessaging(t, session, make([]byte, aes.BlockSize)), nil
+}
+
+// setAuthenticationTemplate selects the PACE protocol and the password with
+// MSE:Set AT.
+func setAuthenticationTemplate(
+	t Transport, protocol asn1.ObjectIdentifier, passwordType PasswordType,
+) error {
+	oid, err := asn1.Marshal(protocol)
+	if err != nil {
+		return fmt.Errorf("failed to encode protocol %s: %w", protocol, err)
+	}
+	data := concatBytes(
+		encodeTLV(cryptoMechanismTag, oid[2:]),
+		encodeTLV(passwordRefTag, []byte{byte(passwordType)}),
+	)
+	apdu := append([]byte{0x00, 0x22, 0xC1, 0xA4, byte(len(data))}, data...)
+	if _, err = transmit(t, apdu); err != nil {
+		return fmt.Errorf("failed to set PACE authentication template: %w", err)
+	}
+	return nil
+}
+
+// generalAuthenticate sends a step of PACE with GENERAL AUTHENTICATE and
+// returns the data object of the response with the tag. Every step except
+// the last one is sent with command chaining.
+func generalAuthenticate(
+	t Transport, tag uint32, value []byte, responseTag uint32, last bool,
+) ([]byte, error) {
+	var data []byte
+	if value != nil {
+		data = encodeTLV(tag, value)
+	}
+	data = encodeTLV(dynamicAuthDataTag, data)
+	cla := byte(0x10)
+	if last {
+		cla = 0x00
+	}
+	apdu := append([]byte{cla, 0x86, 0x00, 0x00, byte(len(data))}, data...)
+	response, err := transmit(t, append(apdu, 0x00))
+	if err != nil {
+		return nil, fmt.Errorf("PACE step 0x%X failed: %w", responseTag, err)
+	}
+
+	objs, err := decodeDataGroup(response, "dynamic authentication data", dynamicAuthDataTag)
+	if err != nil {
+		return nil, fmt.Errorf("PACE step 0x%X failed: %w", responseTag, err)
+	}
+	obj, ok := findTLV(objs, responseTag)
+	if !ok {
+		return nil, fmt.Errorf("PACE step 0x%X failed: %w: data object not found",
+			responseTag, ErrMalformedTLV)
+	}
+	return obj.value, nil
+}
+
+// exchangePoint sends the public point of the terminal and returns the
+// public point of the chip.
+func exchangePoint(
+	t Transport, curve *ecCurve, point ecPoint, tag, responseTag uint32,
+) (ecPoint, error) {
+	data, err := generalAuthenticate(t, tag, curve.marshal(point), responseTag, false)
+	if err != nil {
+		return ecPoint{}, err
+	}
+	chipPoint, err := curve.unmarshal(data)
+	if err != nil {
+		return ecPoint{}, fmt.Errorf("invalid chip public key: %w", err)
+	}
+	return chipPoint, nil
+}
+
+// mapNonce runs the generic mapping: G~ = s*G + H, where H is the shared
+// secret of an anonymous ECDH key agreement.
+func mapNonce(t Transport, curve *ecCurve, s []byte, random io.Reader) (ecPoint, error) {
+	privateKey, publicKey, err := curve.generateKey(random, curve.g)
+	if err != nil {
+		return ecPoint{}, err
+	}
+	chipKey, err := exchangePoint(t, curve, publicKey, mappingDataPCDTag, mappingDataPICCTag)
+	if err != nil {
+		return ecPoint{}, err
+	}
+	shared := curve.scalarMult(chipKey, privateKey)
+	generator := curve.add(curve.scalarMult(curve.g, new(big.Int).SetBytes(s)), shared)
+	if shared.infinity() || generator.infinity() {
+		return ecPoint{}, fmt.Errorf("%w: invalid mapped generator", ErrMutualAuthentication)
+	}
+	return generator, nil
+}
+
+func decryptNonce(kPi, z []byte) ([]byte, error) {
+	if len(z) == 0 || len(z)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid encrypted nonce length %d", len(z))
+	}
+	block, err := aes.NewCipher(kPi)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	s := make([]byte, len(z))
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(s, z)
+	return s, nil
+}
+
+// authenticationToken returns the public key data object the
+// authentication token is computed over.
+func authenticationToken(protocol asn1.ObjectIdentifier, curve *ecCurve, key ecPoint) []byte {
+	oid, _ := asn1.Marshal(protocol) // protocol is encoded by setAuthenticationTemplate
+	point := encodeTLV(ecPublicPointTag, curve.marshal(key))
+	return encodeTLV(publicKeyTag, concatBytes(oid, point))
+}
+
+// deriveAESKey derives an AES key from the seed: SHA-1 for 128 bit keys and
+// SHA-256 for 192 and 256 bit keys.
+func deriveAESKey(seed []byte, counter uint32, size int) []byte {
+	data := binary.BigEndian.AppendUint32(append([]byte{}, seed...), counter)
+	if size == 16 {
+		//nolint:gosec // SHA-1 is required by ICAO 9303
+		digest := sha1.Sum(data)
+		return digest[:size]
+	}
+	digest := sha256.Sum256(data)
+	return digest[:size]
+}
@@ -0,0 +1,150 @@
+package passport
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/asn1"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ICAO 9303 part 11 appendix G.1 worked example, ECDH generic mapping
+// on brainpoolP256r1.
+var (
+	testPACEInfo = PACEInfo{Protocol: oidPACEECDHGMAES128, Version: 2, ParameterID: 13}
+	testPACEKey  = BACKey{
+		DocumentNumber: "T22000129",
+		DateOfBirth:    "640812",
+		DateOfExpiry:   "101031",
+	}
+	// Private keys of the terminal for the mapping and the key agreement.
+	testPACERandom = "7F4EF07B9EA82FD78AD689B38D0BC78CF21F249D953BC46F4C6E19259C010F99" +
+		"A73FB703AC1436A18E0CFA5ABB3F7BEC7A070E7A6788486BEE230C4A22762595"
+	testPACEExchanges = []apduExchange{
+		{command: "0022C1A40F800A04007F00070202040202830101", response: "9000"},
+		{
+			command:  "10860000027C0000",
+			response: "7C12801095A3A016522EE98D01E76CB6B98B42C39000",
+		},
+		{
+			command: "10860000457C438141" + "04" +
+				"7ACF3EFC982EC45565A4B155129EFBC74650DCBFA6362D896FC70262E0C2CC5E" +
+				"544552DCB6725218799115B55C9BAA6D9F6BC3A9618E70C25AF71777A9C4922D" + "00",
+			response: "7C438241" + "04" +
+				"824FBA91C9CBE26BEF53A0EBE7342A3BF178CEA9F45DE0B70AA601651FBA3F57" +
+				"30D8C879AAA9C9F73991E61B58F4D52EB87A0A0C709A49DC63719363CCD13C54" + "9000",
+		},
+		{
+			command: "10860000457C438341" + "04" +
+				"2DB7A64C0355044EC9DF190514C625CBA2CEA48754887122F3A5EF0D5EDD301C" +
+				"3556F3B3B186DF10B857B58F6A7EB80F20BA5DC7BE1D43D9BF850149FBB36462" + "00",
+			response: "7C438441" + "04" +
+				"9E880F842905B8B3181F7AF7CAA9F0EFB743847F44A306D2D28C1D9EC65DF6DB" +
+				"7764B22277A2EDDC3C265A9F018F9CB852E111B768B326904B59A0193776F094" + "9000",
+		},
+		{
+			command:  "008600000C7C0A8508C2B0BD78D94BA86600",
+			response: "7C0A86083ABB9674BCE93C089000",
+		},
+	}
+)
+
+func TestPerformPACE(t *testing.T) {
+	password, err := NewMRZPassword(testPACEKey)
+	require.NoError(t, err)
+	require.Equal(t, "89DED1B26624EC1E634C1989302849DD",
+		strings.ToUpper(hex.EncodeToString(deriveAESKey(password.key, kdfCounterPACE, 16))))
+
+	card := &testCard{t: t, exchanges: append([]apduExchange{}, testPACEExchanges...)}
+	sm, err := performPACE(card, testPACEInfo, password,
+		bytes.NewReader(mustDecodeHex(t, testPACERandom)))
+	require.NoError(t, err)
+	require.Empty(t, card.exchanges)
+	require.Equal(t, make([]byte, aes.BlockSize), sm.ssc)
+
+	// Session keys KSenc F5F0E35C0D7161EE6724EE513A0D9A7F and
+	// KSmac FE251C7858B356B24514B3BD5F4297D1.
+	expected, err := newAESCipher(
+		mustDecodeHex(t, "F5F0E35C0D7161EE6724EE513A0D9A7F"),
+		mustDecodeHex(t, "FE251C7858B356B24514B3BD5F4297D1"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected, sm.cipher)
+}
+
+func TestPerformPACE_Invalid(t *testing.T) {
+	password, err := NewMRZPassword(testPACEKey)
+	require.NoError(t, err)
+
+	wrongToken := append([]apduExchange{}, testPACEExchanges...)
+	wrongToken[4].response = "7C0A86083ABB9674BCE93C099000"
+	offCurve := append([]apduExchange{}, testPACEExchanges...)
+	offCurve[2].response = strings.Replace(offCurve[2].response, "824FBA", "824FBB", 1)
+
+	tests := []struct {
+		name      string
+		info      PACEInfo
+		exchanges []apduExchange
+	}{
+		{name: "Wrong token", info: testPACEInfo, exchanges: wrongToken},
+		{name: "Point not on curve", info: testPACEInfo, exchanges: offCurve[:3]},
+		{
+			name:      "Wrong password",
+			info:      testPACEInfo,
+			exchanges: []apduExchange{{command: testPACEExchanges[0].command, response: "6A88"}},
+		},
+		{
+			name: "Unsupported protocol",
+			info: PACEInfo{Protocol: asn1.ObjectIdentifier{0, 4, 0, 127, 0, 7, 2, 2, 4, 2, 1}},
+		},
+		{
+			name: "Unsupported parameters",
+			info: PACEInfo{Protocol: oidPACEECDHGMAES128, ParameterID: 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := &testCard{t: t, exchanges: tt.exchanges}
+			_, err := performPACE(card, tt.info, password,
+				bytes.NewReader(mustDecodeHex(t, testPACERandom)))
+			require.Error(t, err)
+			require.Empty(t, card.exchanges)
+		})
+	}
+}
+
+func TestNewCANPassword(t *testing.T) {
+	password, err := NewCANPassword("123456")
+	require.NoError(t, err)
+	require.Equal(t, PasswordCAN, password.Type)
+	require.Equal(t, []byte("123456"), password.key)
+
+	for _, can := range []string{"", "12A456"} {
+		_, err = NewCANPassword(can)
+		require.Error(t, err)
+	}
+}
+
+func TestParseCardAccess(t *testing.T) {
+	// PACEInfo for ECDH generic mapping on brainpoolP256r1 and a
+	// ChipAuthenticationInfo that is skipped.
+	cardAccess := mustDecodeHex(t, "3128"+
+		"3012060A04007F0007020204020202010202010D"+
+		"3012060A04007F00070202030202020101020101")
+	infos, err := ParseCardAccess(cardAccess)
+	require.NoError(t, err)
+	require.Equal(t, []PACEInfo{testPACEInfo}, infos)
+
+	for _, data := range []string{
+		"3014" + "3012060A04007F00070202030202020101020101",
+		"3012060A04007F0007020204020202010202010D",
+		"3128" + "3012060A04007F0007020204020202010202010D",
+	} {
+		_, err = ParseCardAccess(mustDecodeHex(t, data))
+		require.Error(t, err, data)
+	}
+}
@@ -0,0 +1,530 @@
+package passport
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Passive authentication failures reported by PassiveAuthReport.
+var (
+	ErrSignatureInvalid   = errors.New("SOD signature is invalid")
+	ErrChainInvalid       = errors.New("document signer certificate is not issued by a trusted CSCA")
+	ErrCertificateExpired = errors.New("certificate is not valid at verification time")
+	ErrCertificateRevoked = errors.New("document signer certificate is revoked")
+	ErrRevocationUnknown  = errors.New("revocation status of the document signer certificate is unknown")
+	ErrKeyUsage           = errors.New("document signer certificate is not allowed to sign documents")
+)
+
+var (
+	oidCSCAMasterList         = asn1.ObjectIdentifier{2, 23, 136, 1, 1, 2}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidRSASSAPSS              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+)
+
+type signatureScheme int
+
+const (
+	schemeRSAPKCS1 signatureScheme = iota
+	schemeRSAPSS
+	schemeECDSA
+)
+
+// signatureAlgorithms lists signature algorithms of the signer info. A zero
+// hash means the digest algorithm of the signer info is used.
+var signatureAlgorithms = []struct {
+	oid    asn1.ObjectIdentifier
+	scheme signatureScheme
+	hash   crypto.Hash
+}{
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}, schemeRSAPKCS1, 0},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}, schemeRSAPKCS1, crypto.SHA1},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 14}, schemeRSAPKCS1, crypto.SHA224},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, schemeRSAPKCS1, crypto.SHA256},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}, schemeRSAPKCS1, crypto.SHA384},
+	{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}, schemeRSAPKCS1, crypto.SHA512},
+	{oidRSASSAPSS, schemeRSAPSS, 0},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}, schemeECDSA, 0},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}, schemeECDSA, crypto.SHA1},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 1}, schemeECDSA, crypto.SHA224},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}, schemeECDSA, crypto.SHA256},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}, schemeECDSA, crypto.SHA384},
+	{asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}, schemeECDSA, crypto.SHA512},
+}
+
+// RFC 4055 RSASSA-PSS-params.
+type pssParameters struct {
+	Hash         pkix.AlgorithmIdentifier `asn1:"explicit,optional,tag:0"`
+	MGF          pkix.AlgorithmIdentifier `asn1:"explicit,optional,tag:1"`
+	SaltLength   int                      `asn1:"explicit,optional,default:20,tag:2"`
+	TrailerField int                      `asn1:"explicit,optional,default:1,tag:3"`
+}
+
+// ICAO 9303 part 12 CscaMasterList.
+type cscaMasterList struct {
+	Version  int
+	CertList []asn1.RawValue `asn1:"set"`
+}
+
+type signedAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// TrustStore holds Country Signing CA certificates and certificate
+// revocation lists used for passive authentication.
+type TrustStore struct {
+	cscas []*x509.Certificate
+	crls  []*x509.RevocationList
+}
+
+// NewTrustStore creates an empty trust store.
+func NewTrustStore() *TrustStore {
+	return &TrustStore{}
+}
+
+// AddCSCA adds trusted CSCA certificates.
+func (ts *TrustStore) AddCSCA(certs ...*x509.Certificate) {
+	ts.cscas = append(ts.cscas, certs...)
+}
+
+// AddCRL adds a certificate revocation list. The CRL is used only when it is
+// signed by one of the trusted CSCA certificates.
+func (ts *TrustStore) AddCRL(crls ...*x509.RevocationList) {
+	ts.crls = append(ts.crls, crls...)
+}
+
+// LoadCSCAFile loads CSCA certificates from a PEM bundle or from an ICAO
+// master list, see LoadMasterList.
+func (ts *TrustStore) LoadCSCAFile(path string) error {
+	//nolint:gosec // path is provided by the issuer configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CSCA file '%s': %w", path, err)
+	}
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		err = ts.LoadPEM(data)
+	} else {
+		err = ts.LoadMasterList(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load CSCA file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// LoadPEM loads CSCA certificates from a PEM bundle.
+func (ts *TrustStore) LoadPEM(data []byte) error {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := parseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return errors.New("no certificates found in PEM data")
+	}
+	ts.AddCSCA(certs...)
+	return nil
+}
+
+// LoadMasterList loads CSCA certificates from a DER encoded ICAO master
+// list. The master list must be signed by a Master List Signer issued by a
+// CSCA already in the trust store, usually the CSCA of the state publishing
+// the list loaded with LoadPEM. The validity dates of the signer are not
+// checked, so an archived master list can be loaded.
+func (ts *TrustStore) LoadMasterList(data []byte) error {
+	return ts.loadMasterList(data, true)
+}
+
+// LoadMasterListUnverified loads CSCA certificates from a DER encoded ICAO
+// master list without verifying its signature. Every certificate in the
+// list becomes a trust anchor, so it must only be used for a master list
+// from a trusted source, e.g. one verified by other means.
+func (ts *TrustStore) LoadMasterListUnverified(data []byte) error {
+	return ts.loadMasterList(data, false)
+}
+
+func (ts *TrustStore) loadMasterList(data []byte, verify bool) error {
+	sd, err := parseSignedData(data, oidCSCAMasterList)
+	if err != nil {
+		return fmt.Errorf("failed to parse master list: %w", err)
+	}
+	if verify {
+		if _, err = verifySignedData(sd); err != nil {
+			return fmt.Errorf("%w: master list: %w", ErrSignatureInvalid, err)
+		}
+		if ts.findIssuer(sd.certificate) == nil {
+			return fmt.Errorf("%w: master list signer '%s'",
+				ErrChainInvalid, sd.certificate.Subject.CommonName)
+		}
+	}
+	var ml cscaMasterList
+	if err = unmarshalDER(sd.eContent, &ml); err != nil {
+		return fmt.Errorf("failed to parse master list: %w", err)
+	}
+	certs := make([]*x509.Certificate, 0, len(ml.CertList))
+	for _, raw := range ml.CertList {
+		cert, err := parseCertificate(raw.FullBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse master list certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	ts.AddCSCA(certs...)
+	return nil
+}
+
+// LoadCRLFile loads a DER or PEM encoded certificate revocation list.
+func (ts *TrustStore) LoadCRLFile(path string) error {
+	//nolint:gosec // path is provided by the issuer configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CRL file '%s': %w", path, err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse CRL file '%s': %w", path, err)
+	}
+	ts.AddCRL(crl)
+	return nil
+}
+
+// PassiveAuthReport is the result of passive authentication of a SOD.
+type PassiveAuthReport struct {
+	SignatureAlgorithm string              // Signature algorithm of the SOD, e.g. SHA256withECDSA
+	SignatureValid     bool                // SOD signature and message digest are valid
+	Chain              []*x509.Certificate // Document Signer and CSCA certificates
+	ChainValid         bool                // Document Signer is issued by a trusted CSCA
+	NotBefore          time.Time           // Start of the Document Signer validity
+	NotAfter           time.Time           // End of the Document Signer validity
+	ValidityValid      bool                // Chain certificates are valid at verification time
+	KeyUsageValid      bool                // Document Signer key usage allows digital signatures
+	RevocationChecked  bool                // A current CRL of the CSCA was available
+	Revoked            bool                // Document Signer is listed in a CRL of the CSCA
+	Errors             []error             // All failed checks
+}
+
+// Passed reports whether all passive authentication checks succeeded. The
+// revocation check is one of them: without a current CRL of the CSCA the
+// report has ErrRevocationUnknown and Passed returns false.
+func (r *PassiveAuthReport) Passed() bool {
+	return len(r.Errors) == 0
+}
+
+// Err returns all failed checks joined together or nil.
+func (r *PassiveAuthReport) Err() error {
+	return errors.Join(r.Errors...)
+}
+
+// VerifySOD performs passive authentication of the SOD: it verifies the
+// signature of the Document Signer, chains the Document Signer certificate
+// to a trusted CSCA, checks the validity dates at the given time, the key
+// usage of the Document Signer and its revocation status with a CRL of the
+// CSCA current at the given time. Data group hashes are checked with
+// SOD.VerifyDataGroup.
+func (ts *TrustStore) VerifySOD(sod *SOD, at time.Time) *PassiveAuthReport {
+	ds := sod.Certificate
+	report := &PassiveAuthReport{
+		Chain:     []*x509.Certificate{ds},
+		NotBefore: ds.NotBefore,
+		NotAfter:  ds.NotAfter,
+	}
+	fail := func(err error) {
+		report.Errors = append(report.Errors, err)
+	}
+
+	name, err := verifySODSignature(sod)
+	report.SignatureAlgorithm = name
+	if err != nil {
+		fail(fmt.Errorf("%w: %w", ErrSignatureInvalid, err))
+	} else {
+		report.SignatureValid = true
+	}
+
+	csca := ts.findIssuer(ds)
+	if csca == nil {
+		fail(ErrChainInvalid)
+	} else {
+		report.Chain = append(report.Chain, csca)
+		report.ChainValid = true
+	}
+
+	report.ValidityValid = true
+	for _, cert := range report.Chain {
+		if at.Before(cert.NotBefore) || at.After(cert.NotAfter) {
+			report.ValidityValid = false
+			fail(fmt.Errorf("%w: '%s' is valid from %s to %s",
+				ErrCertificateExpired, cert.Subject.CommonName,
+				cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+
+	if ds.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		fail(ErrKeyUsage)
+	} else {
+		report.KeyUsageValid = true
+	}
+
+	if csca != nil {
+		report.RevocationChecked, report.Revoked, err = ts.checkRevocation(ds, csca, at)
+		switch {
+		case report.Revoked:
+			fail(ErrCertificateRevoked)
+		case err != nil:
+			fail(err)
+		}
+	}
+
+	return report
+}
+
+func (ts *TrustStore) findIssuer(cert *x509.Certificate) *x509.Certificate {
+	for _, csca := range ts.cscas {
+		if !bytes.Equal(csca.RawSubject, cert.RawIssuer) {
+			continue
+		}
+		if len(cert.AuthorityKeyId) > 0 && len(csca.SubjectKeyId) > 0 &&
+			!bytes.Equal(cert.AuthorityKeyId, csca.SubjectKeyId) {
+			continue
+		}
+		if checkSignatureFrom(csca, cert.Raw) == nil {
+			return csca
+		}
+	}
+	return nil
+}
+
+// checkRevocation looks up the certificate in the CRLs of the CSCA. A
+// revocation found in any CRL counts, but the status is only known when
+// one of the CRLs is current at the given time.
+func (ts *TrustStore) checkRevocation(
+	cert, csca *x509.Certificate, at time.Time,
+) (checked, revoked bool, err error) {
+	var expired *x509.RevocationList
+	for _, crl := range ts.crls {
+		if !bytes.Equal(crl.RawIssuer, csca.RawSubject) || checkSignatureFrom(csca, crl.Raw) != nil {
+			continue
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return true, true, nil
+			}
+		}
+		if at.Before(crl.ThisUpdate) || (!crl.NextUpdate.IsZero() && at.After(crl.NextUpdate)) {
+			expired = crl
+			continue
+		}
+		checked = true
+	}
+	switch {
+	case checked:
+		return true, false, nil
+	case expired != nil:
+		return false, false, fmt.Errorf("%w: the CRL of '%s' is valid from %s to %s",
+			ErrRevocationUnknown, csca.Subject.CommonName,
+			expired.ThisUpdate.Format(time.RFC3339), expired.NextUpdate.Format(time.RFC3339))
+	default:
+		return false, false, fmt.Errorf("%w: no CRL of '%s'",
+			ErrRevocationUnknown, csca.Subject.CommonName)
+	}
+}
+
+// checkSignatureFrom verifies the signature of a DER encoded certificate or
+// CRL with the public key of the issuer. Unlike x509.Certificate.CheckSignature
+// it supports the keys of ECPublicKey and accepts SHA-1 still used by some
+// CSCAs.
+func checkSignatureFrom(issuer *x509.Certificate, raw []byte) error {
+	// Certificates and CRLs share the SIGNED structure of RFC 5280.
+	var signed certificate
+	if err := unmarshalDER(raw, &signed); err != nil {
+		return fmt.Errorf("failed to parse signed object: %w", err)
+	}
+	_, err := verifySignature(issuer.PublicKey, signed.SignatureAlgorithm, 0,
+		signed.TBSCertificate.FullBytes, signed.SignatureValue.RightAlign())
+	return err
+}
+
+// parseSignedAttributes parses the DER encoded SET OF signed attributes.
+func parseSignedAttributes(data []byte) ([]signedAttribute, error) {
+	var attrs []signedAttribute
+	rest, err := asn1.UnmarshalWithParams(data, &attrs, "set")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed attributes: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("failed to parse signed attributes: %d trailing bytes", len(rest))
+	}
+	return attrs, nil
+}
+
+// verifySODSignature checks that the message digest attribute matches the
+// eContent and that the signed attributes are signed by the Document Signer.
+func verifySODSignature(sod *SOD) (string, error) {
+	return verifySignedData(&cmsSignedData{
+		eContent:              sod.EContent,
+		signedAttributes:      sod.SignedAttributes,
+		signerDigestAlgorithm: sod.SignerDigestAlgorithm,
+		signatureAlgorithm:    sod.SignatureAlgorithm,
+		signature:             sod.Signature,
+		certificate:           sod.Certificate,
+	})
+}
+
+// verifySignedData checks that the message digest attribute matches the
+// eContent and that the signed attributes are signed by the signer.
+func verifySignedData(sd *cmsSignedData) (string, error) {
+	attrs, err := parseSignedAttributes(sd.signedAttributes)
+	if err != nil {
+		return "", err
+	}
+	var messageDigest []byte
+	for _, attr := range attrs {
+		if attr.Type.Equal(oidAttributeMessageDigest) && len(attr.Values) == 1 {
+			messageDigest = attr.Values[0].Bytes
+		}
+	}
+	h := sd.signerDigestAlgorithm.New()
+	h.Write(sd.eContent)
+	if !bytes.Equal(h.Sum(nil), messageDigest) {
+		return "", errors.New("message digest does not match eContent")
+	}
+
+	return verifySignature(
+		sd.certificate.PublicKey,
+		sd.signatureAlgorithm,
+		sd.signerDigestAlgorithm,
+		sd.signedAttributes,
+		sd.signature,
+	)
+}
+
+// verifySignature verifies signature over data and returns the name of the
+// signature algorithm.
+func verifySignature(
+	publicKey crypto.PublicKey,
+	algorithm pkix.AlgorithmIdentifier,
+	defaultHash crypto.Hash,
+	data, signature []byte,
+) (string, error) {
+	scheme, hash, err := lookupSignatureAlgorithm(algorithm, defaultHash)
+	if err != nil {
+		return "", err
+	}
+	if !hash.Available() {
+		return "", fmt.Errorf("signature algorithm '%s' without digest algorithm",
+			algorithm.Algorithm)
+	}
+	digest := hash.New()
+	digest.Write(data)
+	hashed := digest.Sum(nil)
+	hashName := hashNames[hash]
+
+	switch scheme {
+	case schemeRSAPKCS1, schemeRSAPSS:
+		pub, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("expected RSA public key, got %T", publicKey)
+		}
+		if scheme == schemeRSAPKCS1 {
+			return hashName + "withRSA", rsa.VerifyPKCS1v15(pub, hash, hashed, signature)
+		}
+		saltLength, err := pssSaltLength(algorithm)
+		if err != nil {
+			return "", err
+		}
+		return hashName + "withRSA/PSS", rsa.VerifyPSS(pub, hash, hashed, signature,
+			&rsa.PSSOptions{SaltLength: saltLength, Hash: hash})
+	default:
+		var valid bool
+		switch pub := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			valid = ecdsa.VerifyASN1(pub, hashed, signature)
+		case *ECPublicKey:
+			valid = pub.curve.verifyASN1(ecPoint{pub.X, pub.Y}, hashed, signature)
+		default:
+			return "", fmt.Errorf("expected ECDSA public key, got %T", publicKey)
+		}
+		if !valid {
+			return hashName + "withECDSA", errors.New("ECDSA verification error")
+		}
+		return hashName + "withECDSA", nil
+	}
+}
+
+var hashNames = map[crypto.Hash]string{
+	crypto.SHA1:   "SHA1",
+	crypto.SHA224: "SHA224",
+	crypto.SHA256: "SHA256",
+	crypto.SHA384: "SHA384",
+	crypto.SHA512: "SHA512",
+}
+
+func lookupSignatureAlgorithm(
+	algorithm pkix.AlgorithmIdentifier, defaultHash crypto.Hash,
+) (signatureScheme, crypto.Hash, error) {
+	for _, alg := range signatureAlgorithms {
+		if !alg.oid.Equal(algorithm.Algorithm) {
+			continue
+		}
+		hash := alg.hash
+		if alg.scheme == schemeRSAPSS {
+			params, err := parsePSSParameters(algorithm)
+			if err != nil {
+				return 0, 0, err
+			}
+			hash = crypto.SHA1
+			if len(params.Hash.Algorithm) > 0 {
+				if hash, err = hashFromOID(params.Hash.Algorithm); err != nil {
+					return 0, 0, err
+				}
+			}
+		}
+		if hash == 0 {
+			hash = defaultHash
+		}
+		return alg.scheme, hash, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported signature algorithm '%s'", algorithm.Algorithm)
+}
+
+func parsePSSParameters(algorithm pkix.AlgorithmIdentifier) (pssParameters, error) {
+	params := pssParameters{SaltLength: 20, TrailerField: 1}
+	if len(algorithm.Parameters.FullBytes) == 0 {
+		return params, nil
+	}
+	if err := unmarshalDER(algorithm.Parameters.FullBytes, &params); err != nil {
+		return pssParameters{}, fmt.Errorf("failed to parse RSASSA-PSS parameters: %w", err)
+	}
+	return params, nil
+}
+
+func pssSaltLength(algorithm pkix.AlgorithmIdentifier) (int, error) {
+	params, err := parsePSSParameters(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	return params.SaltLength, nil
+}
@@ -0,0 +1,406 @@
+package passport
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var verificationTime = time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)
+
+func newTestRSAKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// RFC 5280 TBSCertList without extensions.
+type testTBSCertList struct {
+	Version             int
+	Signature           pkix.AlgorithmIdentifier
+	Issuer              asn1.RawValue
+	ThisUpdate          time.Time
+	NextUpdate          time.Time
+	RevokedCertificates []testRevokedCertificate `asn1:"optional"`
+}
+
+type testRevokedCertificate struct {
+	SerialNumber   *big.Int
+	RevocationDate time.Time
+}
+
+// newTestCRL issues a CRL valid in 2025 revoking the serial numbers.
+func newTestCRL(t *testing.T, csca *testSigner, serials ...int64) *x509.RevocationList {
+	t.Helper()
+	return newTestCRLAt(t, csca,
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		serials...)
+}
+
+// newTestCRLAt issues a CRL with the update times. It is built by hand since
+// x509.CreateRevocationList does not support the keys of testCurveKey.
+func newTestCRLAt(
+	t *testing.T, csca *testSigner, thisUpdate, nextUpdate time.Time, serials ...int64,
+) *x509.RevocationList {
+	t.Helper()
+	signatureAlgorithm := pkix.AlgorithmIdentifier{
+		Algorithm: testSignatureOID(t, csca.key, crypto.SHA256),
+	}
+	tbs := testTBSCertList{
+		Version:    1,
+		Signature:  signatureAlgorithm,
+		Issuer:     asn1.RawValue{FullBytes: csca.cert.RawSubject},
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}
+	for _, serial := range serials {
+		tbs.RevokedCertificates = append(tbs.RevokedCertificates,
+			testRevokedCertificate{SerialNumber: big.NewInt(serial), RevocationDate: thisUpdate})
+	}
+	tbsRaw := mustMarshal(t, tbs, "")
+	digest := crypto.SHA256.New()
+	digest.Write(tbsRaw)
+	signature, err := csca.key.Sign(rand.Reader, digest.Sum(nil), crypto.SHA256)
+	require.NoError(t, err)
+
+	crl, err := x509.ParseRevocationList(mustMarshal(t, certificate{
+		TBSCertificate:     asn1.RawValue{FullBytes: tbsRaw},
+		SignatureAlgorithm: signatureAlgorithm,
+		SignatureValue:     asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	}, ""))
+	require.NoError(t, err)
+	return crl
+}
+
+// newTestMasterList builds an ICAO master list with the certificates signed
+// by the Master List Signer.
+func newTestMasterList(t *testing.T, signer *testSigner, certs ...*x509.Certificate) []byte {
+	t.Helper()
+	ml := cscaMasterList{}
+	for _, c := range certs {
+		ml.CertList = append(ml.CertList, asn1.RawValue{FullBytes: c.Raw})
+	}
+	return newTestSignedData(t, signer, crypto.SHA256,
+		pkix.AlgorithmIdentifier{Algorithm: oidSHA256}, oidCSCAMasterList,
+		mustMarshal(t, ml, ""), oidCSCAMasterList)
+}
+
+func TestTrustStore_VerifySOD(t *testing.T) {
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	ecCSCA := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	ecDS := newTestSigner(t, newTestECKey(t), ecCSCA, 2, notAfter)
+	rsaCSCA := newTestSigner(t, newTestRSAKey(t), nil, 3, notAfter)
+	rsaDS := newTestSigner(t, newTestRSAKey(t), rsaCSCA, 4, notAfter)
+	brainpoolCSCA := newTestCurveSigner(t, newTestCurveKey(t, standardCurves[16]), nil, nil, 5, notAfter)
+	brainpoolDS := newTestCurveSigner(t, newTestCurveKey(t, standardCurves[13]),
+		oidTestBrainpoolP256r1, brainpoolCSCA, 6, notAfter)
+	mixedDS := newTestCurveSigner(t, newTestCurveKey(t, standardCurves[13]), nil, ecCSCA, 7, notAfter)
+	dg1, err := hex.DecodeString(mrzToDg1(testMRZ))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		ds        *testSigner
+		hash      crypto.Hash
+		algorithm string
+	}{
+		{name: "ECDSA SHA-256", ds: ecDS, hash: crypto.SHA256, algorithm: "SHA256withECDSA"},
+		{name: "ECDSA SHA-1", ds: ecDS, hash: crypto.SHA1, algorithm: "SHA1withECDSA"},
+		{name: "RSA SHA-256", ds: rsaDS, hash: crypto.SHA256, algorithm: "SHA256withRSA"},
+		{name: "RSA SHA-512", ds: rsaDS, hash: crypto.SHA512, algorithm: "SHA512withRSA"},
+		{name: "Brainpool CSCA", ds: brainpoolDS, hash: crypto.SHA256, algorithm: "SHA256withECDSA"},
+		{name: "Brainpool Document Signer", ds: mixedDS, hash: crypto.SHA384, algorithm: "SHA384withECDSA"},
+	}
+
+	ts := NewTrustStore()
+	ts.AddCSCA(ecCSCA.cert, rsaCSCA.cert, brainpoolCSCA.cert)
+	ts.AddCRL(newTestCRL(t, ecCSCA, 9), newTestCRL(t, rsaCSCA), newTestCRL(t, brainpoolCSCA))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sod, err := parseSOD(newTestSOD(t, tt.ds, tt.hash, map[int][]byte{1: dg1}))
+			require.NoError(t, err)
+
+			report := ts.VerifySOD(sod, verificationTime)
+			require.NoError(t, report.Err())
+			require.True(t, report.Passed())
+			require.True(t, report.SignatureValid)
+			require.True(t, report.ChainValid)
+			require.True(t, report.ValidityValid)
+			require.True(t, report.KeyUsageValid)
+			require.True(t, report.RevocationChecked)
+			require.False(t, report.Revoked)
+			require.Equal(t, tt.algorithm, report.SignatureAlgorithm)
+			require.Len(t, report.Chain, 2)
+			require.Equal(t, tt.ds.cert.NotAfter, report.NotAfter)
+		})
+	}
+}
+
+func TestTrustStore_VerifySOD_Failures(t *testing.T) {
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	csca := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	ds := newTestSigner(t, newTestECKey(t), csca, 2, notAfter)
+	otherCSCA := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	dg1, err := hex.DecodeString(mrzToDg1(testMRZ))
+	require.NoError(t, err)
+	sodRaw := newTestSOD(t, ds, crypto.SHA256, map[int][]byte{1: dg1})
+
+	t.Run("Untrusted CSCA", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(otherCSCA.cert)
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.False(t, report.Passed())
+		require.True(t, report.SignatureValid)
+		require.False(t, report.ChainValid)
+		require.ErrorIs(t, report.Err(), ErrChainInvalid)
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		sod.Signature[len(sod.Signature)-1] ^= 0xFF
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.False(t, report.SignatureValid)
+		require.True(t, report.ChainValid)
+		require.ErrorIs(t, report.Err(), ErrSignatureInvalid)
+	})
+
+	t.Run("Tampered eContent", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		sod.EContent[len(sod.EContent)-1] ^= 0xFF
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.ErrorIs(t, report.Err(), ErrSignatureInvalid)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+
+		report := ts.VerifySOD(sod, time.Date(2041, 1, 1, 0, 0, 0, 0, time.UTC))
+		require.False(t, report.ValidityValid)
+		require.ErrorIs(t, report.Err(), ErrCertificateExpired)
+	})
+
+	t.Run("No CRL", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		ts.AddCRL(newTestCRL(t, otherCSCA))
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.False(t, report.Passed())
+		require.True(t, report.SignatureValid)
+		require.True(t, report.ChainValid)
+		require.False(t, report.RevocationChecked)
+		require.ErrorIs(t, report.Err(), ErrRevocationUnknown)
+	})
+
+	t.Run("Stale CRL", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		ts.AddCRL(newTestCRLAt(t, csca,
+			time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.False(t, report.Passed())
+		require.False(t, report.RevocationChecked)
+		require.ErrorIs(t, report.Err(), ErrRevocationUnknown)
+	})
+
+	t.Run("Key usage", func(t *testing.T) {
+		key := newTestECKey(t)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(3),
+			Subject:      pkix.Name{Country: []string{"UTO"}, CommonName: "Test Document Signer"},
+			NotBefore:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     notAfter,
+			KeyUsage:     x509.KeyUsageCertSign,
+		}, csca.cert, key.Public(), csca.key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		sod, err := parseSOD(newTestSOD(t, &testSigner{key: key, cert: cert},
+			crypto.SHA256, map[int][]byte{1: dg1}))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		ts.AddCRL(newTestCRL(t, csca))
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.False(t, report.Passed())
+		require.True(t, report.SignatureValid)
+		require.True(t, report.ChainValid)
+		require.False(t, report.KeyUsageValid)
+		require.ErrorIs(t, report.Err(), ErrKeyUsage)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		ts.AddCRL(newTestCRL(t, otherCSCA, 2), newTestCRL(t, csca, 7, 2))
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.True(t, report.RevocationChecked)
+		require.True(t, report.Revoked)
+		require.ErrorIs(t, report.Err(), ErrCertificateRevoked)
+	})
+
+	t.Run("Not revoked", func(t *testing.T) {
+		sod, err := parseSOD(bytes.Clone(sodRaw))
+		require.NoError(t, err)
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		ts.AddCRL(newTestCRL(t, csca, 7))
+
+		report := ts.VerifySOD(sod, verificationTime)
+		require.True(t, report.Passed())
+		require.True(t, report.RevocationChecked)
+		require.False(t, report.Revoked)
+	})
+}
+
+func TestTrustStore_LoadFiles(t *testing.T) {
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	csca := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	otherCSCA := newTestSigner(t, newTestECKey(t), nil, 5, notAfter)
+	brainpoolCSCA := newTestCurveSigner(t, newTestCurveKey(t, standardCurves[17]), nil, nil, 6, notAfter)
+	mls := newTestSigner(t, newTestECKey(t), csca, 7, notAfter)
+	dir := t.TempDir()
+
+	pemPath := filepath.Join(dir, "csca.pem")
+	pemData := append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: csca.cert.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: brainpoolCSCA.cert.Raw})...,
+	)
+	require.NoError(t, os.WriteFile(pemPath, pemData, 0o600))
+
+	mlPath := filepath.Join(dir, "masterlist.ml")
+	ml := newTestMasterList(t, mls, otherCSCA.cert, brainpoolCSCA.cert)
+	require.NoError(t, os.WriteFile(mlPath, ml, 0o600))
+
+	crlPath := filepath.Join(dir, "csca.crl")
+	require.NoError(t, os.WriteFile(crlPath, newTestCRL(t, csca, 2).Raw, 0o600))
+
+	t.Run("PEM", func(t *testing.T) {
+		ts := NewTrustStore()
+		require.NoError(t, ts.LoadCSCAFile(pemPath))
+		require.Len(t, ts.cscas, 2)
+		require.Equal(t, csca.cert.Raw, ts.cscas[0].Raw)
+		require.Equal(t, brainpoolCSCA.cert.PublicKey, ts.cscas[1].PublicKey)
+	})
+
+	t.Run("Master list", func(t *testing.T) {
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		require.NoError(t, ts.LoadCSCAFile(mlPath))
+		require.Len(t, ts.cscas, 3)
+		// DER sorts SET OF elements, the master list order is not preserved
+		require.ElementsMatch(t,
+			[][]byte{otherCSCA.cert.Raw, brainpoolCSCA.cert.Raw},
+			[][]byte{ts.cscas[1].Raw, ts.cscas[2].Raw})
+	})
+
+	t.Run("Master list signer not trusted", func(t *testing.T) {
+		ts := NewTrustStore()
+		ts.AddCSCA(otherCSCA.cert)
+		require.ErrorIs(t, ts.LoadCSCAFile(mlPath), ErrChainInvalid)
+		require.Len(t, ts.cscas, 1)
+	})
+
+	t.Run("Master list signature invalid", func(t *testing.T) {
+		tampered := bytes.Clone(ml)
+		tampered[bytes.Index(tampered, otherCSCA.cert.Raw)+len(otherCSCA.cert.Raw)-1] ^= 0xFF
+		ts := NewTrustStore()
+		ts.AddCSCA(csca.cert)
+		require.ErrorIs(t, ts.LoadMasterList(tampered), ErrSignatureInvalid)
+		require.Len(t, ts.cscas, 1)
+	})
+
+	t.Run("Master list unverified", func(t *testing.T) {
+		ts := NewTrustStore()
+		require.NoError(t, ts.LoadMasterListUnverified(ml))
+		require.Len(t, ts.cscas, 2)
+	})
+
+	ts := NewTrustStore()
+	require.NoError(t, ts.LoadCRLFile(crlPath))
+	require.Len(t, ts.crls, 1)
+
+	require.Error(t, ts.LoadCSCAFile(filepath.Join(dir, "missing.pem")))
+	require.Error(t, ts.LoadCSCAFile(crlPath))
+	require.Error(t, ts.LoadPEM([]byte("-----BEGIN PUBLIC KEY-----\n-----END PUBLIC KEY-----\n")))
+}
+
+func TestVerifySignature_RSAPSS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	data := []byte("signed attributes")
+	digest := crypto.SHA256.New()
+	digest.Write(data)
+	signature, err := rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest.Sum(nil),
+		&rsa.PSSOptions{SaltLength: 32})
+	require.NoError(t, err)
+
+	sha256Algorithm := pkix.AlgorithmIdentifier{
+		Algorithm:  oidSHA256,
+		Parameters: asn1.NullRawValue,
+	}
+	params := mustMarshal(t, pssParameters{
+		Hash: sha256Algorithm,
+		MGF: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8},
+			Parameters: asn1.RawValue{FullBytes: mustMarshal(t, sha256Algorithm, "")},
+		},
+		SaltLength:   32,
+		TrailerField: 1,
+	}, "")
+	algorithm := pkix.AlgorithmIdentifier{
+		Algorithm:  oidRSASSAPSS,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}
+
+	name, err := verifySignature(&key.PublicKey, algorithm, crypto.SHA1, data, signature)
+	require.NoError(t, err)
+	require.Equal(t, "SHA256withRSA/PSS", name)
+
+	_, err = verifySignature(&key.PublicKey, algorithm, crypto.SHA1, []byte("other"), signature)
+	require.Error(t, err)
+
+	_, err = verifySignature(&key.PublicKey,
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 3}},
+		crypto.SHA256, data, signature)
+	require.Error(t, err)
+}
@@ -2,16 +2,20 @@ package passport
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/0xPolygonID/go-circuit-external/common"
 	"github.com/0xPolygonID/go-circuit-external/template"
 	basicPerson "github.com/0xPolygonID/go-circuit-external/template/templates/basicPersonV1_43"
+	"github.com/iden3/go-schema-processor/v2/merklize"
 	"github.com/iden3/go-schema-processor/v2/verifiable"
 )
 
@@ -53,16 +57,65 @@ var (
 
 type PassportV1Inputs struct {
 	PassportData string `json:"passportData"`
+	// Printed MRZ text, e.g. from OCR of the data page. Used instead of PassportData
+	// when the chip can not be read. The DG1 is built from it, see Source.
+	MRZ string `json:"mrz,omitempty"`
 	// Generated on mobile app values
 	CredentialSubjectID             string `json:"credentialSubjectID"`             // credentialSubject.id
 	CredentialStatusRevocationNonce int    `json:"credentialStatusRevocationNonce"` // credentialStatus.revocationNonce
 	CredentialStatusID              string `json:"credentialStatusID"`              // credentialStatus.id
 	IssuanceDate                    int64  `json:"issuanceDate"`                    // unix timestamp
-	LinkNonce                       string `json:"linkNonce"`
+	LinkNonce                       string `json:"linkNonce"`                       // see common.NewLinkNonce
 	// Mobile dynamic values with Firebase config
 	IssuerID string `json:"issuerID"` // issuer
+	// StrictMode enables check digit validation of the DG1. It is an issuer
+	// setting and is never read from JSON, so clients can not disable it.
+	StrictMode bool `json:"-"`
+	// Optional hex encoded EF.SOD. When set, DG1 is checked against its hash
+	SOD string `json:"sod,omitempty"`
+	// Optional hex encoded DG2 with the portrait of the holder
+	DG2 string `json:"dg2,omitempty"`
+	// Optional hex encoded DG14 and DG15 with the public keys of the chip. When SOD is
+	// set, they are checked against its hashes
+	DG14 string `json:"dg14,omitempty"`
+	DG15 string `json:"dg15,omitempty"`
+	// Optional hex encoded Active Authentication signature of the challenge chosen
+	// by the issuer, see VerifyActiveAuthentication
+	AASignature string `json:"aaSignature,omitempty"`
+	// DatePolicy configures the checks of the MRZ date of birth. It is an issuer
+	// setting and is never read from JSON, so clients can not relax it.
+	DatePolicy DatePolicy `json:"-"`
+	// ValidityPolicy configures the document expiry checks. It is an issuer
+	// setting and is never read from JSON, so clients can not relax it.
+	ValidityPolicy ValidityPolicy `json:"-"`
+
+	// AcceptSpecimenCountries accepts the codes of ICAO specimen documents such as
+	// "UTO" for integration tests with specimen passports. It is never read from
+	// JSON, so clients can not enable it.
+	AcceptSpecimenCountries bool `json:"-"`
 }
 
+// MRZCredentialType is added to the types of credentials built from the
+// printed MRZ instead of the chip, see Source. The marker only describes
+// honest clients: a holder can encode the printed MRZ as a DG1 and send it
+// as PassportData, and the credential then does not have the type. Only a
+// SOD that passes passive authentication, see TrustStore.VerifySOD, shows
+// that the data was read from a chip.
+const MRZCredentialType = "https://github.com/0xPolygonID/go-circuit-external/passport#MRZCredential"
+
+// rdfType is the merklized path of the credential types.
+const rdfType = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+
+// ErrUnsupportedFormat is returned for documents other than TD3 passports.
+// The circuit takes the 93 bytes DG1 of the TD3 layout with short form
+// lengths, while TD1 and TD2 documents and visas have a DG1 of a different
+// size and layout.
+var ErrUnsupportedFormat = errors.New("document format is not supported by the circuit")
+
+// circuitDG1Size is the size of the TD3 DG1 taken by the circuit:
+// 0x61 0x5B 0x5F1F 0x58 MRZ.
+const circuitDG1Size = 5 + td3Length
+
 type anonAadhaarV1CircuitInputs struct {
 	DG1                 []int      `json:"dg1"`
 	HolderNameSize      int        `json:"holderNameSize"`
@@ -79,29 +132,25 @@ type anonAadhaarV1CircuitInputs struct {
 }
 
 func (a *PassportV1Inputs) W3CCredential() (*verifiable.W3CCredential, error) {
-	dg1, err := ParseDG1(a.PassportData)
+	dg1, err := a.parseCircuitDG1()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DG1: %w", err)
+		return nil, err
 	}
 
 	timeNow := time.Unix(a.IssuanceDate, 0).UTC()
-	dobTime, doeTime, err := convertData(
-		timeNow,
-		dg1.DateOfBirth,
-		dg1.DateOfExpiry,
-	)
+	dobTime, doeTime, err := a.convertDates(timeNow, dg1)
 	if err != nil {
-		return nil,
-			fmt.Errorf(
-				"failed to convert data dob '%s', doe '%s': %w",
-				dg1.DateOfBirth, dg1.DateOfExpiry, err)
+		return nil, err
+	}
+	if err = a.ValidityPolicy.checkDocument(timeNow, doeTime); err != nil {
+		return nil, err
 	}
 	credentialExpirationTime := calculateExpirationDate(doeTime, timeNow)
 
 	credentialSubject := map[string]interface{}{
 		"dateOfBirth":              common.TimeToInt(dobTime),
 		"documentExpirationDate":   common.TimeToInt(doeTime),
-		"fullName":                 dg1.HolderName,
+		"fullName":                 circuitFullName(dg1),
 		"governmentIdentifier":     dg1.DocumentNumber,
 		"governmentIdentifierType": dg1.DocumentType,
 		"sex":                      dg1.Sex,
@@ -117,12 +166,20 @@ func (a *PassportV1Inputs) W3CCredential() (*verifiable.W3CCredential, error) {
 		RevocationNonce: uint64(a.CredentialStatusRevocationNonce),
 		Type:            verifiable.Iden3OnchainSparseMerkleTreeProof2023,
 	}
+	options := []func(*verifiable.W3CCredential){
+		basicPerson.WithIssuanceDate(timeNow),
+		basicPerson.WithExpiration(credentialExpirationTime),
+	}
+	if a.Source() == SourceMRZ {
+		options = append(options, func(vc *verifiable.W3CCredential) {
+			vc.Type = append(vc.Type, MRZCredentialType)
+		})
+	}
 	vc, err := basicPerson.BuildBasicPersonV1_43Credential(
 		credentialSubject,
 		credentialRevocation,
 		a.IssuerID,
-		basicPerson.WithIssuanceDate(timeNow),
-		basicPerson.WithExpiration(credentialExpirationTime),
+		options...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build credential: %w", err)
@@ -132,35 +189,26 @@ func (a *PassportV1Inputs) W3CCredential() (*verifiable.W3CCredential, error) {
 
 func (a *PassportV1Inputs) InputsMarshal() ([]byte, error) {
 	ctx := context.TODO()
-	tmpl, err := template.New(templateSize)
+	tmpl, err := a.newTemplate(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create template: %w", err)
-	}
-	err = tmpl.Upload(ctx, basicPerson.BasicPersonV1_43)
-	if err != nil {
-		return nil, fmt.Errorf("failed to upload template 'BasicPersonV1_43': %w", err)
-	}
-	err = tmpl.Upload(ctx, passportTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to upload template 'PassportV1Template': %w", err)
+		return nil, err
 	}
 	templateRoot := tmpl.Root()
 
-	dg1, err := ParseDG1(a.PassportData)
+	dg1, err := a.parseCircuitDG1()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DG1: %w", err)
+		return nil, err
 	}
 
 	timeNow := time.Unix(a.IssuanceDate, 0).UTC()
-	dobTime, doeTime, err := convertData(
-		timeNow,
-		dg1.DateOfBirth,
-		dg1.DateOfExpiry,
-	)
+	dobTime, doeTime, err := a.convertDates(timeNow, dg1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert data: %w", err)
+		return nil, err
 	}
 
+	if err = a.ValidityPolicy.checkDocument(timeNow, doeTime); err != nil {
+		return nil, err
+	}
 	credentialExpirationTime := calculateExpirationDate(doeTime, timeNow)
 
 	// List of values to hash
@@ -168,7 +216,7 @@ func (a *PassportV1Inputs) InputsMarshal() ([]byte, error) {
 		value string
 		dest  **big.Int
 	}{
-		{dg1.HolderName, new(*big.Int)},
+		{circuitFullName(dg1), new(*big.Int)},
 		{dg1.DocumentNumber, new(*big.Int)},
 		{dg1.DocumentType, new(*big.Int)},
 		{string(dg1.Sex), new(*big.Int)},
@@ -198,7 +246,7 @@ func (a *PassportV1Inputs) InputsMarshal() ([]byte, error) {
 	credentialSubjetID := *valuesToHash[7].dest
 	issuer := *valuesToHash[8].dest
 
-	siblings, err := tmpl.Update(ctx, []template.Node{
+	nodes := []template.Node{
 		{
 			basicPerson.DateOfBirth,
 			big.NewInt(int64(common.TimeToInt(dobTime))),
@@ -219,7 +267,9 @@ func (a *PassportV1Inputs) InputsMarshal() ([]byte, error) {
 		{basicPerson.Issuer, issuer},
 		{basicPerson.DocumentNationality, notionalityHash},
 		{basicPerson.DocumentIssuer, issuingCountryHash},
-	})
+	}
+
+	siblings, err := tmpl.Update(ctx, nodes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update template: %w", err)
 	}
@@ -231,7 +281,7 @@ func (a *PassportV1Inputs) InputsMarshal() ([]byte, error) {
 
 	inputs := anonAadhaarV1CircuitInputs{
 		DG1:                 toIntsArray(dg1.Raw),
-		HolderNameSize:      len(dg1.HolderName),
+		HolderNameSize:      len(circuitFullName(dg1)),
 		CurrentDate:         timeNow.Format("060102"),
 		RevocationNonce:     a.CredentialStatusRevocationNonce,
 		CredentialStatusID:  credentialStatusID.String(),
@@ -252,6 +302,247 @@ func (a *PassportV1Inputs) InputsMarshal() ([]byte, error) {
 	return jsonBytes, nil
 }
 
+// newTemplate builds the template tree with the keys of the enabled claims.
+func (a *PassportV1Inputs) newTemplate(ctx context.Context) (*template.Template, error) {
+	tmpl, err := template.New(templateSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	staticNodes := basicPerson.BasicPersonV1_43
+	if a.Source() == SourceMRZ {
+		staticNodes, err = mrzCredentialTemplate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = tmpl.Upload(ctx, staticNodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload template 'BasicPersonV1_43': %w", err)
+	}
+	err = tmpl.Upload(ctx, passportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload template 'PassportV1Template': %w", err)
+	}
+	return tmpl, nil
+}
+
+// mrzCredentialTemplate returns BasicPersonV1_43 with the types of
+// a credential built from the MRZ. The types are merklized in IRI order,
+// so MRZCredentialType comes before VerifiableCredential and BasicPerson.
+func mrzCredentialTemplate() ([]template.Node, error) {
+	typeKeys := make([]*big.Int, 3)
+	for i := range typeKeys {
+		path, err := merklize.NewPath(rdfType, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create type path: %w", err)
+		}
+		if typeKeys[i], err = path.MtEntry(); err != nil {
+			return nil, fmt.Errorf("failed to hash type path: %w", err)
+		}
+	}
+	mrzType, err := common.HashValue(MRZCredentialType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash value '%s': %w", MRZCredentialType, err)
+	}
+
+	types := []*big.Int{mrzType, nil, nil}
+	nodes := make([]template.Node, 0, len(basicPerson.BasicPersonV1_43)+1)
+	for _, node := range basicPerson.BasicPersonV1_43 {
+		switch node.Key.String() {
+		case typeKeys[0].String():
+			types[1] = node.Value
+		case typeKeys[1].String():
+			types[2] = node.Value
+		default:
+			nodes = append(nodes, node)
+		}
+	}
+	for i, value := range types {
+		if value == nil {
+			return nil, errors.New("credential types are missing in template 'BasicPersonV1_43'")
+		}
+		nodes = append(nodes, template.Node{Key: typeKeys[i], Value: value})
+	}
+	return nodes, nil
+}
+
+// convertDates resolves the dates of birth and expiry and checks them with
+// DatePolicy.
+func (a *PassportV1Inputs) convertDates(today time.Time, dg1 *Passport) (dob, doe time.Time, err error) {
+	dob, doe, err = convertData(today, dg1.DateOfBirth, dg1.DateOfExpiry, a.DatePolicy)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"failed to convert data dob '%s', doe '%s': %w", dg1.DateOfBirth, dg1.DateOfExpiry, err)
+	}
+	return dob, doe, nil
+}
+
+// parseCircuitDG1 parses DG1 and checks that the circuit can prove it, so
+// that no credential is issued without provable inputs.
+func (a *PassportV1Inputs) parseCircuitDG1() (*Passport, error) {
+	dg1, err := a.parseDG1()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DG1: %w", err)
+	}
+	if dg1.Format != FormatTD3 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, dg1.Format)
+	}
+	if len(dg1.Raw) != circuitDG1Size {
+		return nil, fmt.Errorf("%w: DG1 of %d bytes, expected %d",
+			ErrUnsupportedFormat, len(dg1.Raw), circuitDG1Size)
+	}
+	return dg1, nil
+}
+
+func (a *PassportV1Inputs) parseDG1() (*Passport, error) {
+	var opts []ParseOption
+	if a.StrictMode {
+		opts = append(opts, WithStrictMode())
+	}
+	var (
+		dg1 *Passport
+		err error
+	)
+	switch {
+	case a.MRZ != "" && a.PassportData != "":
+		return nil, errors.New("only one of passportData and mrz can be set")
+	case a.MRZ != "":
+		dg1, err = ParseMRZ(a.MRZ)
+	default:
+		dg1, err = ParseDG1(a.PassportData, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = validateCountries(dg1, a.AcceptSpecimenCountries); err != nil {
+		return nil, err
+	}
+
+	sod, err := a.parseSOD()
+	if err != nil {
+		return nil, err
+	}
+	if sod != nil {
+		if err := sod.VerifyDataGroup(1, dg1.Raw); err != nil {
+			return nil, fmt.Errorf("failed to verify DG1 against SOD: %w", err)
+		}
+	}
+
+	return dg1, nil
+}
+
+// validateCountries checks the issuing state and the nationality codes
+// against the country table.
+func validateCountries(dg1 *Passport, specimen bool) error {
+	var opts []common.LookupOption
+	if specimen {
+		opts = append(opts, common.WithSpecimenCountries())
+	}
+	if _, err := common.LookupCountry(dg1.IssuingCountry, opts...); err != nil {
+		return fmt.Errorf("invalid issuing country: %w", err)
+	}
+	if _, err := common.LookupCountry(dg1.Nationality, opts...); err != nil {
+		return fmt.Errorf("invalid nationality: %w", err)
+	}
+	return nil
+}
+
+// Source reports whether the credential data is read from the chip or
+// built from the printed MRZ. Credentials built from the MRZ have the
+// MRZCredentialType type, the other claims and the DG1 circuit input are
+// the same in both cases. SourceChip is what the client claims, it is not
+// evidence of a chip read, see MRZCredentialType.
+func (a *PassportV1Inputs) Source() DataSource {
+	if a.MRZ != "" {
+		return SourceMRZ
+	}
+	return SourceChip
+}
+
+func (a *PassportV1Inputs) parseSOD() (*SOD, error) {
+	if a.SOD == "" {
+		return nil, nil
+	}
+	sod, err := ParseSOD(a.SOD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SOD: %w", err)
+	}
+	return sod, nil
+}
+
+// Portrait parses DG2 and returns the first face image of the holder.
+// When SOD is set, DG2 is checked against its hash.
+func (a *PassportV1Inputs) Portrait() (*FaceImage, error) {
+	if a.DG2 == "" {
+		return nil, errors.New("DG2 is not provided")
+	}
+	dg2, err := ParseDG2(a.DG2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DG2: %w", err)
+	}
+	sod, err := a.parseSOD()
+	if err != nil {
+		return nil, err
+	}
+	if sod != nil {
+		if err = sod.VerifyDataGroup(2, dg2.Raw); err != nil {
+			return nil, fmt.Errorf("failed to verify DG2 against SOD: %w", err)
+		}
+	}
+	return &dg2.Faces[0], nil
+}
+
+// VerifyActiveAuthentication checks that the chip signed the challenge with
+// the private key of DG15, which rules out a cloned chip. The challenge must
+// be generated randomly by the issuer for this session and never taken from
+// the client, otherwise a recorded challenge and signature can be replayed.
+// DG15 is trusted only through its SOD hash, so SOD is required and should
+// pass passive authentication, see TrustStore.VerifySOD. DG14 is required for
+// EC keys.
+func (a *PassportV1Inputs) VerifyActiveAuthentication(challenge []byte) error {
+	if a.DG15 == "" || a.AASignature == "" {
+		return errors.New("DG15 and AA signature are required")
+	}
+	sod, err := a.parseSOD()
+	if err != nil {
+		return err
+	}
+	if sod == nil {
+		return errors.New("SOD is required to trust the DG15 public key")
+	}
+
+	dg15, err := ParseDG15(a.DG15)
+	if err != nil {
+		return fmt.Errorf("failed to parse DG15: %w", err)
+	}
+	if err = sod.VerifyDataGroup(15, dg15.Raw); err != nil {
+		return fmt.Errorf("failed to verify DG15 against SOD: %w", err)
+	}
+	var dg14 *DG14
+	if a.DG14 != "" {
+		if dg14, err = ParseDG14(a.DG14); err != nil {
+			return fmt.Errorf("failed to parse DG14: %w", err)
+		}
+		if err = sod.VerifyDataGroup(14, dg14.Raw); err != nil {
+			return fmt.Errorf("failed to verify DG14 against SOD: %w", err)
+		}
+	}
+
+	signature, err := hex.DecodeString(a.AASignature)
+	if err != nil {
+		return fmt.Errorf("invalid AA signature: %w", err)
+	}
+	return VerifyActiveAuthentication(dg15, dg14, challenge, signature)
+}
+
+// circuitFullName returns the fullName claim as the circuit derives it from
+// the name field of DG1: every filler is replaced by a space, so the surname
+// and the given names are separated by two spaces unlike in HolderName.
+func circuitFullName(dg1 *Passport) string {
+	name := nameFields[dg1.Format]
+	return strings.TrimSpace(strings.ReplaceAll(dg1.mrz[name.start:name.end], "<", " "))
+}
+
 func toIntsArray(b []byte) []int {
 	out := make([]int, len(b))
 	for i := range b {
@@ -1,11 +1,29 @@
 package passport
 
 import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/0xPolygonID/go-circuit-external/common"
+	"github.com/0xPolygonID/go-circuit-external/template"
+	basicPerson "github.com/0xPolygonID/go-circuit-external/template/templates/basicPersonV1_43"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
 	"github.com/stretchr/testify/require"
 )
 
@@ -116,3 +134,446 @@ func TestInputsUnmarshal(t *testing.T) {
 
 	require.Equal(t, expected, *signals)
 }
+
+func TestW3CCredential_TD1(t *testing.T) {
+	issuanceDate, err := time.Parse(time.RFC3339Nano, "2025-03-21T17:28:52.201289Z")
+	require.NoError(t, err)
+
+	inputs := PassportV1Inputs{
+		PassportData: mrzToDg1(
+			"I<UTOD231458907<<<<<<<<<<<<<<<" +
+				"7408122F3104159UTO<<<<<<<<<<<4" +
+				"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+		),
+		IssuerID:                        "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID:             "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusRevocationNonce: int(time.Unix(1257894000, 0).Unix()),
+		CredentialStatusID:              "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G/credentialStatus?contractAddress=80001:0x2fCE183c7Fbc4EbB5DB3B0F5a63e0e02AE9a85d2&state=a1abdb9f44c7b649eb4d21b59ef34bd38e054aa3e500987575a14fc92c49f42c",
+		IssuanceDate:                    issuanceDate.UTC().Unix(),
+		LinkNonce:                       "1",
+		AcceptSpecimenCountries:         true,
+	}
+
+	// The circuit takes TD3 documents only, so no credential is issued.
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestW3CCredential_LongFormDG1(t *testing.T) {
+	// The same TD3 MRZ in an envelope with long form lengths.
+	dg1 := append([]byte{0x61, 0x81, 0x5C, 0x5F, 0x1F, 0x81, 0x58}, testMRZ...)
+	passport, err := ParseDG1(hex.EncodeToString(dg1))
+	require.NoError(t, err)
+	require.Equal(t, FormatTD3, passport.Format)
+
+	inputs := PassportV1Inputs{
+		PassportData:        hex.EncodeToString(dg1),
+		IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusID:  "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		IssuanceDate:        time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		LinkNonce:           "1",
+	}
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestPassportV1Inputs_VerifyActiveAuthentication(t *testing.T) {
+	ds := newTestDocumentSigner(t)
+	dg1Hex := mrzToDg1(testMRZ)
+	dg1, err := hex.DecodeString(dg1Hex)
+	require.NoError(t, err)
+
+	aaKey := newTestAAKey(t)
+	dg15 := newTestRSADG15(t, aaKey)
+	curve := standardCurves[13]
+	ecKey, ecPub, err := curve.generateKey(rand.Reader, curve.g)
+	require.NoError(t, err)
+	ecDG15, err := hex.DecodeString(testDataGroup(dg15Tag, testECPublicKeyInfo(t, curve, nil, ecPub)))
+	require.NoError(t, err)
+	dg14, err := hex.DecodeString(testDG14(t, testSecurityInfo(t, oidAA, 1, oidTestECDSAPlainSHA256)))
+	require.NoError(t, err)
+	hashed := sha256.Sum256(testAAChallenge)
+
+	newInputs := func() PassportV1Inputs {
+		return PassportV1Inputs{
+			PassportData: dg1Hex,
+			SOD: hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256,
+				map[int][]byte{1: dg1, 15: dg15.Raw})),
+			DG15:        hex.EncodeToString(dg15.Raw),
+			AASignature: hex.EncodeToString(signISO9796(t, aaKey, crypto.SHA1, 0, testAAChallenge)),
+		}
+	}
+
+	t.Run("RSA", func(t *testing.T) {
+		inputs := newInputs()
+		require.NoError(t, inputs.VerifyActiveAuthentication(testAAChallenge))
+
+		require.ErrorIs(t, inputs.VerifyActiveAuthentication(
+			[]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x09}), ErrActiveAuthentication)
+	})
+
+	t.Run("ECDSA", func(t *testing.T) {
+		inputs := newInputs()
+		inputs.SOD = hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256,
+			map[int][]byte{1: dg1, 14: dg14, 15: ecDG15}))
+		inputs.DG14 = hex.EncodeToString(dg14)
+		inputs.DG15 = hex.EncodeToString(ecDG15)
+		inputs.AASignature = hex.EncodeToString(signPlainECDSA(t, curve, ecKey, hashed[:]))
+		require.NoError(t, inputs.VerifyActiveAuthentication(testAAChallenge))
+
+		inputs.DG14 = ""
+		require.ErrorIs(t, inputs.VerifyActiveAuthentication(testAAChallenge), ErrActiveAuthentication)
+	})
+
+	t.Run("DG15 not in SOD", func(t *testing.T) {
+		inputs := newInputs()
+		inputs.SOD = hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256, map[int][]byte{1: dg1}))
+		require.ErrorIs(t, inputs.VerifyActiveAuthentication(testAAChallenge), ErrDataGroupHashMismatch)
+	})
+
+	t.Run("Cloned chip key", func(t *testing.T) {
+		inputs := newInputs()
+		clonedKey := newTestAAKey(t)
+		inputs.DG15 = hex.EncodeToString(newTestRSADG15(t, clonedKey).Raw)
+		inputs.AASignature = hex.EncodeToString(
+			signISO9796(t, clonedKey, crypto.SHA1, 0, testAAChallenge))
+		require.ErrorIs(t, inputs.VerifyActiveAuthentication(testAAChallenge), ErrDataGroupHashMismatch)
+	})
+
+	t.Run("Missing data", func(t *testing.T) {
+		inputs := newInputs()
+		inputs.SOD = ""
+		require.Error(t, inputs.VerifyActiveAuthentication(testAAChallenge))
+
+		inputs = newInputs()
+		inputs.AASignature = ""
+		require.Error(t, inputs.VerifyActiveAuthentication(testAAChallenge))
+
+		inputs = newInputs()
+		require.ErrorIs(t, inputs.VerifyActiveAuthentication(nil), ErrActiveAuthentication)
+	})
+}
+
+func TestInputsMarshal_MRZ(t *testing.T) {
+	mrz := "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<\n" +
+		"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<00"
+	chipInputs := PassportV1Inputs{
+		PassportData:                    mrzToDg1(strings.ReplaceAll(mrz, "\n", "")),
+		IssuerID:                        "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID:             "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusRevocationNonce: int(time.Unix(1257894000, 0).Unix()),
+		CredentialStatusID:              "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		IssuanceDate:                    time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		LinkNonce:                       "1",
+	}
+	mrzInputs := chipInputs
+	mrzInputs.PassportData = ""
+	mrzInputs.MRZ = mrz
+	require.Equal(t, SourceChip, chipInputs.Source())
+	require.Equal(t, SourceMRZ, mrzInputs.Source())
+
+	expected, err := chipInputs.InputsMarshal()
+	require.NoError(t, err)
+	inputsCircuit, err := mrzInputs.InputsMarshal()
+	require.NoError(t, err)
+	var chipCircuitInputs, mrzCircuitInputs map[string]interface{}
+	require.NoError(t, json.Unmarshal(expected, &chipCircuitInputs))
+	require.NoError(t, json.Unmarshal(inputsCircuit, &mrzCircuitInputs))
+	require.Equal(t, chipCircuitInputs["dg1"], mrzCircuitInputs["dg1"])
+	// the MRZ credential type changes the template
+	require.NotEqual(t, chipCircuitInputs["templateRoot"], mrzCircuitInputs["templateRoot"])
+
+	expectedCredential, err := chipInputs.W3CCredential()
+	require.NoError(t, err)
+	credential, err := mrzInputs.W3CCredential()
+	require.NoError(t, err)
+	require.Equal(t, expectedCredential.CredentialSubject, credential.CredentialSubject)
+	require.NotContains(t, expectedCredential.Type, MRZCredentialType)
+	require.Equal(t, append(expectedCredential.Type, MRZCredentialType), credential.Type)
+
+	mrzInputs.PassportData = chipInputs.PassportData
+	_, err = mrzInputs.InputsMarshal()
+	require.Error(t, err)
+}
+
+func TestMRZCredentialTemplate(t *testing.T) {
+	nodes, err := mrzCredentialTemplate()
+	require.NoError(t, err)
+	require.Len(t, nodes, len(basicPerson.BasicPersonV1_43)+1)
+
+	mrzType, err := common.HashValue(MRZCredentialType)
+	require.NoError(t, err)
+	types := map[string]string{}
+	for _, node := range nodes {
+		types[node.Key.String()] = node.Value.String()
+	}
+	// type keys and values of BasicPersonV1_43 move one index up
+	require.Equal(t, mrzType.String(),
+		types["14122086068848155444790679436566779517121339700977110548919573157521629996400"])
+	require.Equal(t,
+		"8932896889521641034417268999369968324098807262074941120983759052810017489370",
+		types["18943208076435454904128050626016920086499867123501959273334294100443438004188"])
+	require.Contains(t, nodes, template.NewNode(
+		"14229423645570821103364981263422134295725145210767336426921970830491746839852",
+		"3930329666255035859341917616531724337843722428795107776052883525249467734017",
+	))
+}
+
+// testIPFSClient serves IPFS documents from memory.
+type testIPFSClient map[string][]byte
+
+func (c testIPFSClient) Cat(url string) (io.ReadCloser, error) {
+	doc, ok := c[url]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(doc)), nil
+}
+
+// testDocumentLoader serves the JSON-LD contexts of the credentials from
+// testdata. The BasicPerson context is not available offline, so it is
+// replaced by a stand-in with another vocabulary.
+func testDocumentLoader(t *testing.T) merklize.MerklizeOption {
+	t.Helper()
+	readFile := func(name string) []byte {
+		data, err := os.ReadFile("testdata/jsonld/" + name)
+		require.NoError(t, err)
+		return data
+	}
+	cache, err := loaders.NewMemoryCacheEngine(
+		loaders.WithEmbeddedDocumentBytes(verifiable.JSONLDSchemaW3CCredential2018,
+			readFile("credentials-v1.jsonld")),
+		loaders.WithEmbeddedDocumentBytes(verifiable.JSONLDSchemaIden3Credential,
+			readFile("iden3proofs.jsonld")),
+	)
+	require.NoError(t, err)
+	ipfs := testIPFSClient{
+		strings.TrimPrefix(basicPerson.BasicPersonV1_43_JSON_LD, "ipfs://"): readFile("basic-person.jsonld"),
+	}
+	return merklize.WithDocumentLoader(
+		loaders.NewDocumentLoader(ipfs, "", loaders.WithCacheEngine(cache)))
+}
+
+func TestMRZCredentialTemplate_Merklized(t *testing.T) {
+	inputs := PassportV1Inputs{
+		MRZ: "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<\n" +
+			"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<00",
+		IssuerID:                        "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID:             "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusRevocationNonce: 1257894000,
+		CredentialStatusID:              "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L/credentialStatus",
+		IssuanceDate:                    time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		LinkNonce:                       "1",
+	}
+	credential, err := inputs.W3CCredential()
+	require.NoError(t, err)
+	circuitInputs, err := inputs.InputsMarshal()
+	require.NoError(t, err)
+	var signals anonAadhaarV1CircuitInputs
+	require.NoError(t, json.Unmarshal(circuitInputs, &signals))
+
+	ctx := context.Background()
+	mt, err := merkletree.NewMerkleTree(ctx, memory.NewMemoryStorage(), 40)
+	require.NoError(t, err)
+	_, err = credential.Merklize(ctx, testDocumentLoader(t),
+		merklize.WithMerkleTree(merklize.MerkleTreeSQLAdapter(mt)))
+	require.NoError(t, err)
+	requireNode := func(key, value *big.Int) {
+		t.Helper()
+		_, actual, _, err := mt.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, value.String(), actual.String(), "node %s", key)
+	}
+
+	// The static nodes match the merklized credential, except for the
+	// BasicPerson type replaced by the stand-in context.
+	basicPersonType := basicPerson.BasicPersonV1_43[0].Value
+	standInType, err := common.HashValue("urn:uuid:00000000-0000-0000-0000-000000000000")
+	require.NoError(t, err)
+	nodes, err := mrzCredentialTemplate()
+	require.NoError(t, err)
+	for _, node := range nodes {
+		if node.Value.Cmp(basicPersonType) == 0 {
+			requireNode(node.Key, standInType)
+			continue
+		}
+		requireNode(node.Key, node.Value)
+	}
+
+	// The nodes computed by the circuit from the inputs match too.
+	issuanceDate := time.Unix(signals.IssuanceDate.Int64(), 0)
+	for _, node := range []struct {
+		key   *big.Int
+		value string
+	}{
+		{basicPerson.Issuer, signals.Issuer},
+		{basicPerson.CredentialStatusID, signals.CredentialStatusID},
+		{basicPerson.CredentialSubjectID, signals.CredentialSubjectID},
+		{basicPerson.RevocationNonce, big.NewInt(int64(signals.RevocationNonce)).String()},
+		{basicPerson.IssuanceDate, common.TimeToUnixNano(issuanceDate).String()},
+		{basicPerson.ExpirationDate, common.TimeToUnixNano(issuanceDate.AddDate(1, 0, 0)).String()},
+	} {
+		requireNode(node.key, common.MustBigInt(node.value))
+	}
+}
+
+func TestInputsMarshal_DatePolicy(t *testing.T) {
+	inputs := PassportV1Inputs{
+		PassportData:        mrzToDg1(testMRZ),
+		IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusID:  "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		IssuanceDate:        time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		LinkNonce:           "1",
+		DatePolicy:          DatePolicy{MaxAge: 120},
+	}
+	_, err := inputs.InputsMarshal()
+	require.NoError(t, err)
+
+	inputs.DatePolicy.MaxAge = 20
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrImplausibleDateOfBirth)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrImplausibleDateOfBirth)
+
+	inputs.DatePolicy.MaxAge = -1
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrInvalidDatePolicy)
+
+	inputs.PassportData = mrzToDg1(strings.Replace(testMRZ, "960309", "9603<<", 1))
+	inputs.DatePolicy = DatePolicy{}
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrUnknownDate)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrUnknownDate)
+}
+
+func TestPassportV1Inputs_DatePolicyJSON(t *testing.T) {
+	inputs := PassportV1Inputs{DatePolicy: DatePolicy{MaxAge: 20}}
+	err := json.Unmarshal([]byte(`{"DatePolicy":{"maxAge":0},"datePolicy":{"maxAge":0}}`), &inputs)
+	require.NoError(t, err)
+	require.Equal(t, DatePolicy{MaxAge: 20}, inputs.DatePolicy)
+
+	data, err := json.Marshal(inputs)
+	require.NoError(t, err)
+	require.NotContains(t, strings.ToLower(string(data)), "datepolicy")
+}
+
+func TestInputsMarshal_ValidityPolicyJSON(t *testing.T) {
+	// testMRZ expires on 2035-08-03, 14 days after the issuance date
+	data := []byte(`{
+		"passportData": "` + mrzToDg1(testMRZ) + `",
+		"issuerID": "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		"credentialSubjectID": "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		"credentialStatusID": "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		"issuanceDate": ` + strconv.FormatInt(time.Date(2035, 7, 20, 0, 0, 0, 0, time.UTC).Unix(), 10) + `,
+		"linkNonce": "1",
+		"ValidityPolicy": {"minRemainingDays": 0},
+		"validityPolicy": {"minRemainingDays": 0}
+	}`)
+
+	inputs := PassportV1Inputs{ValidityPolicy: ValidityPolicy{MinRemainingDays: 30}}
+	require.NoError(t, json.Unmarshal(data, &inputs))
+	require.Equal(t, ValidityPolicy{MinRemainingDays: 30}, inputs.ValidityPolicy)
+	_, err := inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrInsufficientValidity)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrInsufficientValidity)
+
+	inputs = PassportV1Inputs{}
+	require.NoError(t, json.Unmarshal(bytes.ReplaceAll(data,
+		[]byte(`"minRemainingDays": 0`), []byte(`"minRemainingDays": 30`)), &inputs))
+	require.Zero(t, inputs.ValidityPolicy)
+	_, err = inputs.InputsMarshal()
+	require.NoError(t, err)
+
+	out, err := json.Marshal(inputs)
+	require.NoError(t, err)
+	require.NotContains(t, strings.ToLower(string(out)), "validitypolicy")
+}
+
+func TestW3CCredential_Visa(t *testing.T) {
+	tests := []struct {
+		name string
+		mrz  string
+	}{
+		{
+			name: "MRV-A",
+			mrz: "V<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\n" +
+				"L8988901C4XXX4009078F9612109ZE184226B<<<<<<<",
+		},
+		{
+			name: "MRV-B",
+			mrz: "VCUTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\n" +
+				"D231458907UTO7408122F3004157<<<<<<<<",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := PassportV1Inputs{
+				MRZ:                     tt.mrz,
+				IssuerID:                "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+				CredentialSubjectID:     "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+				CredentialStatusID:      "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+				IssuanceDate:            time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+				LinkNonce:               "1",
+				AcceptSpecimenCountries: true,
+			}
+
+			_, err := inputs.W3CCredential()
+			require.ErrorIs(t, err, ErrUnsupportedFormat)
+			_, err = inputs.InputsMarshal()
+			require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+			inputs.AcceptSpecimenCountries = false
+			_, err = inputs.W3CCredential()
+			require.ErrorIs(t, err, common.ErrUnknownCountry)
+		})
+	}
+}
+
+func TestPassportV1Inputs_AcceptSpecimenCountriesJSON(t *testing.T) {
+	var inputs PassportV1Inputs
+	err := json.Unmarshal([]byte(`{"AcceptSpecimenCountries":true,"acceptSpecimenCountries":true}`), &inputs)
+	require.NoError(t, err)
+	require.False(t, inputs.AcceptSpecimenCountries)
+}
+
+func TestPassportV1Inputs_StrictModeJSON(t *testing.T) {
+	inputs := PassportV1Inputs{StrictMode: true}
+	err := json.Unmarshal([]byte(`{"StrictMode":false,"strictMode":false}`), &inputs)
+	require.NoError(t, err)
+	require.True(t, inputs.StrictMode)
+
+	data, err := json.Marshal(inputs)
+	require.NoError(t, err)
+	require.NotContains(t, strings.ToLower(string(data)), "strictmode")
+}
+
+func TestW3CCredential_UnknownCountry(t *testing.T) {
+	inputs := PassportV1Inputs{
+		PassportData:        mrzToDg1(strings.Replace(testMRZ, "UKR", "ZZZ", 1)),
+		IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusID:  "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		IssuanceDate:        time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		LinkNonce:           "1",
+	}
+	_, err := inputs.W3CCredential()
+	require.ErrorIs(t, err, common.ErrUnknownCountry)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, common.ErrUnknownCountry)
+
+	inputs.PassportData = mrzToDg1(strings.Replace(testMRZ, "UKR", "D<<", 1))
+	credential, err := inputs.W3CCredential()
+	require.NoError(t, err)
+	nationalities, ok := credential.CredentialSubject["nationalities"].(map[string]interface{})
+	require.True(t, ok)
+	require.Equal(t, "D", nationalities["nationality2CountryCode"])
+}
@@ -0,0 +1,505 @@
+package passport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des" //nolint:gosec // 3DES is required by ICAO 9303 BAC
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// Transport sends a command APDU to the chip and returns the response APDU
+// with the trailing status word. It is implemented by NFC reader drivers.
+type Transport interface {
+	Transmit(apdu []byte) ([]byte, error)
+}
+
+// Elementary file identifiers of the eMRTD application.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 21
+const (
+	FileCOM  uint16 = 0x011E // EF.COM
+	FileDG1  uint16 = 0x0101 // EF.DG1
+	FileDG2  uint16 = 0x0102 // EF.DG2
+	FileDG11 uint16 = 0x010B // EF.DG11
+	FileDG12 uint16 = 0x010C // EF.DG12
+	FileDG14 uint16 = 0x010E // EF.DG14
+	FileDG15 uint16 = 0x010F // EF.DG15
+	FileSOD  uint16 = 0x011D // EF.SOD
+
+	FileCardAccess uint16 = 0x011C // EF.CardAccess of the master file
+)
+
+// eMRTDApplicationID is the AID of the LDS1 eMRTD application.
+var eMRTDApplicationID = []byte{0xA0, 0x00, 0x00, 0x02, 0x47, 0x10, 0x01}
+
+const (
+	swSuccess = 0x9000
+
+	// maxReadLength is the largest READ BINARY length whose protected
+	// response fits into a short response APDU.
+	maxReadLength = 0xDF
+	// maxReadOffset is the largest offset of READ BINARY with even INS.
+	maxReadOffset = 0x7FFF
+)
+
+// Secure messaging data objects.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 77
+const (
+	smEncryptedDataTag = 0x87 // Padding indicator and encrypted data
+	smExpectedLenTag   = 0x97 // Expected length of the response data
+	smStatusTag        = 0x99 // Processing status
+	smMACTag           = 0x8E // Cryptographic checksum
+
+	smPaddingIndicator = 0x01
+	smCLA              = 0x0C
+)
+
+// ErrSecureMessaging is returned when a protected response APDU is malformed
+// or its cryptographic checksum does not match.
+var ErrSecureMessaging = errors.New("secure messaging error")
+
+// StatusError is returned when the chip responds with a status word
+// other than 9000.
+type StatusError struct {
+	SW uint16 // Status word SW1 SW2
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status word %04X", e.SW)
+}
+
+// smCipher is the cipher suite of a secure messaging session.
+type smCipher interface {
+	blockSize() int
+	encrypt(ssc, data []byte) []byte
+	decrypt(ssc, data []byte) []byte
+	// mac computes the cryptographic checksum of the padded data.
+	mac(data []byte) []byte
+}
+
+// tdesCipher is the 3DES cipher suite established by BAC: two key 3DES in
+// CBC mode with zero IV and the ISO/IEC 9797-1 MAC algorithm 3.
+type tdesCipher struct {
+	enc        cipher.Block
+	macA, macB cipher.Block // single DES with the halves of the MAC key
+}
+
+func newTDESCipher(kEnc, kMac []byte) (*tdesCipher, error) {
+	enc, err := newTDES(kEnc)
+	if err != nil {
+		return nil, err
+	}
+	macA, macB, err := newRetailMAC(kMac)
+	if err != nil {
+		return nil, err
+	}
+	return &tdesCipher{enc: enc, macA: macA, macB: macB}, nil
+}
+
+// newTDES creates a two key 3DES cipher from a 16 byte key.
+func newTDES(key []byte) (cipher.Block, error) {
+	if len(key) != 16 {
+		return nil, fmt.Errorf("invalid 3DES key length %d", len(key))
+	}
+	k := append(append([]byte{}, key...), key[:8]...)
+	block, err := des.NewTripleDESCipher(k) //nolint:gosec // required by ICAO 9303
+	if err != nil {
+		return nil, fmt.Errorf("failed to create 3DES cipher: %w", err)
+	}
+	return block, nil
+}
+
+func (c *tdesCipher) blockSize() int { return des.BlockSize }
+
+func (c *tdesCipher) encrypt(_, data []byte) []byte {
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(c.enc, make([]byte, des.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func (c *tdesCipher) decrypt(_, data []byte) []byte {
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c.enc, make([]byte, des.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func (c *tdesCipher) mac(data []byte) []byte {
+	return retailMAC(c.macA, c.macB, data)
+}
+
+// newRetailMAC creates the single DES ciphers of a 16 byte MAC key.
+func newRetailMAC(key []byte) (ka, kb cipher.Block, err error) {
+	if len(key) != 16 {
+		return nil, nil, fmt.Errorf("invalid MAC key length %d", len(key))
+	}
+	if ka, err = des.NewCipher(key[:8]); err != nil { //nolint:gosec // required by ICAO 9303
+		return nil, nil, fmt.Errorf("failed to create DES cipher: %w", err)
+	}
+	if kb, err = des.NewCipher(key[8:]); err != nil { //nolint:gosec // required by ICAO 9303
+		return nil, nil, fmt.Errorf("failed to create DES cipher: %w", err)
+	}
+	return ka, kb, nil
+}
+
+// retailMAC computes the ISO/IEC 9797-1 MAC algorithm 3 with DES of the
+// padded data: single DES CBC with the first half of the key and 3DES on the
+// final block.
+func retailMAC(ka, kb cipher.Block, data []byte) []byte {
+	h := make([]byte, des.BlockSize)
+	for i := 0; i < len(data); i += des.BlockSize {
+		subtle.XORBytes(h, h, data[i:i+des.BlockSize])
+		ka.Encrypt(h, h)
+	}
+	kb.Decrypt(h, h)
+	ka.Encrypt(h, h)
+	return h
+}
+
+// macSize is the size of the cryptographic checksum of AES secure messaging.
+const macSize = 8
+
+// aesCipher is the AES cipher suite established by PACE: AES in CBC mode with
+// the encrypted send sequence counter as IV and AES-CMAC truncated to 8 bytes.
+type aesCipher struct {
+	enc, macKey cipher.Block
+}
+
+func newAESCipher(kEnc, kMac []byte) (*aesCipher, error) {
+	enc, err := aes.NewCipher(kEnc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	macKey, err := aes.NewCipher(kMac)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	return &aesCipher{enc: enc, macKey: macKey}, nil
+}
+
+func (c *aesCipher) blockSize() int { return aes.BlockSize }
+
+func (c *aesCipher) iv(ssc []byte) []byte {
+	iv := make([]byte, aes.BlockSize)
+	c.enc.Encrypt(iv, ssc)
+	return iv
+}
+
+func (c *aesCipher) encrypt(ssc, data []byte) []byte {
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(c.enc, c.iv(ssc)).CryptBlocks(out, data)
+	return out
+}
+
+func (c *aesCipher) decrypt(ssc, data []byte) []byte {
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c.enc, c.iv(ssc)).CryptBlocks(out, data)
+	return out
+}
+
+func (c *aesCipher) mac(data []byte) []byte {
+	return cmac(c.macKey, data)[:macSize]
+}
+
+// cmac computes the AES-CMAC of the data, RFC 4493.
+func cmac(block cipher.Block, data []byte) []byte {
+	bs := block.BlockSize()
+	k1 := make([]byte, bs)
+	block.Encrypt(k1, k1)
+	k1 = doubleSubkey(k1)
+	k2 := doubleSubkey(k1)
+
+	n := (len(data) + bs - 1) / bs
+	last := make([]byte, bs)
+	if n > 0 && len(data)%bs == 0 {
+		subtle.XORBytes(last, data[(n-1)*bs:], k1)
+	} else {
+		if n == 0 {
+			n = 1
+		}
+		rest := data[(n-1)*bs:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		subtle.XORBytes(last, last, k2)
+	}
+
+	mac := make([]byte, bs)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(mac, mac, data[i*bs:(i+1)*bs])
+		block.Encrypt(mac, mac)
+	}
+	subtle.XORBytes(mac, mac, last)
+	block.Encrypt(mac, mac)
+	return mac
+}
+
+// doubleSubkey multiplies the block by x in GF(2^128).
+func doubleSubkey(in []byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+	if carry != 0 {
+		out[len(out)-1] ^= 0x87
+	}
+	return out
+}
+
+// SecureMessaging protects the APDUs of a session established by BAC or PACE.
+// It implements Transport, so files are read through it with ReadFile.
+// https://www.icao.int/publications/Documents/9303_p11_cons_en.pdf page 76
+type SecureMessaging struct {
+	transport Transport
+	cipher    smCipher
+	ssc       []byte // send sequence counter
+}
+
+func newSecureMessaging(transport Transport, c smCipher, ssc []byte) *SecureMessaging {
+	return &SecureMessaging{transport: transport, cipher: c, ssc: ssc}
+}
+
+// Transmit protects the command APDU, sends it to the chip and returns the
+// unprotected response APDU.
+func (sm *SecureMessaging) Transmit(apdu []byte) ([]byte, error) {
+	protected, err := sm.Wrap(apdu)
+	if err != nil {
+		return nil, err
+	}
+	response, err := sm.transport.Transmit(protected)
+	if err != nil {
+		return nil, err
+	}
+	return sm.Unwrap(response)
+}
+
+// Wrap protects a short command APDU and increments the send sequence counter.
+func (sm *SecureMessaging) Wrap(apdu []byte) ([]byte, error) {
+	if len(apdu) < 4 {
+		return nil, fmt.Errorf("invalid command APDU length %d", len(apdu))
+	}
+	if apdu[0]&smCLA == smCLA {
+		return nil, errors.New("command APDU is already protected")
+	}
+	data, le, hasLe, err := parseCommandBody(apdu[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	ssc := sm.incrementSSC()
+	header := []byte{apdu[0] | smCLA, apdu[1], apdu[2], apdu[3]}
+	bs := sm.cipher.blockSize()
+	var objs []byte
+	if len(data) > 0 {
+		encrypted := sm.cipher.encrypt(ssc, pad(data, bs))
+		objs = append(objs,
+			encodeTLV(smEncryptedDataTag, append([]byte{smPaddingIndicator}, encrypted...))...)
+	}
+	if hasLe {
+		objs = append(objs, encodeTLV(smExpectedLenTag, []byte{le})...)
+	}
+
+	macInput := append(append(append([]byte{}, ssc...), pad(header, bs)...), objs...)
+	objs = append(objs, encodeTLV(smMACTag, sm.cipher.mac(pad(macInput, bs)))...)
+	if len(objs) > 0xFF {
+		return nil, fmt.Errorf("protected command data is too long: %d bytes", len(objs))
+	}
+
+	protected := append(header, byte(len(objs)))
+	protected = append(protected, objs...)
+	return append(protected, 0x00), nil
+}
+
+// Unwrap verifies and decrypts a protected response APDU and increments the
+// send sequence counter. It returns the response data with the status word.
+// Responses without data objects are returned unchanged when they report
+// an error.
+func (sm *SecureMessaging) Unwrap(response []byte) ([]byte, error) {
+	if len(response) < 2 {
+		return nil, fmt.Errorf("invalid response APDU length %d", len(response))
+	}
+	ssc := sm.incrementSSC()
+	body, sw := response[:len(response)-2], response[len(response)-2:]
+	if len(body) == 0 {
+		if statusWord(sw) == swSuccess {
+			return nil, fmt.Errorf("%w: response is not protected", ErrSecureMessaging)
+		}
+		return response, nil
+	}
+
+	objs, err := decodeTLVs(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecureMessaging, err)
+	}
+	macInput := append([]byte{}, ssc...)
+	var checksum, encrypted, status []byte
+	for _, obj := range objs {
+		switch obj.tag {
+		case smMACTag:
+			checksum = obj.value
+			continue
+		case smEncryptedDataTag:
+			encrypted = obj.value
+		case smStatusTag:
+			status = obj.value
+		}
+		macInput = append(macInput, obj.raw...)
+	}
+	if checksum == nil {
+		return nil, fmt.Errorf("%w: cryptographic checksum is missing", ErrSecureMessaging)
+	}
+	bs := sm.cipher.blockSize()
+	if subtle.ConstantTimeCompare(checksum, sm.cipher.mac(pad(macInput, bs))) != 1 {
+		return nil, fmt.Errorf("%w: cryptographic checksum does not match", ErrSecureMessaging)
+	}
+	if len(status) == 2 {
+		sw = status
+	}
+
+	var data []byte
+	if encrypted != nil {
+		if len(encrypted) < 1 || encrypted[0] != smPaddingIndicator ||
+			(len(encrypted)-1)%bs != 0 {
+			return nil, fmt.Errorf("%w: malformed encrypted data", ErrSecureMessaging)
+		}
+		data, err = unpad(sm.cipher.decrypt(ssc, encrypted[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSecureMessaging, err)
+		}
+	}
+	return append(data, sw...), nil
+}
+
+// incrementSSC increments the send sequence counter and returns a copy of it.
+func (sm *SecureMessaging) incrementSSC() []byte {
+	for i := len(sm.ssc) - 1; i >= 0; i-- {
+		sm.ssc[i]++
+		if sm.ssc[i] != 0 {
+			break
+		}
+	}
+	return append([]byte{}, sm.ssc...)
+}
+
+// parseCommandBody splits the body of a short command APDU into the command
+// data and the expected response length.
+func parseCommandBody(body []byte) (data []byte, le byte, hasLe bool, err error) {
+	switch {
+	case len(body) == 0:
+		return nil, 0, false, nil
+	case len(body) == 1:
+		return nil, body[0], true, nil
+	}
+	lc := int(body[0])
+	switch len(body) {
+	case 1 + lc:
+		return body[1:], 0, false, nil
+	case 2 + lc:
+		return body[1 : 1+lc], body[1+lc], true, nil
+	default:
+		return nil, 0, false, fmt.Errorf(
+			"invalid command APDU: Lc %d does not match %d data bytes", lc, len(body)-1)
+	}
+}
+
+// pad applies ISO/IEC 9797-1 padding method 2.
+func pad(data []byte, blockSize int) []byte {
+	out := append(append([]byte{}, data...), 0x80)
+	for len(out)%blockSize != 0 {
+		out = append(out, 0x00)
+	}
+	return out
+}
+
+func unpad(data []byte) ([]byte, error) {
+	for i := len(data) - 1; i >= 0; i-- {
+		switch data[i] {
+		case 0x00:
+			continue
+		case 0x80:
+			return data[:i], nil
+		}
+		break
+	}
+	return nil, errors.New("invalid padding")
+}
+
+func statusWord(sw []byte) uint16 {
+	return uint16(sw[0])<<8 | uint16(sw[1])
+}
+
+// transmit sends the command APDU and returns the response data when the
+// status word is 9000.
+func transmit(t Transport, apdu []byte) ([]byte, error) {
+	response, err := t.Transmit(apdu)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transmit APDU: %w", err)
+	}
+	if len(response) < 2 {
+		return nil, fmt.Errorf("invalid response APDU length %d", len(response))
+	}
+	if sw := statusWord(response[len(response)-2:]); sw != swSuccess {
+		return nil, &StatusError{SW: sw}
+	}
+	return response[:len(response)-2], nil
+}
+
+// SelectApplication selects the eMRTD application. It is sent before the
+// access control protocol.
+func SelectApplication(t Transport) error {
+	apdu := append([]byte{0x00, 0xA4, 0x04, 0x0C, byte(len(eMRTDApplicationID))},
+		eMRTDApplicationID...)
+	if _, err := transmit(t, apdu); err != nil {
+		return fmt.Errorf("failed to select eMRTD application: %w", err)
+	}
+	return nil
+}
+
+// ReadFile selects the elementary file and reads it with READ BINARY.
+// The file length is taken from the BER-TLV header of the file, so the
+// transport is usually a SecureMessaging session.
+func ReadFile(t Transport, fileID uint16) ([]byte, error) {
+	selectFile := []byte{0x00, 0xA4, 0x02, 0x0C, 0x02, byte(fileID >> 8), byte(fileID)}
+	if _, err := transmit(t, selectFile); err != nil {
+		return nil, fmt.Errorf("failed to select file %04X: %w", fileID, err)
+	}
+
+	header, err := readBinary(t, 0, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %04X: %w", fileID, err)
+	}
+	_, tagSize, err := decodeTag(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %04X: %w", fileID, err)
+	}
+	length, lengthSize, err := decodeLength(header[tagSize:], maxReadOffset+maxReadLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %04X: %w", fileID, err)
+	}
+	size := tagSize + lengthSize + length
+
+	file := header
+	for len(file) < size {
+		chunk, err := readBinary(t, len(file), min(size-len(file), maxReadLength))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %04X: %w", fileID, err)
+		}
+		file = append(file, chunk...)
+	}
+	return file[:size], nil
+}
+
+func readBinary(t Transport, offset, length int) ([]byte, error) {
+	if offset > maxReadOffset {
+		return nil, fmt.Errorf("offset %d exceeds %d", offset, maxReadOffset)
+	}
+	data, err := transmit(t, []byte{0x00, 0xB0, byte(offset >> 8), byte(offset), byte(length)})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no data at offset %d", offset)
+	}
+	return data, nil
+}
@@ -0,0 +1,216 @@
+package passport
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// apduExchange is a command APDU expected by testCard and its response.
+type apduExchange struct {
+	command  string
+	response string
+}
+
+// testCard replays recorded APDU exchanges.
+type testCard struct {
+	t         *testing.T
+	exchanges []apduExchange
+}
+
+func (c *testCard) Transmit(apdu []byte) ([]byte, error) {
+	require.NotEmpty(c.t, c.exchanges, "unexpected command %X", apdu)
+	exchange := c.exchanges[0]
+	c.exchanges = c.exchanges[1:]
+	require.Equal(c.t, strings.ToUpper(exchange.command), strings.ToUpper(hex.EncodeToString(apdu)))
+	return mustDecodeHex(c.t, exchange.response), nil
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// ICAO 9303 part 11 appendix D.4 worked example.
+const (
+	testKSEnc = "979EC13B1CBFE9DCD01AB0FED307EAE5"
+	testKSMac = "F1CB1F1FB5ADF208806B89DC579DC1F8"
+	testSSC   = "887022120C06C226"
+)
+
+// testReadCOMExchanges select EF.COM and read it in two parts.
+var testReadCOMExchanges = []apduExchange{
+	{
+		command:  "0CA4020C158709016375432908C044F68E08BF8B92D635FF24F800",
+		response: "990290008E08FA855A5D4C50A8ED9000",
+	},
+	{
+		command:  "0CB000000D9701048E08ED6705417E96BA5500",
+		response: "8709019FF0EC34F9922651990290008E08AD55CC17140B2DED9000",
+	},
+	{
+		command: "0CB000040D9701128E082EA28A70F3C7B53500",
+		response: "871901FB9235F4E4037F2327DCC8964F1F9B8C30F42C8E2FFF224A99029000" +
+			"8E08C8B2787EAEA07D749000",
+	},
+}
+
+func newTestSecureMessaging(t *testing.T, card Transport) *SecureMessaging {
+	session, err := newTDESCipher(mustDecodeHex(t, testKSEnc), mustDecodeHex(t, testKSMac))
+	require.NoError(t, err)
+	return newSecureMessaging(card, session, mustDecodeHex(t, testSSC))
+}
+
+func TestSecureMessaging_WrapUnwrap(t *testing.T) {
+	sm := newTestSecureMessaging(t, nil)
+
+	protected, err := sm.Wrap(mustDecodeHex(t, "00A4020C02011E"))
+	require.NoError(t, err)
+	require.Equal(t, testReadCOMExchanges[0].command,
+		strings.ToUpper(hex.EncodeToString(protected)))
+	response, err := sm.Unwrap(mustDecodeHex(t, testReadCOMExchanges[0].response))
+	require.NoError(t, err)
+	require.Equal(t, "9000", hex.EncodeToString(response))
+
+	protected, err = sm.Wrap(mustDecodeHex(t, "00B0000004"))
+	require.NoError(t, err)
+	require.Equal(t, testReadCOMExchanges[1].command,
+		strings.ToUpper(hex.EncodeToString(protected)))
+	response, err = sm.Unwrap(mustDecodeHex(t, testReadCOMExchanges[1].response))
+	require.NoError(t, err)
+	require.Equal(t, "60145f019000", hex.EncodeToString(response))
+	require.Equal(t, "887022120C06C22A", strings.ToUpper(hex.EncodeToString(sm.ssc)))
+}
+
+func TestSecureMessaging_Unwrap_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		err      error
+	}{
+		{
+			name:     "Wrong checksum",
+			response: "990290008E08FA855A5D4C50A8EE9000",
+			err:      ErrSecureMessaging,
+		},
+		{
+			name:     "Missing checksum",
+			response: "990290009000",
+			err:      ErrSecureMessaging,
+		},
+		{
+			name:     "Unprotected success",
+			response: "9000",
+			err:      ErrSecureMessaging,
+		},
+		{
+			name:     "Malformed data objects",
+			response: "99059000",
+			err:      ErrSecureMessaging,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestSecureMessaging(t, nil)
+			_, err := sm.Wrap(mustDecodeHex(t, "00A4020C02011E"))
+			require.NoError(t, err)
+			_, err = sm.Unwrap(mustDecodeHex(t, tt.response))
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	sm := newTestSecureMessaging(t, nil)
+	response, err := sm.Unwrap(mustDecodeHex(t, "6982"))
+	require.NoError(t, err)
+	require.Equal(t, "6982", hex.EncodeToString(response))
+}
+
+func TestSecureMessaging_Wrap_Invalid(t *testing.T) {
+	sm := newTestSecureMessaging(t, nil)
+	for _, apdu := range []string{"00A4", "0CA4020C", "00A4020C05011E"} {
+		_, err := sm.Wrap(mustDecodeHex(t, apdu))
+		require.Error(t, err, apdu)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	card := &testCard{t: t, exchanges: testReadCOMExchanges}
+	file, err := ReadFile(newTestSecureMessaging(t, card), FileCOM)
+	require.NoError(t, err)
+	require.Equal(t, "60145F0104303130365F36063034303030305C026175",
+		strings.ToUpper(hex.EncodeToString(file)))
+	require.Empty(t, card.exchanges)
+
+	dg1 := mustDecodeHex(t, mrzToDg1(testMRZ))
+	card = &testCard{t: t, exchanges: []apduExchange{
+		{command: "00A4020C020101", response: "9000"},
+		{command: "00B0000004", response: hex.EncodeToString(dg1[:4]) + "9000"},
+		{
+			command:  fmt.Sprintf("00B00004%02X", len(dg1)-4),
+			response: hex.EncodeToString(dg1[4:]) + "9000",
+		},
+	}}
+	file, err = ReadFile(card, FileDG1)
+	require.NoError(t, err)
+	require.Equal(t, dg1, file)
+
+	card = &testCard{t: t, exchanges: []apduExchange{
+		{command: "00A4020C020101", response: "6A82"},
+	}}
+	_, err = ReadFile(card, FileDG1)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, uint16(0x6A82), statusErr.SW)
+}
+
+func TestCMAC(t *testing.T) {
+	// RFC 4493 section 4.
+	block, err := aes.NewCipher(mustDecodeHex(t, "2B7E151628AED2A6ABF7158809CF4F3C"))
+	require.NoError(t, err)
+	message := "6BC1BEE22E409F96E93D7E117393172AAE2D8A571E03AC9C9EB76FAC45AF8E51" +
+		"30C81C46A35CE411E5FBC1191A0A52EFF69F2445DF4F9B17AD2B417BE66C3710"
+
+	tests := []struct {
+		length int
+		mac    string
+	}{
+		{0, "BB1D6929E95937287FA37D129B756746"},
+		{16, "070A16B46B4D4144F79BDD9DD04A287C"},
+		{40, "DFA66747DE9AE63030CA32611497C827"},
+		{64, "51F0BEBF7E3B9D92FC49741779363CFE"},
+	}
+	for _, tt := range tests {
+		mac := cmac(block, mustDecodeHex(t, message)[:tt.length])
+		require.Equal(t, tt.mac, strings.ToUpper(hex.EncodeToString(mac)), tt.length)
+	}
+}
+
+func TestSecureMessaging_AES(t *testing.T) {
+	session, err := newAESCipher(
+		mustDecodeHex(t, "F5F0E35C0D7161EE6724EE513A0D9A7F"),
+		mustDecodeHex(t, "FE251C7858B356B24514B3BD5F4297D1"),
+	)
+	require.NoError(t, err)
+	sm := newSecureMessaging(nil, session, make([]byte, aes.BlockSize))
+
+	protected, err := sm.Wrap(mustDecodeHex(t, "00A4020C02011E"))
+	require.NoError(t, err)
+	require.Equal(t, "0CA4020C1D871101", strings.ToUpper(hex.EncodeToString(protected[:8])))
+	require.Len(t, protected, 5+19+10+1)
+
+	// Response protected with SSC 2.
+	ssc := append(make([]byte, aes.BlockSize-1), 0x02)
+	do87 := encodeTLV(smEncryptedDataTag,
+		append([]byte{smPaddingIndicator}, session.encrypt(ssc, pad([]byte("EF.COM"), 16))...))
+	do99 := encodeTLV(smStatusTag, []byte{0x90, 0x00})
+	mac := session.mac(pad(concatBytes(ssc, do87, do99), 16))
+	response, err := sm.Unwrap(concatBytes(do87, do99, encodeTLV(smMACTag, mac), []byte{0x90, 0x00}))
+	require.NoError(t, err)
+	require.Equal(t, append([]byte("EF.COM"), 0x90, 0x00), response)
+}
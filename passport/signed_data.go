@@ -0,0 +1,199 @@
+package passport
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/0xPolygonID/go-circuit-external/common"
+)
+
+const (
+	maxDG1Bytes              = 128
+	maxEContentBytes         = 1536
+	maxSignedAttributesBytes = 256
+
+	rsaWordSize   = 121
+	ecdsaWordSize = 64
+)
+
+type shaPadFunc func(m []byte, maxShaBytes int) ([]byte, int, error)
+
+// signedDataCircuit is the hash algorithm and the padding used by a signed
+// data circuit.
+type signedDataCircuit struct {
+	hash crypto.Hash
+	pad  shaPadFunc
+}
+
+var signedDataCircuits = map[string]signedDataCircuit{
+	CredentialSHA1:   {crypto.SHA1, common.SHA256Pad},
+	CredentialSHA224: {crypto.SHA224, common.SHA256Pad},
+	CredentialSHA256: {crypto.SHA256, common.SHA256Pad},
+	CredentialSHA384: {crypto.SHA384, common.SHA512Pad},
+	CredentialSHA512: {crypto.SHA512, common.SHA512Pad},
+}
+
+// PassportSignedDataV1Inputs are inputs of the circuits proving that DG1 is
+// signed by the Document Signer: the DG1 hash is in the eContent, the eContent
+// hash is in the signed attributes and the signed attributes are signed.
+type PassportSignedDataV1Inputs struct {
+	CircuitID    string `json:"circuitID"`    // one of CredentialSHA* constants
+	PassportData string `json:"passportData"` // hex encoded DG1
+	SOD          string `json:"sod"`          // hex encoded EF.SOD
+}
+
+type passportSignedDataV1CircuitInputs struct {
+	DG1                       []string `json:"dg1"`
+	DG1ShaLength              int      `json:"dg1ShaLength"`
+	EContent                  []string `json:"eContent"`
+	EContentShaLength         int      `json:"eContentShaLength"`
+	DG1HashOffset             int      `json:"dg1HashOffset"`
+	SignedAttributes          []string `json:"signedAttributes"`
+	SignedAttributesShaLength int      `json:"signedAttributesShaLength"`
+	EContentHashOffset        int      `json:"eContentHashOffset"`
+	Signature                 []string `json:"signature"`
+	PubKey                    []string `json:"pubKey"`
+}
+
+func (a *PassportSignedDataV1Inputs) InputsMarshal() ([]byte, error) {
+	circuit, ok := signedDataCircuits[a.CircuitID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported circuit '%s'", a.CircuitID)
+	}
+
+	dg1, err := ParseDG1(a.PassportData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DG1: %w", err)
+	}
+	sod, err := ParseSOD(a.SOD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SOD: %w", err)
+	}
+	if sod.DigestAlgorithm != circuit.hash || sod.SignerDigestAlgorithm != circuit.hash {
+		return nil, fmt.Errorf(
+			"circuit '%s' expects %s, SOD uses %s for data groups and %s for signed attributes",
+			a.CircuitID, circuit.hash, sod.DigestAlgorithm, sod.SignerDigestAlgorithm,
+		)
+	}
+	if err = sod.VerifyDataGroup(1, dg1.Raw); err != nil {
+		return nil, fmt.Errorf("failed to verify DG1 against SOD: %w", err)
+	}
+
+	dg1HashOffset, err := hashOffset(circuit.hash, dg1.Raw, sod.EContent)
+	if err != nil {
+		return nil, fmt.Errorf("DG1 hash is not in eContent: %w", err)
+	}
+	eContentHashOffset, err := hashOffset(circuit.hash, sod.EContent, sod.SignedAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("eContent hash is not in signed attributes: %w", err)
+	}
+
+	dg1Padded, dg1Len, err := circuit.pad(dg1.Raw, maxDG1Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pad DG1: %w", err)
+	}
+	eContentPadded, eContentLen, err := circuit.pad(sod.EContent, maxEContentBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pad eContent: %w", err)
+	}
+	signedAttrsPadded, signedAttrsLen, err := circuit.pad(
+		sod.SignedAttributes, maxSignedAttributesBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pad signed attributes: %w", err)
+	}
+
+	signature, pubKey, err := signatureLimbs(sod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split signature: %w", err)
+	}
+
+	inputs := passportSignedDataV1CircuitInputs{
+		DG1:                       common.Uint8ArrayToCharArray(dg1Padded),
+		DG1ShaLength:              dg1Len,
+		EContent:                  common.Uint8ArrayToCharArray(eContentPadded),
+		EContentShaLength:         eContentLen,
+		DG1HashOffset:             dg1HashOffset,
+		SignedAttributes:          common.Uint8ArrayToCharArray(signedAttrsPadded),
+		SignedAttributesShaLength: signedAttrsLen,
+		EContentHashOffset:        eContentHashOffset,
+		Signature:                 common.BigIntListToStrings(signature),
+		PubKey:                    common.BigIntListToStrings(pubKey),
+	}
+
+	jsonBytes, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inputs: %w", err)
+	}
+
+	return jsonBytes, nil
+}
+
+// hashOffset returns the offset of the hash of data in container.
+func hashOffset(hash crypto.Hash, data, container []byte) (int, error) {
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+	offset := bytes.Index(container, digest)
+	if offset < 0 {
+		return 0, fmt.Errorf("%s hash %x not found", hash, digest)
+	}
+	return offset, nil
+}
+
+// signatureLimbs splits the SOD signature and the Document Signer public key
+// into circuit words. RSA values use 121 bit words, ECDSA values use 64 bit
+// words with r and s (x and y for the key) concatenated, for the NIST curves
+// and for the curves of ECPublicKey.
+func signatureLimbs(sod *SOD) (signature, pubKey []*big.Int, err error) {
+	switch pub := sod.Certificate.PublicKey.(type) {
+	case *rsa.PublicKey:
+		words := big.NewInt(int64((pub.N.BitLen() + rsaWordSize - 1) / rsaWordSize))
+		signature, err = common.SplitToWords(
+			new(big.Int).SetBytes(sod.Signature), big.NewInt(rsaWordSize), words)
+		if err != nil {
+			return nil, nil, err
+		}
+		pubKey, err = common.SplitToWords(pub.N, big.NewInt(rsaWordSize), words)
+		if err != nil {
+			return nil, nil, err
+		}
+		return signature, pubKey, nil
+	case *ecdsa.PublicKey:
+		return ecdsaLimbs(sod.Signature, pub.Curve.Params().BitSize, pub.X, pub.Y)
+	case *ECPublicKey:
+		return ecdsaLimbs(sod.Signature, pub.curve.p.BitLen(), pub.X, pub.Y)
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// ecdsaLimbs splits a DER encoded ECDSA signature and the public key point
+// on a curve of bitSize bits into circuit words.
+func ecdsaLimbs(der []byte, bitSize int, x, y *big.Int) (signature, pubKey []*big.Int, err error) {
+	var sig struct{ R, S *big.Int }
+	if err = unmarshalDER(der, &sig); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse ECDSA signature: %w", err)
+	}
+	words := big.NewInt(int64((bitSize + ecdsaWordSize - 1) / ecdsaWordSize))
+	for _, v := range []struct {
+		value *big.Int
+		dest  *[]*big.Int
+	}{
+		{sig.R, &signature},
+		{sig.S, &signature},
+		{x, &pubKey},
+		{y, &pubKey},
+	} {
+		limbs, err := common.SplitToWords(v.value, big.NewInt(ecdsaWordSize), words)
+		if err != nil {
+			return nil, nil, err
+		}
+		*v.dest = append(*v.dest, limbs...)
+	}
+	return signature, pubKey, nil
+}
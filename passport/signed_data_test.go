@@ -0,0 +1,158 @@
+package passport
+
+import (
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func bytesFromCharArray(t *testing.T, chars []string) []byte {
+	t.Helper()
+	out := make([]byte, len(chars))
+	for i, c := range chars {
+		v, err := strconv.Atoi(c)
+		require.NoError(t, err)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+func TestPassportSignedDataV1Inputs_InputsMarshal(t *testing.T) {
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	csca := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	ecDS := newTestSigner(t, newTestECKey(t), csca, 2, notAfter)
+	rsaDS := newTestSigner(t, newTestRSAKey(t), csca, 3, notAfter)
+	brainpoolDS := newTestCurveSigner(t, newTestCurveKey(t, standardCurves[16]), nil, csca, 4, notAfter)
+	signers := []struct {
+		name  string
+		ds    *testSigner
+		limbs int
+	}{
+		{name: "ECDSA", ds: ecDS, limbs: 8},
+		{name: "RSA", ds: rsaDS, limbs: 17},
+		{name: "brainpoolP384r1", ds: brainpoolDS, limbs: 12},
+	}
+	dg1Hex := mrzToDg1(testMRZ)
+	dg1, err := hex.DecodeString(dg1Hex)
+	require.NoError(t, err)
+	dg2 := []byte{0x75, 0x03, 0x01, 0x02, 0x03}
+
+	tests := []struct {
+		circuitID string
+		hash      crypto.Hash
+		blockSize int
+	}{
+		{CredentialSHA1, crypto.SHA1, 64},
+		{CredentialSHA224, crypto.SHA224, 64},
+		{CredentialSHA256, crypto.SHA256, 64},
+		{CredentialSHA384, crypto.SHA384, 128},
+		{CredentialSHA512, crypto.SHA512, 128},
+	}
+
+	for _, tt := range tests {
+		for _, signer := range signers {
+			t.Run(tt.circuitID+"/"+signer.name, func(t *testing.T) {
+				sodRaw := newTestSOD(t, signer.ds, tt.hash, map[int][]byte{1: dg1, 2: dg2})
+				sod, err := parseSOD(sodRaw)
+				require.NoError(t, err)
+				inputs := PassportSignedDataV1Inputs{
+					CircuitID:    tt.circuitID,
+					PassportData: dg1Hex,
+					SOD:          hex.EncodeToString(sodRaw),
+				}
+				jsonInputs, err := inputs.InputsMarshal()
+				require.NoError(t, err)
+
+				var out passportSignedDataV1CircuitInputs
+				require.NoError(t, json.Unmarshal(jsonInputs, &out))
+				require.Len(t, out.DG1, maxDG1Bytes)
+				require.Len(t, out.EContent, maxEContentBytes)
+				require.Len(t, out.SignedAttributes, maxSignedAttributesBytes)
+				require.Zero(t, out.DG1ShaLength%tt.blockSize)
+				require.Zero(t, out.EContentShaLength%tt.blockSize)
+				require.Zero(t, out.SignedAttributesShaLength%tt.blockSize)
+
+				paddedDG1 := bytesFromCharArray(t, out.DG1)
+				require.Equal(t, dg1, paddedDG1[:len(dg1)])
+
+				eContent := bytesFromCharArray(t, out.EContent)
+				h := tt.hash.New()
+				h.Write(dg1)
+				require.Equal(t, h.Sum(nil),
+					eContent[out.DG1HashOffset:out.DG1HashOffset+tt.hash.Size()])
+
+				signedAttrs := bytesFromCharArray(t, out.SignedAttributes)
+				h = tt.hash.New()
+				h.Write(sod.EContent)
+				require.Equal(t, h.Sum(nil),
+					signedAttrs[out.EContentHashOffset:out.EContentHashOffset+tt.hash.Size()])
+
+				require.Len(t, out.Signature, signer.limbs)
+				require.Len(t, out.PubKey, signer.limbs)
+				pubKeyLimb, ok := new(big.Int).SetString(out.PubKey[0], 10)
+				require.True(t, ok)
+				require.NotZero(t, pubKeyLimb.Sign())
+				if pub, ok := signer.ds.cert.PublicKey.(*ECPublicKey); ok {
+					// little endian 64 bit words of x followed by y
+					x := new(big.Int)
+					for i := signer.limbs/2 - 1; i >= 0; i-- {
+						limb, ok := new(big.Int).SetString(out.PubKey[i], 10)
+						require.True(t, ok)
+						x.Lsh(x, ecdsaWordSize).Or(x, limb)
+					}
+					require.Equal(t, pub.X, x)
+				}
+			})
+		}
+	}
+}
+
+func TestPassportSignedDataV1Inputs_InputsMarshal_Errors(t *testing.T) {
+	ds := newTestDocumentSigner(t)
+	dg1Hex := mrzToDg1(testMRZ)
+	dg1, err := hex.DecodeString(dg1Hex)
+	require.NoError(t, err)
+	sodHex := hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256, map[int][]byte{1: dg1}))
+
+	tests := []struct {
+		name   string
+		inputs PassportSignedDataV1Inputs
+	}{
+		{
+			name: "Unknown circuit",
+			inputs: PassportSignedDataV1Inputs{
+				CircuitID: "credential_md5", PassportData: dg1Hex, SOD: sodHex,
+			},
+		},
+		{
+			name:   "Hash mismatch",
+			inputs: PassportSignedDataV1Inputs{CircuitID: CredentialSHA1, PassportData: dg1Hex, SOD: sodHex},
+		},
+		{
+			name: "Other DG1",
+			inputs: PassportSignedDataV1Inputs{
+				CircuitID: CredentialSHA256,
+				PassportData: mrzToDg1("P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<" +
+					"AC12345684UKR9603091M3508035<<<<<<<<<<<<<<02"),
+				SOD: sodHex,
+			},
+		},
+		{
+			name:   "Missing SOD",
+			inputs: PassportSignedDataV1Inputs{CircuitID: CredentialSHA256, PassportData: dg1Hex},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.inputs.InputsMarshal()
+			require.Error(t, err)
+		})
+	}
+}
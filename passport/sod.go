@@ -0,0 +1,334 @@
+package passport
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	// Register hash functions used by ICAO 9303 documents.
+	_ "crypto/sha1" //nolint:gosec // SHA-1 is still used by issued passports
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// sodTag is the application tag of EF.SOD.
+const sodTag = 0x77
+
+// ErrDataGroupHashMismatch is returned when a data group does not hash to
+// the value stored in the Document Security Object.
+var ErrDataGroupHashMismatch = errors.New("data group hash mismatch")
+
+var (
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidLDSSecurityObject = asn1.ObjectIdentifier{2, 23, 136, 1, 1, 1}
+
+	oidAttributeContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA224 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+var digestAlgorithms = []struct {
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}{
+	{oidSHA1, crypto.SHA1},
+	{oidSHA224, crypto.SHA224},
+	{oidSHA256, crypto.SHA256},
+	{oidSHA384, crypto.SHA384},
+	{oidSHA512, crypto.SHA512},
+}
+
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for _, alg := range digestAlgorithms {
+		if alg.oid.Equal(oid) {
+			return alg.hash, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported digest algorithm '%s'", oid)
+}
+
+// RFC 5652 CMS structures.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// ICAO 9303 part 10 LDSSecurityObject.
+type ldsSecurityObject struct {
+	Version             int
+	HashAlgorithm       pkix.AlgorithmIdentifier
+	DataGroupHashValues []dataGroupHash
+	LDSVersionInfo      asn1.RawValue `asn1:"optional"`
+}
+
+type dataGroupHash struct {
+	DataGroupNumber    int
+	DataGroupHashValue []byte
+}
+
+// SOD represents the Document Security Object (EF.SOD) of an eMRTD.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 55
+type SOD struct {
+	DigestAlgorithm       crypto.Hash              // Algorithm of the data group hashes
+	DataGroupHashes       map[int][]byte           // Data group number to its hash
+	Certificate           *x509.Certificate        // Document Signer certificate, see ECPublicKey
+	Certificates          []*x509.Certificate      // All certificates embedded in the SOD
+	EContent              []byte                   // DER encoded LDSSecurityObject
+	SignedAttributes      []byte                   // DER encoded signed attributes (SET OF)
+	SignerDigestAlgorithm crypto.Hash              // Digest algorithm of the signer info
+	SignatureAlgorithm    pkix.AlgorithmIdentifier // Signature algorithm of the signer info
+	Signature             []byte                   // Signature over the signed attributes
+	Raw                   []byte                   // Raw data including group tag
+}
+
+// ParseSOD parses the provided hex encoded EF.SOD data.
+func ParseSOD(data string) (*SOD, error) {
+	sodRaw, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOD format: data should be a hexadecimal string: %w", err)
+	}
+	return parseSOD(sodRaw)
+}
+
+func parseSOD(sodRaw []byte) (*SOD, error) {
+	envelope, rest, err := decodeTLV(sodRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOD format: %w", err)
+	}
+	if len(rest) != 0 || envelope.tag != sodTag {
+		return nil, fmt.Errorf("invalid SOD format: %w: expected a single 0x%X object",
+			ErrMalformedTLV, sodTag)
+	}
+	sd, err := parseSignedData(envelope.value, oidLDSSecurityObject)
+	if err != nil {
+		return nil, err
+	}
+
+	var lds ldsSecurityObject
+	if err = unmarshalDER(sd.eContent, &lds); err != nil {
+		return nil, fmt.Errorf("failed to parse LDS security object: %w", err)
+	}
+	digestAlgorithm, err := hashFromOID(lds.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDS security object: %w", err)
+	}
+	hashes := make(map[int][]byte, len(lds.DataGroupHashValues))
+	for _, dg := range lds.DataGroupHashValues {
+		if _, ok := hashes[dg.DataGroupNumber]; ok {
+			return nil, fmt.Errorf("invalid LDS security object: duplicate hash of DG%d",
+				dg.DataGroupNumber)
+		}
+		hashes[dg.DataGroupNumber] = dg.DataGroupHashValue
+	}
+
+	return &SOD{
+		DigestAlgorithm:       digestAlgorithm,
+		DataGroupHashes:       hashes,
+		Certificate:           sd.certificate,
+		Certificates:          sd.certificates,
+		EContent:              sd.eContent,
+		SignedAttributes:      sd.signedAttributes,
+		SignerDigestAlgorithm: sd.signerDigestAlgorithm,
+		SignatureAlgorithm:    sd.signatureAlgorithm,
+		Signature:             sd.signature,
+		Raw:                   sodRaw,
+	}, nil
+}
+
+// cmsSignedData is a CMS SignedData with a single signer, e.g. the SOD or a
+// CSCA master list.
+type cmsSignedData struct {
+	eContent              []byte      // Encapsulated content
+	signedAttributes      []byte      // DER encoded signed attributes (SET OF)
+	signerDigestAlgorithm crypto.Hash // Digest algorithm of the signer info
+	signatureAlgorithm    pkix.AlgorithmIdentifier
+	signature             []byte              // Signature over the signed attributes
+	certificate           *x509.Certificate   // Signer certificate
+	certificates          []*x509.Certificate // All embedded certificates
+}
+
+// parseSignedData parses a DER encoded ContentInfo with SignedData of the
+// encapsulated content type.
+func parseSignedData(data []byte, eContentType asn1.ObjectIdentifier) (*cmsSignedData, error) {
+	var ci contentInfo
+	if err := unmarshalDER(data, &ci); err != nil {
+		return nil, fmt.Errorf("failed to parse content info: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unexpected content type '%s'", ci.ContentType)
+	}
+
+	var sd signedData
+	if err := unmarshalDER(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("failed to parse signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(eContentType) {
+		return nil, fmt.Errorf("unexpected encapsulated content type '%s'",
+			sd.EncapContentInfo.EContentType)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("expected 1 signer info, got %d", len(sd.SignerInfos))
+	}
+
+	si := sd.SignerInfos[0]
+	signerDigestAlgorithm, err := hashFromOID(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer info: %w", err)
+	}
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return nil, errors.New("invalid signer info: signed attributes are missing")
+	}
+	// The signature is calculated over the DER encoding of SET OF attributes
+	// instead of the implicit [0] tag used in the signer info.
+	signedAttributes := bytes.Clone(si.SignedAttrs.FullBytes)
+	signedAttributes[0] = 0x31
+	if err = checkContentType(signedAttributes, eContentType); err != nil {
+		return nil, fmt.Errorf("invalid signer info: %w", err)
+	}
+
+	var certificates []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		certificates, err = parseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed data certificates: %w", err)
+		}
+	}
+	signer, err := findSignerCertificate(si.SID, certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmsSignedData{
+		eContent:              sd.EncapContentInfo.EContent,
+		signedAttributes:      signedAttributes,
+		signerDigestAlgorithm: signerDigestAlgorithm,
+		signatureAlgorithm:    si.SignatureAlgorithm,
+		signature:             si.Signature,
+		certificate:           signer,
+		certificates:          certificates,
+	}, nil
+}
+
+// checkContentType checks that the content type attribute of the signed
+// attributes is the eContentType, RFC 5652 section 11.1. Otherwise the
+// signature could cover content of another type.
+func checkContentType(signedAttributes []byte, eContentType asn1.ObjectIdentifier) error {
+	attrs, err := parseSignedAttributes(signedAttributes)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		if !attr.Type.Equal(oidAttributeContentType) {
+			continue
+		}
+		var contentType asn1.ObjectIdentifier
+		if len(attr.Values) != 1 || unmarshalDER(attr.Values[0].FullBytes, &contentType) != nil {
+			return errors.New("invalid content type attribute")
+		}
+		if !contentType.Equal(eContentType) {
+			return fmt.Errorf("content type attribute '%s' does not match eContentType '%s'",
+				contentType, eContentType)
+		}
+		return nil
+	}
+	return errors.New("content type attribute is missing")
+}
+
+// findSignerCertificate selects the certificate referenced by the signer
+// identifier, either by issuer and serial number or by subject key identifier.
+func findSignerCertificate(
+	sid asn1.RawValue, certificates []*x509.Certificate,
+) (*x509.Certificate, error) {
+	if len(certificates) == 0 {
+		return nil, errors.New("signer certificate is missing")
+	}
+
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if err := unmarshalDER(sid.FullBytes, &ias); err != nil {
+			return nil, fmt.Errorf("failed to parse signer identifier: %w", err)
+		}
+		for _, c := range certificates {
+			if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) &&
+				c.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+				return c, nil
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, c := range certificates {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signer identifier tag %d", sid.Tag)
+	}
+	return nil, errors.New("signer certificate not found for signer identifier")
+}
+
+func unmarshalDER(data []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("%d trailing bytes", len(rest))
+	}
+	return nil
+}
+
+// VerifyDataGroup checks that data hashes to the value stored in the SOD
+// for the data group number. data must include the data group tag.
+func (s *SOD) VerifyDataGroup(number int, data []byte) error {
+	expected, ok := s.DataGroupHashes[number]
+	if !ok {
+		return fmt.Errorf("%w: DG%d is not present in the SOD", ErrDataGroupHashMismatch, number)
+	}
+	h := s.DigestAlgorithm.New()
+	h.Write(data)
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		return fmt.Errorf("%w: DG%d hash %x does not match SOD hash %x",
+			ErrDataGroupHashMismatch, number, actual, expected)
+	}
+	return nil
+}
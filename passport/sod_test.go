@@ -0,0 +1,341 @@
+package passport
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMRZ = "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<" +
+	"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02"
+
+type testAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// testSigner is a key with its certificate used to build test documents.
+type testSigner struct {
+	key  crypto.Signer
+	cert *x509.Certificate
+}
+
+func newTestECKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// newTestSigner issues a certificate for key. The certificate is self-signed
+// CSCA when parent is nil, or a Document Signer certificate otherwise.
+func newTestSigner(
+	t *testing.T, key crypto.Signer, parent *testSigner, serial int64, notAfter time.Time,
+) *testSigner {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     notAfter,
+		SubjectKeyId: []byte{byte(serial), 1, 2, 3},
+	}
+	signerCert, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.Subject = pkix.Name{Country: []string{"UTO"}, CommonName: "Test CSCA"}
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		tmpl.Subject = pkix.Name{Country: []string{"UTO"}, CommonName: "Test Document Signer"}
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, key.Public(), signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testSigner{key: key, cert: cert}
+}
+
+func newTestDocumentSigner(t *testing.T) *testSigner {
+	t.Helper()
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	csca := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	return newTestSigner(t, newTestECKey(t), csca, 2, notAfter)
+}
+
+func mustMarshal(t *testing.T, v interface{}, params string) []byte {
+	t.Helper()
+	b, err := asn1.MarshalWithParams(v, params)
+	require.NoError(t, err)
+	return b
+}
+
+func testHashOID(t *testing.T, h crypto.Hash) asn1.ObjectIdentifier {
+	t.Helper()
+	for _, alg := range digestAlgorithms {
+		if alg.hash == h {
+			return alg.oid
+		}
+	}
+	t.Fatalf("unsupported hash %s", h)
+	return nil
+}
+
+// testSignatureOID returns the signature algorithm of the key combined with
+// the hash.
+func testSignatureOID(t *testing.T, key crypto.Signer, h crypto.Hash) asn1.ObjectIdentifier {
+	t.Helper()
+	scheme := schemeECDSA
+	if _, ok := key.Public().(*rsa.PublicKey); ok {
+		scheme = schemeRSAPKCS1
+	}
+	for _, alg := range signatureAlgorithms {
+		if alg.scheme == scheme && alg.hash == h {
+			return alg.oid
+		}
+	}
+	t.Fatalf("unsupported signature hash %s", h)
+	return nil
+}
+
+// newTestSOD builds an EF.SOD with the hashes of the data groups signed by
+// the Document Signer.
+func newTestSOD(
+	t *testing.T, ds *testSigner, hash crypto.Hash, dataGroups map[int][]byte,
+) []byte {
+	t.Helper()
+	lds := ldsSecurityObject{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: testHashOID(t, hash)}}
+	for i := 1; i <= 16; i++ {
+		dg, ok := dataGroups[i]
+		if !ok {
+			continue
+		}
+		h := hash.New()
+		h.Write(dg)
+		lds.DataGroupHashValues = append(lds.DataGroupHashValues, dataGroupHash{
+			DataGroupNumber:    i,
+			DataGroupHashValue: h.Sum(nil),
+		})
+	}
+	return newTestSODWith(t, ds, hash, lds, oidLDSSecurityObject)
+}
+
+// newTestSODWith builds an EF.SOD with the LDS security object and the
+// content type attribute signed by the Document Signer.
+func newTestSODWith(
+	t *testing.T, ds *testSigner, hash crypto.Hash, lds ldsSecurityObject,
+	contentType asn1.ObjectIdentifier,
+) []byte {
+	t.Helper()
+	ci := newTestSignedData(t, ds, hash, lds.HashAlgorithm, oidLDSSecurityObject,
+		mustMarshal(t, lds, ""), contentType)
+	return mustMarshal(t, asn1.RawValue{
+		Class: asn1.ClassApplication, Tag: 23, IsCompound: true, Bytes: ci,
+	}, "")
+}
+
+// newTestSignedData builds a CMS ContentInfo with the eContent and the
+// content type attribute signed by the signer.
+func newTestSignedData(
+	t *testing.T, signer *testSigner, hash crypto.Hash, hashAlgorithm pkix.AlgorithmIdentifier,
+	eContentType asn1.ObjectIdentifier, eContent []byte, contentType asn1.ObjectIdentifier,
+) []byte {
+	t.Helper()
+	eContentHash := hash.New()
+	eContentHash.Write(eContent)
+	attrs := []testAttribute{
+		{
+			Type: oidAttributeContentType,
+			Values: asn1.RawValue{FullBytes: mustMarshal(t,
+				[]asn1.ObjectIdentifier{contentType}, "set")},
+		},
+		{
+			Type: oidAttributeMessageDigest,
+			Values: asn1.RawValue{FullBytes: mustMarshal(t,
+				[][]byte{eContentHash.Sum(nil)}, "set")},
+		},
+	}
+	signedAttrs := mustMarshal(t, attrs, "set")
+
+	attrsHash := hash.New()
+	attrsHash.Write(signedAttrs)
+	signature, err := signer.key.Sign(rand.Reader, attrsHash.Sum(nil), hash)
+	require.NoError(t, err)
+
+	taggedAttrs := append([]byte{0xA0}, signedAttrs[1:]...)
+	sd := signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{hashAlgorithm},
+		EncapContentInfo: encapContentInfo{
+			EContentType: eContentType,
+			EContent:     eContent,
+		},
+		Certificates: asn1.RawValue{
+			Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signer.cert.Raw,
+		},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			SID: asn1.RawValue{FullBytes: mustMarshal(t, issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: signer.cert.RawIssuer},
+				SerialNumber: signer.cert.SerialNumber,
+			}, "")},
+			DigestAlgorithm: hashAlgorithm,
+			SignedAttrs:     asn1.RawValue{FullBytes: taggedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm: testSignatureOID(t, signer.key, hash),
+			},
+			Signature: signature,
+		}},
+	}
+	return mustMarshal(t, contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{FullBytes: mustMarshal(t, sd, "explicit,tag:0")},
+	}, "")
+}
+
+func TestParseSOD(t *testing.T) {
+	ds := newTestDocumentSigner(t)
+	dg1, err := hex.DecodeString(mrzToDg1(testMRZ))
+	require.NoError(t, err)
+	dg2 := []byte{0x75, 0x03, 0x01, 0x02, 0x03}
+
+	for _, h := range []crypto.Hash{
+		crypto.SHA1, crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512,
+	} {
+		t.Run(h.String(), func(t *testing.T) {
+			sodRaw := newTestSOD(t, ds, h, map[int][]byte{1: dg1, 2: dg2})
+
+			sod, err := ParseSOD(hex.EncodeToString(sodRaw))
+			require.NoError(t, err)
+			require.Equal(t, h, sod.DigestAlgorithm)
+			require.Equal(t, h, sod.SignerDigestAlgorithm)
+			require.Len(t, sod.DataGroupHashes, 2)
+			require.Len(t, sod.DataGroupHashes[1], h.Size())
+			require.Equal(t, ds.cert.Raw, sod.Certificate.Raw)
+			require.Equal(t, byte(0x31), sod.SignedAttributes[0])
+			require.Equal(t, sodRaw, sod.Raw)
+
+			require.NoError(t, sod.VerifyDataGroup(1, dg1))
+			require.NoError(t, sod.VerifyDataGroup(2, dg2))
+			require.ErrorIs(t, sod.VerifyDataGroup(1, dg2), ErrDataGroupHashMismatch)
+			require.ErrorIs(t, sod.VerifyDataGroup(3, dg2), ErrDataGroupHashMismatch)
+		})
+	}
+}
+
+func TestParseSOD_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not hex", input: "zz"},
+		{name: "Wrong tag", input: "7603020100"},
+		{name: "Not CMS", input: "7703020100"},
+		{name: "Truncated", input: "7710300e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSOD(tt.input)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseSOD_BrainpoolDocumentSigner(t *testing.T) {
+	notAfter := time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	csca := newTestSigner(t, newTestECKey(t), nil, 1, notAfter)
+	dg1Hex := mrzToDg1(testMRZ)
+	dg1, err := hex.DecodeString(dg1Hex)
+	require.NoError(t, err)
+
+	for _, curveOID := range []asn1.ObjectIdentifier{oidTestBrainpoolP256r1, nil} {
+		key := newTestCurveKey(t, standardCurves[13])
+		ds := newTestCurveSigner(t, key, curveOID, csca, 2, notAfter)
+		sodRaw := newTestSOD(t, ds, crypto.SHA256, map[int][]byte{1: dg1})
+
+		sod, err := ParseSOD(hex.EncodeToString(sodRaw))
+		require.NoError(t, err)
+		require.Equal(t, ds.cert.Raw, sod.Certificate.Raw)
+		require.Equal(t, key.Public(), sod.Certificate.PublicKey)
+		require.NoError(t, sod.VerifyDataGroup(1, dg1))
+
+		inputs := PassportV1Inputs{
+			PassportData:        dg1Hex,
+			IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+			CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+			CredentialStatusID:  "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+			IssuanceDate:        time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+			LinkNonce:           "1",
+			SOD:                 hex.EncodeToString(sodRaw),
+		}
+		_, err = inputs.InputsMarshal()
+		require.NoError(t, err)
+	}
+}
+
+func TestParseSOD_InvalidSecurityObject(t *testing.T) {
+	ds := newTestDocumentSigner(t)
+	hashAlgorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	dgHash := dataGroupHash{DataGroupNumber: 1, DataGroupHashValue: make([]byte, 32)}
+
+	t.Run("Duplicate data group", func(t *testing.T) {
+		lds := ldsSecurityObject{
+			HashAlgorithm:       hashAlgorithm,
+			DataGroupHashValues: []dataGroupHash{dgHash, dgHash},
+		}
+		_, err := parseSOD(newTestSODWith(t, ds, crypto.SHA256, lds, oidLDSSecurityObject))
+		require.ErrorContains(t, err, "duplicate hash of DG1")
+	})
+
+	t.Run("Content type mismatch", func(t *testing.T) {
+		lds := ldsSecurityObject{
+			HashAlgorithm:       hashAlgorithm,
+			DataGroupHashValues: []dataGroupHash{dgHash},
+		}
+		_, err := parseSOD(newTestSODWith(t, ds, crypto.SHA256, lds, oidCSCAMasterList))
+		require.ErrorContains(t, err, "does not match eContentType")
+	})
+}
+
+func TestPassportV1Inputs_SOD(t *testing.T) {
+	ds := newTestDocumentSigner(t)
+	dg1Hex := mrzToDg1(testMRZ)
+	dg1, err := hex.DecodeString(dg1Hex)
+	require.NoError(t, err)
+
+	inputs := PassportV1Inputs{
+		PassportData:        dg1Hex,
+		IssuerID:            "did:iden3:privado:main:2Si3eZUE6XetYsmU5dyUK2Cvaxr1EEe65vdv2BML4L",
+		CredentialSubjectID: "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		CredentialStatusID:  "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G",
+		IssuanceDate:        time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC).Unix(),
+		LinkNonce:           "1",
+		SOD:                 hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256, map[int][]byte{1: dg1})),
+	}
+	_, err = inputs.W3CCredential()
+	require.NoError(t, err)
+
+	otherDG1, err := hex.DecodeString(mrzToDg1(
+		"P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<" +
+			"AC12345684UKR9603091M3508035<<<<<<<<<<<<<<02",
+	))
+	require.NoError(t, err)
+	inputs.SOD = hex.EncodeToString(newTestSOD(t, ds, crypto.SHA256, map[int][]byte{1: otherDG1}))
+	_, err = inputs.W3CCredential()
+	require.ErrorIs(t, err, ErrDataGroupHashMismatch)
+	_, err = inputs.InputsMarshal()
+	require.ErrorIs(t, err, ErrDataGroupHashMismatch)
+}
@@ -0,0 +1,189 @@
+package passport
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedTLV is returned when data is not a valid BER-TLV encoding.
+var ErrMalformedTLV = errors.New("malformed BER-TLV data")
+
+// maxTagSize is the maximum supported size of a tag in bytes. ICAO 9303 LDS
+// uses tags of up to two bytes, three byte tags of ISO/IEC 7816-4 data
+// objects are accepted as well, see encodeTLV.
+const maxTagSize = 3
+
+// tlv is a single BER-TLV data object.
+// https://www.icao.int/publications/Documents/9303_p10_cons_en.pdf page 32
+type tlv struct {
+	tag   uint32 // tag with all tag bytes, e.g. 0x5F1F
+	value []byte // content bytes
+	raw   []byte // tag, length and content bytes
+}
+
+// constructed reports whether the value of the object is a sequence of
+// nested data objects.
+func (t tlv) constructed() bool {
+	first := t.tag
+	for first > 0xFF {
+		first >>= 8
+	}
+	return first&0x20 != 0
+}
+
+// children decodes the nested data objects of a constructed object.
+func (t tlv) children() ([]tlv, error) {
+	if !t.constructed() {
+		return nil, fmt.Errorf("%w: tag 0x%X is not constructed", ErrMalformedTLV, t.tag)
+	}
+	return decodeTLVs(t.value)
+}
+
+// decodeTLV decodes the first data object in data and returns
+// the bytes following it.
+func decodeTLV(data []byte) (obj tlv, rest []byte, err error) {
+	tag, tagSize, err := decodeTag(data)
+	if err != nil {
+		return tlv{}, nil, err
+	}
+	length, lengthSize, err := decodeLength(data[tagSize:], len(data)-tagSize)
+	if err != nil {
+		return tlv{}, nil, fmt.Errorf("tag 0x%X: %w", tag, err)
+	}
+	headerSize := tagSize + lengthSize
+	if length > len(data)-headerSize {
+		return tlv{}, nil, fmt.Errorf(
+			"%w: tag 0x%X: length %d exceeds available %d bytes",
+			ErrMalformedTLV, tag, length, len(data)-headerSize,
+		)
+	}
+	end := headerSize + length
+	return tlv{
+		tag:   tag,
+		value: data[headerSize:end],
+		raw:   data[:end],
+	}, data[end:], nil
+}
+
+// decodeTLVs decodes all consecutive data objects in data.
+func decodeTLVs(data []byte) ([]tlv, error) {
+	var objs []tlv
+	for len(data) > 0 {
+		obj, rest, err := decodeTLV(data)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+		data = rest
+	}
+	return objs, nil
+}
+
+// decodeDataGroup decodes a data group envelope with the tag and returns
+// its nested data objects.
+func decodeDataGroup(data []byte, name string, tag uint32) ([]tlv, error) {
+	dg, rest, err := decodeTLV(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes after %s", ErrMalformedTLV, len(rest), name)
+	}
+	if dg.tag != tag {
+		return nil, fmt.Errorf(
+			"%w: expected %s tag 0x%X, got 0x%X",
+			ErrMalformedTLV, name, tag, dg.tag,
+		)
+	}
+	return dg.children()
+}
+
+// encodeTLV encodes a data object with a one, two or three byte tag.
+func encodeTLV(tag uint32, value []byte) []byte {
+	var out []byte
+	switch {
+	case tag > 0xFFFF:
+		out = []byte{byte(tag >> 16), byte(tag >> 8), byte(tag)}
+	case tag > 0xFF:
+		out = []byte{byte(tag >> 8), byte(tag)}
+	default:
+		out = []byte{byte(tag)}
+	}
+	switch n := len(value); {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xFF:
+		out = append(out, 0x81, byte(n))
+	case n <= 0xFFFF:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(out, value...)
+}
+
+// findTLV returns the first data object with the tag.
+func findTLV(objs []tlv, tag uint32) (tlv, bool) {
+	for _, obj := range objs {
+		if obj.tag == tag {
+			return obj, true
+		}
+	}
+	return tlv{}, false
+}
+
+func decodeTag(data []byte) (tag uint32, size int, err error) {
+	if len(data) == 0 {
+		return 0, 0, fmt.Errorf("%w: missing tag", ErrMalformedTLV)
+	}
+	tag = uint32(data[0])
+	size = 1
+	// low five bits set mean the tag number continues in subsequent bytes
+	if data[0]&0x1F != 0x1F {
+		return tag, size, nil
+	}
+	for {
+		if size == len(data) {
+			return 0, 0, fmt.Errorf("%w: truncated tag", ErrMalformedTLV)
+		}
+		if size == maxTagSize {
+			return 0, 0, fmt.Errorf("%w: tag is longer than %d bytes", ErrMalformedTLV, maxTagSize)
+		}
+		b := data[size]
+		tag = tag<<8 | uint32(b)
+		size++
+		if b&0x80 == 0 {
+			return tag, size, nil
+		}
+	}
+}
+
+// decodeLength decodes the length field at the start of data. Lengths above
+// limit are rejected before the conversion to int: four length bytes
+// overflow int on 32-bit platforms.
+func decodeLength(data []byte, limit int) (length, size int, err error) {
+	if len(data) == 0 {
+		return 0, 0, fmt.Errorf("%w: missing length", ErrMalformedTLV)
+	}
+	value, size := uint64(data[0]), 1
+	if first := data[0]; first >= 0x80 {
+		if first == 0x80 {
+			return 0, 0, fmt.Errorf("%w: indefinite length is not supported", ErrMalformedTLV)
+		}
+		n := int(first & 0x7F)
+		if n > 4 {
+			return 0, 0, fmt.Errorf("%w: length field of %d bytes is too long", ErrMalformedTLV, n)
+		}
+		if n >= len(data) {
+			return 0, 0, fmt.Errorf("%w: truncated length", ErrMalformedTLV)
+		}
+		value, size = 0, n+1
+		for _, b := range data[1:size] {
+			value = value<<8 | uint64(b)
+		}
+	}
+	if value > uint64(limit) {
+		return 0, 0, fmt.Errorf("%w: length %d exceeds %d bytes", ErrMalformedTLV, value, limit)
+	}
+	return int(value), size, nil
+}
@@ -0,0 +1,189 @@
+package passport
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTLV(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedTag   uint32
+		expectedValue string
+		expectedRest  string
+	}{
+		{
+			name:          "One byte tag, short length",
+			input:         "8002AABB",
+			expectedTag:   0x80,
+			expectedValue: "aabb",
+		},
+		{
+			name:          "Two byte tag",
+			input:         "5F1F03414243",
+			expectedTag:   0x5F1F,
+			expectedValue: "414243",
+		},
+		{
+			name:          "Long form length with one byte",
+			input:         "618103010203",
+			expectedTag:   0x61,
+			expectedValue: "010203",
+		},
+		{
+			name:          "Long form length with two bytes",
+			input:         "7F61820002AABBCC",
+			expectedTag:   0x7F61,
+			expectedValue: "aabb",
+			expectedRest:  "cc",
+		},
+		{
+			name:          "Empty value",
+			input:         "0400",
+			expectedTag:   0x04,
+			expectedValue: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.input)
+			require.NoError(t, err)
+			obj, rest, err := decodeTLV(data)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTag, obj.tag)
+			require.Equal(t, tt.expectedValue, hex.EncodeToString(obj.value))
+			require.Equal(t, tt.expectedRest, hex.EncodeToString(rest))
+		})
+	}
+}
+
+func TestEncodeTLV(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      uint32
+		length   int
+		expected string
+	}{
+		{name: "One byte tag, short length", tag: 0x80, length: 2, expected: "8002"},
+		{name: "Two byte tag", tag: 0x5F1F, length: 0x7F, expected: "5f1f7f"},
+		{name: "One byte length", tag: 0x61, length: 0x80, expected: "618180"},
+		{name: "Two byte length", tag: 0x7F61, length: 0x100, expected: "7f61820100"},
+		{name: "Three byte length", tag: 0x5F2E, length: 0x10000, expected: "5f2e83010000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := make([]byte, tt.length)
+			encoded := encodeTLV(tt.tag, value)
+			require.Equal(t, tt.expected, hex.EncodeToString(encoded[:len(encoded)-tt.length]))
+
+			obj, rest, err := decodeTLV(encoded)
+			require.NoError(t, err)
+			require.Empty(t, rest)
+			require.Equal(t, tt.tag, obj.tag)
+			require.Equal(t, value, obj.value)
+		})
+	}
+}
+
+func TestDecodeTLV_Malformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Empty", input: ""},
+		{name: "Missing length", input: "61"},
+		{name: "Truncated tag", input: "5F"},
+		{name: "Too long tag", input: "5F818101"},
+		{name: "Value shorter than length", input: "6105AABB"},
+		{name: "Indefinite length", input: "6180AABB0000"},
+		{name: "Truncated long form length", input: "6182AA"},
+		{name: "Too long length field", input: "6185AABBCCDDEE"},
+		{name: "Four byte length beyond data", input: "6184FFFFFFFFAABB"},
+		{name: "Long form length beyond data", input: "61820100AABB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.input)
+			require.NoError(t, err)
+			_, _, err = decodeTLV(data)
+			require.ErrorIs(t, err, ErrMalformedTLV)
+		})
+	}
+}
+
+func TestTLVChildren(t *testing.T) {
+	data, err := hex.DecodeString("610A5F1F0241428001FF0400")
+	require.NoError(t, err)
+	obj, _, err := decodeTLV(data)
+	require.NoError(t, err)
+	require.True(t, obj.constructed())
+
+	children, err := obj.children()
+	require.NoError(t, err)
+	require.Len(t, children, 3)
+
+	mrz, ok := findTLV(children, 0x5F1F)
+	require.True(t, ok)
+	require.False(t, mrz.constructed())
+	require.Equal(t, []byte("AB"), mrz.value)
+
+	_, err = mrz.children()
+	require.ErrorIs(t, err, ErrMalformedTLV)
+
+	_, ok = findTLV(children, 0x5F2E)
+	require.False(t, ok)
+}
+
+func TestParseDG1_Envelope(t *testing.T) {
+	mrz := "P<UKRKUZNETSOV<<VALERIY<<<<<<<<<<<<<<<<<<<<<" +
+		"AC12345674UKR9603091M3508035<<<<<<<<<<<<<<02"
+
+	t.Run("Long form lengths", func(t *testing.T) {
+		data := append([]byte{0x61, 0x81, 0x5C, 0x5F, 0x1F, 0x81, 0x58}, mrz...)
+		p, err := ParseDG1(hex.EncodeToString(data))
+		require.NoError(t, err)
+		require.Equal(t, "AC1234567", p.DocumentNumber)
+		require.Equal(t, data, p.Raw)
+	})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "Wrong data group tag",
+			data: append([]byte{0x75, 0x5B, 0x5F, 0x1F, 0x58}, mrz...),
+		},
+		{
+			name: "Missing MRZ data element",
+			data: append([]byte{0x61, 0x5B, 0x5F, 0x2E, 0x58}, mrz...),
+		},
+		{
+			name: "Truncated envelope",
+			data: append([]byte{0x61, 0x5C, 0x5F, 0x1F, 0x58}, mrz...),
+		},
+		{
+			name: "Trailing bytes",
+			data: append(append([]byte{0x61, 0x5B, 0x5F, 0x1F, 0x58}, mrz...), 0x00),
+		},
+		{
+			name: "Too short",
+			data: []byte{0x61, 0x03},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDG1(hex.EncodeToString(tt.data))
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrMalformedTLV), err.Error())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package passport
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -8,6 +9,8 @@ import (
 
 const (
 	circomYearSeconds = 31536000
+	// maxValidityPolicyDays bounds the day counts of ValidityPolicy.
+	maxValidityPolicyDays = 100 * 366
 )
 
 // FormatDate formats date to 8 digits format
@@ -31,20 +34,89 @@ func intToTime(dateInt int) time.Time {
 	)
 }
 
+// DatePolicy configures the checks of the MRZ date of birth. The century is
+// always resolved like the circuit does: birth dates after the issuance date
+// belong to the 1900s. Dates with an unknown day or month are not supported.
+type DatePolicy struct {
+	// MaxAge is the maximum plausible age in years. Zero means no limit.
+	MaxAge int `json:"maxAge,omitempty"`
+}
+
+var (
+	ErrInvalidDate            = errors.New("invalid MRZ date")
+	ErrUnknownDate            = errors.New("MRZ date has unknown day or month")
+	ErrImplausibleDateOfBirth = errors.New("implausible date of birth")
+	ErrInvalidDatePolicy      = errors.New("invalid date policy")
+)
+
+func (p DatePolicy) validate() error {
+	if p.MaxAge < 0 {
+		return fmt.Errorf("%w: maxAge must not be negative", ErrInvalidDatePolicy)
+	}
+	return nil
+}
+
+// ValidityPolicy configures the checks of the document expiry. The zero
+// value matches the circuit: the document must not be expired. The circuit
+// rejects expired documents and issues the credential for one year capped
+// at the document expiry, so a grace period or a shorter credential
+// lifetime can not be configured.
+type ValidityPolicy struct {
+	// MinRemainingDays is the minimum number of days the document
+	// must stay valid after issuance of the credential.
+	MinRemainingDays int `json:"minRemainingDays,omitempty"`
+}
+
+var (
+	ErrDocumentExpired       = errors.New("document is expired")
+	ErrInsufficientValidity  = errors.New("document validity is too short")
+	ErrInvalidValidityPolicy = errors.New("invalid validity policy")
+)
+
+func (p ValidityPolicy) validate() error {
+	if p.MinRemainingDays < 0 {
+		return fmt.Errorf("%w: minRemainingDays must not be negative", ErrInvalidValidityPolicy)
+	}
+	if p.MinRemainingDays > maxValidityPolicyDays {
+		return fmt.Errorf("%w: minRemainingDays must not exceed %d days",
+			ErrInvalidValidityPolicy, maxValidityPolicyDays)
+	}
+	return nil
+}
+
+// checkDocument verifies the document expiry against the issuance date.
+func (p ValidityPolicy) checkDocument(today, doe time.Time) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if doe.Before(day) {
+		return fmt.Errorf("%w: expired on %s", ErrDocumentExpired, doe.Format(time.DateOnly))
+	}
+	if p.MinRemainingDays > 0 && doe.Before(day.AddDate(0, 0, p.MinRemainingDays)) {
+		return fmt.Errorf("%w: expires on %s, less than %d days after issuance",
+			ErrInsufficientValidity, doe.Format(time.DateOnly), p.MinRemainingDays)
+	}
+	return nil
+}
+
 // dateOfBirth and dateOfExpiry are provided in YYMMDD format from passport
-// this function uses issuanceDate(time.Now) do define the year.
-func convertData(today time.Time, dateOfBirth, dateOfExpiry string) (
+// this function uses issuanceDate(time.Now) and the policy to define the year.
+func convertData(today time.Time, dateOfBirth, dateOfExpiry string, policy DatePolicy) (
 	dob, doe time.Time, err error,
 ) {
-	dobInt, err := strconv.Atoi(dateOfBirth)
+	if err = policy.validate(); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	dobYear, dobMonth, dobDay, err := parseMRZDate(dateOfBirth)
 	if err != nil {
 		return time.Time{}, time.Time{},
-			fmt.Errorf("failed to convert date of birth to int: %w", err)
+			fmt.Errorf("failed to parse date of birth '%s': %w", dateOfBirth, err)
 	}
-	expiryInt, err := strconv.Atoi(dateOfExpiry)
+	expiryYear, expiryMonth, expiryDay, err := parseMRZDate(dateOfExpiry)
 	if err != nil {
 		return time.Time{}, time.Time{},
-			fmt.Errorf("failed to convert date of expiry to int: %w", err)
+			fmt.Errorf("failed to parse date of expiry '%s': %w", dateOfExpiry, err)
 	}
 	todayInt, err := strconv.Atoi(today.Format("060102"))
 	if err != nil {
@@ -53,21 +125,66 @@ func convertData(today time.Time, dateOfBirth, dateOfExpiry string) (
 	}
 
 	// define dob
-	dobInt = formatDate(dobInt, todayInt)
+	dobInt := formatDate(dobYear*10000+dobMonth*100+dobDay, todayInt)
+	dob, err = calendarDate(dobInt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date of birth: %w", err)
+	}
+	if dob.After(today) {
+		return time.Time{}, time.Time{},
+			fmt.Errorf("%w: %s is in the future", ErrImplausibleDateOfBirth, dob.Format(time.DateOnly))
+	}
+	if policy.MaxAge > 0 && dob.AddDate(policy.MaxAge, 0, 0).Before(today) {
+		return time.Time{}, time.Time{},
+			fmt.Errorf("%w: %s is more than %d years ago",
+				ErrImplausibleDateOfBirth, dob.Format(time.DateOnly), policy.MaxAge)
+	}
 
 	// define doe
-	if expiryInt < todayInt {
-		return time.Time{}, time.Time{},
-			fmt.Errorf("passport is expired")
+	expiryInt := 20000000 + expiryYear*10000 + expiryMonth*100 + expiryDay
+	doe, err = calendarDate(expiryInt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date of expiry: %w", err)
 	}
-	expiryInt += 20000000
 
-	return intToTime(dobInt), intToTime(expiryInt), nil
+	return dob, doe, nil
+}
+
+// parseMRZDate parses a YYMMDD date and checks that it is a calendar date.
+// Unknown day or month, marked with "<<" or "XX", is rejected with
+// ErrUnknownDate: the circuit only proves dates made of digits, so documents
+// with a partial date of birth are not supported.
+func parseMRZDate(date string) (year, month, day int, err error) {
+	if len(date) != 6 {
+		return 0, 0, 0, fmt.Errorf("%w: expected 6 characters, got %d", ErrInvalidDate, len(date))
+	}
+	parts := [3]*int{&year, &month, &day}
+	for i, part := range parts {
+		value := date[i*2 : i*2+2]
+		if i > 0 && (value == "<<" || value == "XX") {
+			return 0, 0, 0, ErrUnknownDate
+		}
+		if value[0] < '0' || value[0] > '9' || value[1] < '0' || value[1] > '9' {
+			return 0, 0, 0, fmt.Errorf("%w: unexpected characters '%s'", ErrInvalidDate, value)
+		}
+		*part = int(value[0]-'0')*10 + int(value[1]-'0')
+	}
+	return year, month, day, nil
+}
+
+// calendarDate converts the YYYYMMDD date to time and checks that it is
+// a calendar date, e.g. not February 30.
+func calendarDate(dateInt int) (time.Time, error) {
+	t := intToTime(dateInt)
+	if t.Month() != time.Month((dateInt%10000)/100) || t.Day() != dateInt%100 {
+		return time.Time{}, fmt.Errorf("%w: %d is not a calendar date", ErrInvalidDate, dateInt)
+	}
+	return t, nil
 }
 
 func calculateExpirationDate(passportExpirationDate, currentDate time.Time) time.Time {
 	diff := passportExpirationDate.Sub(currentDate)
-	if diff.Seconds() < 31536000 {
+	if diff.Seconds() < circomYearSeconds {
 		return passportExpirationDate
 	}
 	return currentDate.Add(circomYearSeconds * time.Second)
@@ -1,6 +1,7 @@
 package passport
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -51,7 +52,7 @@ func TestConvertData_Success(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			dob, doe, err := convertData(tt.today, tt.dateOfBirth, tt.dateOfExpiry)
+			dob, doe, err := convertData(tt.today, tt.dateOfBirth, tt.dateOfExpiry, DatePolicy{})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -65,6 +66,195 @@ func TestConvertData_Success(t *testing.T) {
 	}
 }
 
+func TestConvertData_Policy(t *testing.T) {
+	today := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		dateOfBirth string
+		policy      DatePolicy
+		expectedDOB time.Time
+	}{
+		{
+			name:        "Born this year before issuance",
+			dateOfBirth: "240115",
+			expectedDOB: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Within max age",
+			dateOfBirth: "250302",
+			policy:      DatePolicy{MaxAge: 100},
+			expectedDOB: time.Date(1925, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Leap day",
+			dateOfBirth: "000229",
+			expectedDOB: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dob, _, err := convertData(today, tt.dateOfBirth, "301231", tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !dob.Equal(tt.expectedDOB) {
+				t.Errorf("expected DOB %v, got %v", tt.expectedDOB, dob)
+			}
+		})
+	}
+}
+
+func TestConvertData_Errors(t *testing.T) {
+	today := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		dateOfBirth  string
+		dateOfExpiry string
+		policy       DatePolicy
+		expectedErr  error
+	}{
+		{
+			name:         "Unknown day",
+			dateOfBirth:  "9603<<",
+			dateOfExpiry: "301231",
+			expectedErr:  ErrUnknownDate,
+		},
+		{
+			name:         "Unknown day and month",
+			dateOfBirth:  "96XXXX",
+			dateOfExpiry: "301231",
+			expectedErr:  ErrUnknownDate,
+		},
+		{
+			name:         "Unknown expiry day",
+			dateOfBirth:  "960309",
+			dateOfExpiry: "3012XX",
+			expectedErr:  ErrUnknownDate,
+		},
+		{
+			name:         "Month out of range",
+			dateOfBirth:  "961309",
+			dateOfExpiry: "301231",
+			expectedErr:  ErrInvalidDate,
+		},
+		{
+			name:         "Not a calendar date",
+			dateOfBirth:  "960230",
+			dateOfExpiry: "301231",
+			expectedErr:  ErrInvalidDate,
+		},
+		{
+			name:         "Not a leap year",
+			dateOfBirth:  "970229",
+			dateOfExpiry: "301231",
+			expectedErr:  ErrInvalidDate,
+		},
+		{
+			name:         "Unknown year",
+			dateOfBirth:  "<<0309",
+			dateOfExpiry: "301231",
+			expectedErr:  ErrInvalidDate,
+		},
+		{
+			name:         "Older than max age",
+			dateOfBirth:  "250301",
+			dateOfExpiry: "301231",
+			policy:       DatePolicy{MaxAge: 90},
+			expectedErr:  ErrImplausibleDateOfBirth,
+		},
+		{
+			name:         "Negative max age",
+			dateOfBirth:  "960309",
+			dateOfExpiry: "301231",
+			policy:       DatePolicy{MaxAge: -1},
+			expectedErr:  ErrInvalidDatePolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := convertData(today, tt.dateOfBirth, tt.dateOfExpiry, tt.policy)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidityPolicy(t *testing.T) {
+	today := time.Date(2024, 3, 1, 17, 28, 52, 0, time.UTC)
+	tests := []struct {
+		name               string
+		policy             ValidityPolicy
+		doe                time.Time
+		expectedErr        error
+		expectedExpiration time.Time
+	}{
+		{
+			name:               "Default",
+			doe:                time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC),
+			expectedExpiration: today.Add(circomYearSeconds * time.Second),
+		},
+		{
+			name:               "Expires today",
+			doe:                time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			expectedExpiration: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Expired yesterday",
+			doe:         time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			expectedErr: ErrDocumentExpired,
+		},
+		{
+			name:               "Enough remaining validity",
+			policy:             ValidityPolicy{MinRemainingDays: 90},
+			doe:                time.Date(2024, 5, 30, 0, 0, 0, 0, time.UTC),
+			expectedExpiration: time.Date(2024, 5, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Not enough remaining validity",
+			policy:      ValidityPolicy{MinRemainingDays: 90},
+			doe:         time.Date(2024, 5, 29, 0, 0, 0, 0, time.UTC),
+			expectedErr: ErrInsufficientValidity,
+		},
+		{
+			name:        "Minimum remaining validity too long",
+			policy:      ValidityPolicy{MinRemainingDays: maxValidityPolicyDays + 1},
+			doe:         time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC),
+			expectedErr: ErrInvalidValidityPolicy,
+		},
+		{
+			name:        "Negative minimum remaining validity",
+			policy:      ValidityPolicy{MinRemainingDays: -1},
+			doe:         time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC),
+			expectedErr: ErrInvalidValidityPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.checkDocument(today, tt.doe)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expiration := calculateExpirationDate(tt.doe, today)
+			if !expiration.Equal(tt.expectedExpiration) {
+				t.Errorf("expected %v, got %v", tt.expectedExpiration, expiration)
+			}
+		})
+	}
+}
+
 func TestDefineExpirationDate(t *testing.T) {
 	tests := []struct {
 		name                   string